@@ -0,0 +1,332 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/sirupsen/logrus"
+)
+
+// commandComedian is the single entry point for all commands: /comedian <subcommand> args
+const commandComedian = "/comedian"
+
+// command describes a command Comedian understands
+type command struct {
+	// Name is used as /comedian subcommand and as a standalone slash command
+	Name string
+	// Aliases are alternative names of the command
+	Aliases []string
+	// Global commands are not about a channel, they can be sent in direct messages without naming one
+	Global bool
+	// Access is required to run the command
+	Access access
+	// ArgAccess is required instead of Access when an argument of the command is the key, like "on" or "/ admin".
+	// The empty key matches the command without arguments. If several arguments are keys, access of each is required
+	ArgAccess map[string]access
+	// Usage is a localized description of command arguments, handlers parse and check arguments themselves
+	Usage string
+	// Help is a localized description of the command
+	Help string
+	// Handler runs the command
+	Handler func(c echo.Context, f url.Values) error
+}
+
+// access is a permission a command requires and the channel it is checked in
+type access struct {
+	// Permission is required, access without permission is given to everyone
+	Permission string
+	// ChannelArg is the position of the argument, starting from 1, which names the channel the permission is checked in.
+	// When it is 0 or the argument is missing, the permission is checked in all channels if Workspace is set,
+	// or in the channel the command is sent from
+	ChannelArg int
+	Workspace  bool
+	// ChannelOf finds the channel by the argument, channel names like #general are expected by default.
+	// Its error is the reply to the command
+	ChannelOf func(arg string) (string, error)
+	// UserArg is the position of the argument naming the user whose standups the command shows,
+	// OwnPermission is enough when it is the user who runs the command
+	UserArg       int
+	OwnPermission string
+}
+
+// languageArg names languages Comedian has translations for and the default one
+func languageArg() string {
+	return strings.Join(append(append([]string{}, config.Languages...), "default"), " | ")
+}
+
+// registerCommands builds the registry of commands
+func (r *REST) registerCommands() {
+	t := r.conf.Translate
+	formats := strings.Join(reporting.Formats, " | ")
+	manageSchedule := map[string]access{"on": {Permission: model.PermScheduleManage}, "off": {Permission: model.PermScheduleManage}}
+	r.commands = []command{
+		{Name: "help", Aliases: []string{"helper", "помощь"}, Global: true, Usage: t.UsageHelp, Help: t.HelpHelp, Handler: r.helpCommand},
+		{Name: "add", Aliases: []string{"добавить"}, Access: access{Permission: model.PermMembersManage}, ArgAccess: map[string]access{
+			"admin": {Permission: model.PermRolesManage, Workspace: true},
+			"админ": {Permission: model.PermRolesManage, Workspace: true},
+			"pm":    {Permission: model.PermRolesManage},
+			"пм":    {Permission: model.PermRolesManage},
+		}, Usage: t.UsageAdd, Help: t.HelpAdd, Handler: r.addCommand},
+		{Name: "delete", Aliases: []string{"удалить"}, Access: access{Permission: model.PermMembersManage}, ArgAccess: map[string]access{
+			"admin": {Permission: model.PermRolesManage, Workspace: true},
+			"админ": {Permission: model.PermRolesManage, Workspace: true},
+		}, Usage: t.UsageDelete, Help: t.HelpDelete, Handler: r.deleteCommand},
+		{Name: "list", Aliases: []string{"список"}, Access: access{Permission: model.PermMembersView}, Usage: t.UsageList, Help: t.HelpList, Handler: r.listCommand},
+		{Name: "standup_time_set", Access: access{Permission: model.PermScheduleManage}, Usage: t.UsageStandupTimeSet, Help: t.HelpStandupTimeSet, Handler: r.addTime},
+		{Name: "standup_time_remove", Access: access{Permission: model.PermScheduleManage}, Help: t.HelpStandupTimeRemove, Handler: r.removeTime},
+		{Name: "standup_time", Access: access{Permission: model.PermScheduleView}, Help: t.HelpStandupTime, Handler: r.listTime},
+		{Name: "timetable_set", Access: access{Permission: model.PermScheduleManage}, Usage: t.UsageTimetableSet, Help: t.HelpTimetableSet, Handler: r.addTimeTable},
+		{Name: "timetable_remove", Access: access{Permission: model.PermScheduleManage}, Usage: t.UsageTimetableRemove, Help: t.HelpTimetableRemove, Handler: r.removeTimeTable},
+		{Name: "timetable_show", Access: access{Permission: model.PermScheduleView}, Usage: t.UsageTimetableShow, Help: t.HelpTimetableShow, Handler: r.showTimeTable},
+		{Name: "report_by_project", Access: access{Permission: model.PermReportsView, ChannelArg: 1}, Usage: fmt.Sprintf(t.UsageReportByProject, formats), Help: t.HelpReportByProject, Handler: r.reportByProject},
+		// standups of another user come from all channels, so reading them takes the permission in every channel
+		{Name: "report_by_user", Access: access{Permission: model.PermReportsView, Workspace: true, UserArg: 1, OwnPermission: model.PermReportsOwn}, Usage: fmt.Sprintf(t.UsageReportByUser, formats), Help: t.HelpReportByUser, Handler: r.reportByUser},
+		{Name: "report_by_user_in_project", Access: access{Permission: model.PermReportsView, ChannelArg: 1, UserArg: 2, OwnPermission: model.PermReportsOwn}, Usage: fmt.Sprintf(t.UsageReportByUserInProject, formats), Help: t.HelpReportByUserInProject, Handler: r.reportByProjectAndUser},
+		{Name: "standup_rules_set", Access: access{Permission: model.PermScheduleManage}, Usage: t.UsageStandupRulesSet, Help: t.HelpStandupRulesSet, Handler: r.setStandupRules},
+		{Name: "standup_rules_show", Access: access{Permission: model.PermScheduleView}, Help: t.HelpStandupRulesShow, Handler: r.showStandupRules},
+		{Name: "interview_mode", Access: access{Permission: model.PermScheduleView}, ArgAccess: manageSchedule, Usage: t.UsageInterviewMode, Help: t.HelpInterviewMode, Handler: r.interviewMode},
+		{Name: "thread_mode", Access: access{Permission: model.PermScheduleView}, ArgAccess: manageSchedule, Usage: t.UsageThreadMode, Help: t.HelpThreadMode, Handler: r.threadMode},
+		{Name: "blockers", Access: access{Permission: model.PermBlockersView, ChannelArg: 1, ChannelOf: r.blockersChannel}, ArgAccess: map[string]access{
+			"resolve": {Permission: model.PermBlockersResolve, ChannelArg: 2, ChannelOf: r.blockerChannel},
+		}, Usage: t.UsageBlockers, Help: t.HelpBlockers, Handler: r.blockers},
+		{Name: "my_stats", Access: access{Permission: model.PermReportsOwn}, Usage: t.UsageMyStats, Help: t.HelpMyStats, Handler: r.myStats},
+		// digests of all channels take managing the workspace
+		{Name: "digest_set", Access: access{Permission: model.PermDigestsManage}, ArgAccess: map[string]access{
+			"all":    {Permission: model.PermWorkspaceManage, Workspace: true},
+			"remove": {Permission: model.PermDigestsManage, ChannelArg: 2, ChannelOf: r.digestChannel},
+		}, Usage: t.UsageDigestSet, Help: t.HelpDigestSet, Handler: r.digestSet},
+		{Name: "digest_list", Access: access{Permission: model.PermScheduleView}, Help: t.HelpDigestList, Handler: r.digestList},
+		{Name: "delivery_status", Access: access{Permission: model.PermWorkspaceManage, Workspace: true}, Help: t.HelpDeliveryStatus, Handler: r.deliveryStatus},
+		{Name: "dashboard", Access: access{Permission: model.PermReportsView, ChannelArg: 1}, ArgAccess: map[string]access{
+			"all": {Permission: model.PermWorkspaceManage, Workspace: true},
+		}, Usage: t.UsageDashboard, Help: t.HelpDashboard, Handler: r.dashboardCommand},
+		{Name: "api_token", Access: access{Permission: model.PermWorkspaceManage, Workspace: true}, Usage: t.UsageAPIToken, Help: t.HelpAPIToken, Handler: r.apiToken},
+		{Name: "webhook_set", Access: access{Permission: model.PermWorkspaceManage, Workspace: true}, Usage: t.UsageWebhookSet, Help: t.HelpWebhookSet, Handler: r.webhookSet},
+		{Name: "webhook_list", Access: access{Permission: model.PermWorkspaceManage, Workspace: true}, Usage: t.UsageWebhookList, Help: t.HelpWebhookList, Handler: r.webhookList},
+		{Name: "sync_members", Access: access{Permission: model.PermMembersManage}, Usage: t.UsageSyncMembers, Help: t.HelpSyncMembers, Handler: r.syncMembers},
+		// anyone may see the language and change their own one
+		{Name: "language_set", Aliases: []string{"язык"}, Access: access{Permission: model.PermScheduleManage}, ArgAccess: map[string]access{
+			"":   {},
+			"me": {},
+		}, Usage: fmt.Sprintf(t.UsageLanguageSet, languageArg()), Help: t.HelpLanguageSet, Handler: r.languageSet},
+		{Name: "channel_settings", Access: access{Permission: model.PermScheduleView}, ArgAccess: map[string]access{
+			"set": {Permission: model.PermScheduleManage},
+		}, Usage: fmt.Sprintf(t.UsageChannelSettings, strings.Join(channelSettingNames(), " | ")), Help: t.HelpChannelSettings, Handler: r.channelSettings},
+		{Name: "roles", Access: access{Permission: model.PermMembersView}, ArgAccess: map[string]access{
+			"bind":   {Permission: model.PermRolesManage, ChannelArg: 4, Workspace: true},
+			"unbind": {Permission: model.PermRolesManage, ChannelArg: 4, Workspace: true},
+			"define": {Permission: model.PermRolesManage, Workspace: true},
+			"remove": {Permission: model.PermRolesManage, Workspace: true},
+		}, Usage: t.UsageRoles, Help: t.HelpRoles, Handler: r.roles},
+	}
+}
+
+// findCommand looks up command by its name or alias, leading slash is ignored
+func (r *REST) findCommand(name string) (command, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "/"))
+	for _, cmd := range r.commands {
+		if cmd.Name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.Aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+// IsCommand reports whether command is handled by REST API
+func (r *REST) IsCommand(name string) bool {
+	if name == commandComedian {
+		return true
+	}
+	_, ok := r.findCommand(name)
+	return ok
+}
+
+// RunCommand runs command received in a message through the same handlers as slash commands
+func (r *REST) RunCommand(form url.Values) string {
+	req := httptest.NewRequest(http.MethodPost, "/commands", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	err := r.handleCommands(r.echo.NewContext(req, rec))
+	if err != nil {
+		logrus.Errorf("rest: handleCommands failed: %v\n", err)
+		return r.conf.Translate.SomethingWentWrong
+	}
+	return rec.Body.String()
+}
+
+func (r *REST) handleCommands(c echo.Context) error {
+	form, err := c.FormParams()
+	if err != nil {
+		logrus.Errorf("rest: c.FormParams failed: %v\n", err)
+	}
+
+	// responses are written in the language of the user who runs the command
+	r = r.localized(form.Get("channel_id"), form.Get("user_id"))
+
+	name := form.Get("command")
+	if name == commandComedian {
+		fields := strings.Fields(form.Get("text"))
+		if len(fields) == 0 {
+			fields = []string{"help"}
+		}
+		name = fields[0]
+		form.Set("text", strings.Join(fields[1:], " "))
+		if _, ok := r.findCommand(name); !ok {
+			commandsTotal.Inc("unknown", "unknown")
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.UnknownCommand, name))
+		}
+	}
+	cmd, ok := r.findCommand(name)
+	if !ok {
+		commandsTotal.Inc("unknown", "unknown")
+		return c.String(http.StatusNotImplemented, "Not implemented")
+	}
+
+	// direct messages have no channel, it is named before commands sent there
+	if !cmd.Global && strings.HasPrefix(form.Get("channel_id"), "D") {
+		commandsTotal.Inc(cmd.Name, "error")
+		return c.String(http.StatusOK, r.conf.Translate.CommandNeedsChannel)
+	}
+	if denied := r.checkAccess(cmd, form); denied != "" {
+		commandsTotal.Inc(cmd.Name, "denied")
+		return c.String(http.StatusOK, denied)
+	}
+	start := time.Now()
+	err = cmd.Handler(c, form)
+	commandDuration.ObserveSince(start, cmd.Name)
+	if err != nil {
+		commandsTotal.Inc(cmd.Name, "error")
+		return err
+	}
+	commandsTotal.Inc(cmd.Name, "ok")
+	return nil
+}
+
+func (r *REST) helpCommand(c echo.Context, f url.Values) error {
+	name := strings.TrimSpace(f.Get("text"))
+	if name == "" {
+		text := r.conf.Translate.HelpUsage
+		for _, cmd := range r.commands {
+			text += fmt.Sprintf(r.conf.Translate.HelpItem, cmd.usage(), cmd.Help)
+		}
+		text += r.conf.Translate.HelpFooter
+		return c.String(http.StatusOK, text)
+	}
+
+	cmd, ok := r.findCommand(name)
+	if !ok {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.UnknownCommand, name))
+	}
+	text := fmt.Sprintf(r.conf.Translate.HelpDetails, cmd.usage(), cmd.Help)
+	aliases := append([]string{"/" + cmd.Name}, cmd.Aliases...)
+	text += fmt.Sprintf(r.conf.Translate.HelpAliases, strings.Join(aliases, ", "))
+	if cmd.Access.Permission != "" {
+		text += fmt.Sprintf(r.conf.Translate.HelpAccess, cmd.Access.Permission)
+	}
+	return c.String(http.StatusOK, text)
+}
+
+// usage returns command with its arguments, like "add @user1 @user2 [/ role]"
+func (cmd command) usage() string {
+	return strings.TrimSpace(cmd.Name + " " + cmd.Usage)
+}
+
+// accessFor returns access the command requires with the arguments
+func (cmd command) accessFor(args []string) []access {
+	if len(args) == 0 {
+		if a, ok := cmd.ArgAccess[""]; ok {
+			return []access{a}
+		}
+		return []access{cmd.Access}
+	}
+	required := []access{}
+	matched := map[string]bool{}
+	for _, arg := range args {
+		key := strings.ToLower(strings.TrimPrefix(arg, "/"))
+		a, ok := cmd.ArgAccess[key]
+		if key == "" || !ok || matched[key] {
+			continue
+		}
+		matched[key] = true
+		required = append(required, a)
+	}
+	if len(required) == 0 {
+		return []access{cmd.Access}
+	}
+	return required
+}
+
+// checkAccess checks access the command requires with its arguments and returns the reply if it is denied
+func (r *REST) checkAccess(cmd command, f url.Values) string {
+	userID := f.Get("user_id")
+	args := strings.Fields(f.Get("text"))
+	for _, a := range cmd.accessFor(args) {
+		if a.Permission == "" {
+			continue
+		}
+		channelID, err := r.accessChannel(a, f.Get("channel_id"), args)
+		if err != nil {
+			return err.Error()
+		}
+		permission := a.Permission
+		if a.OwnPermission != "" && a.UserArg > 0 && a.UserArg <= len(args) {
+			if id, err := r.userArg(args[a.UserArg-1]); err == nil && id == userID {
+				permission = a.OwnPermission
+			}
+		}
+		if !r.can(userID, channelID, permission) {
+			return r.accessDenied(permission)
+		}
+	}
+	return ""
+}
+
+// accessChannel returns the channel access is checked in, empty channel means all channels
+func (r *REST) accessChannel(a access, channelID string, args []string) (string, error) {
+	if a.ChannelArg > 0 && a.ChannelArg <= len(args) {
+		channelOf := a.ChannelOf
+		if channelOf == nil {
+			channelOf = r.channelArg
+		}
+		return channelOf(args[a.ChannelArg-1])
+	}
+	if a.Workspace {
+		return "", nil
+	}
+	return channelID, nil
+}
+
+// channelArg returns ID of the channel named by a command argument like <#ID|name>, #name or name
+func (r *REST) channelArg(arg string) (string, error) {
+	if strings.HasPrefix(arg, "<#") {
+		return r.parseRecipient(arg)
+	}
+	channelID, err := r.db.GetChannelID(strings.TrimPrefix(arg, "#"))
+	if err != nil {
+		return "", errors.New(r.conf.Translate.WrongProjectName)
+	}
+	return channelID, nil
+}
+
+// userArg returns ID of the user named by a command argument like <@ID|name>, @name or name
+func (r *REST) userArg(arg string) (string, error) {
+	if strings.HasPrefix(arg, "<@") {
+		return r.parseRecipient(arg)
+	}
+	user, err := r.db.SelectUserByUserName(strings.TrimPrefix(arg, "@"))
+	return user.UserID, err
+}
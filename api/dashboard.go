@@ -0,0 +1,331 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// dashboardLinkTTL is how long signed dashboard links stay valid
+	dashboardLinkTTL = time.Hour
+	// dashboardDays is the number of days shown on dashboard charts
+	dashboardDays = 28
+
+	heatmapCell   = 14
+	heatmapGap    = 2
+	heatmapLabels = 140
+	trendHeight   = 60
+)
+
+// Colors of heatmap cells
+const (
+	colorOnTime     = "#2eb67d"
+	colorLate       = "#ecb22e"
+	colorMissed     = "#e01e5a"
+	colorNotTracked = "#ebedf0"
+)
+
+// dashboardPage is data rendered by dashboard template
+type dashboardPage struct {
+	T         config.Translate
+	Generated string
+	Channels  []dashboardChannel
+}
+
+// dashboardChannel is a section of dashboard about one channel
+type dashboardChannel struct {
+	Name         string
+	Width        int
+	Height       int
+	Days         []heatmapLabel
+	Rows         []heatmapRow
+	TrendWidth   int
+	Trend        string
+	NonReporters []string
+	Blockers     []model.Blocker
+}
+
+type heatmapLabel struct {
+	X    int
+	Text string
+}
+
+type heatmapRow struct {
+	Y      int
+	TextY  int
+	Member string
+	Cells  []heatmapCellView
+}
+
+type heatmapCellView struct {
+	X     int
+	Color string
+	Title string
+}
+
+func (r *REST) dashboardCommand(c echo.Context, f url.Values) error {
+	if !r.dashboardEnabled() {
+		return c.String(http.StatusOK, r.conf.Translate.DashboardDisabled)
+	}
+
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	channelID := ca.ChannelID
+	text := strings.TrimSpace(ca.Text)
+	if text == "all" {
+		channelID = ""
+	} else if text != "" {
+		channelID, err = r.parseRecipient(text)
+		if err != nil || strings.HasPrefix(channelID, "U") || strings.HasPrefix(channelID, "W") {
+			return c.String(http.StatusOK, r.conf.Translate.WrongProjectName)
+		}
+	}
+
+	link := r.dashboardLink(channelID, time.Now())
+	err = r.slack.SendUserMessage(f.Get("user_id"), fmt.Sprintf(r.conf.Translate.DashboardLink, link, r.conf.Translate.Plural(config.PluralMinutes, int(dashboardLinkTTL.Minutes()))))
+	if err != nil {
+		logrus.Errorf("rest: SendUserMessage failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, r.conf.Translate.DashboardSent)
+}
+
+// dashboardEnabled shows if dashboard links can be issued, which needs both public URL and signing secret
+func (r *REST) dashboardEnabled() bool {
+	return r.conf.DashboardURL != "" && r.conf.DashboardSecret != ""
+}
+
+// dashboardLink returns link to dashboard of the channel, or of all channels if channelID is empty
+func (r *REST) dashboardLink(channelID string, now time.Time) string {
+	expires := now.Add(dashboardLinkTTL).Unix()
+	query := url.Values{
+		"channel": {channelID},
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sig":     {r.dashboardSignature(channelID, expires)},
+	}
+	return strings.TrimSuffix(r.conf.DashboardURL, "/") + "/dashboard?" + query.Encode()
+}
+
+func (r *REST) dashboardSignature(channelID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(r.conf.DashboardSecret))
+	fmt.Fprintf(mac, "%v|%v", channelID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validDashboardLink checks signature and expiration time of dashboard link
+func (r *REST) validDashboardLink(channelID, expires, signature string, now time.Time) bool {
+	if r.conf.DashboardSecret == "" {
+		return false
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > exp {
+		return false
+	}
+	expected := r.dashboardSignature(channelID, exp)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (r *REST) dashboard(c echo.Context) error {
+	channelID := c.QueryParam("channel")
+	if !r.validDashboardLink(channelID, c.QueryParam("expires"), c.QueryParam("sig"), time.Now()) {
+		return c.String(http.StatusForbidden, r.conf.Translate.DashboardLinkExpired)
+	}
+
+	channels := []model.Channel{}
+	if channelID != "" {
+		channel, err := r.db.SelectChannel(channelID)
+		if err != nil {
+			return c.String(http.StatusNotFound, r.conf.Translate.WrongProjectName)
+		}
+		channels = append(channels, channel)
+	} else {
+		all, err := r.db.GetAllChannels()
+		if err != nil {
+			logrus.Errorf("rest: GetAllChannels failed: %v\n", err)
+			return c.String(http.StatusInternalServerError, r.conf.Translate.SomethingWentWrong)
+		}
+		for _, channel := range all {
+			if !channel.Archived {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	now := time.Now()
+	dateFrom := now.AddDate(0, 0, 1-dashboardDays)
+	page := dashboardPage{T: r.conf.Translate, Generated: now.Format("2006-01-02 15:04")}
+	for _, channel := range channels {
+		report, err := r.report.StandupReportByProject(channel, dateFrom, now)
+		if err != nil {
+			logrus.Errorf("rest: StandupReportByProject failed: %v\n", err)
+			continue
+		}
+		blockers, err := r.db.ListOpenBlockers(channel.ChannelID)
+		if err != nil {
+			logrus.Errorf("rest: ListOpenBlockers failed: %v\n", err)
+		}
+		page.Channels = append(page.Channels, buildDashboardChannel(channel, report.Entries, r.dashboardNonReporters(channel, now), blockers, dateFrom, now))
+	}
+
+	var buf bytes.Buffer
+	err := dashboardTemplate.Execute(&buf, page)
+	if err != nil {
+		logrus.Errorf("rest: dashboardTemplate.Execute failed: %v\n", err)
+		return c.String(http.StatusInternalServerError, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.HTML(http.StatusOK, buf.String())
+}
+
+// dashboardNonReporters lists members of the channel who have not submitted a standup today.
+// Report entries can not be used for it, missed standups get there only at the end of the day
+func (r *REST) dashboardNonReporters(channel model.Channel, now time.Time) []string {
+	dateFrom := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	members, err := r.db.GetNonReporters(channel.ChannelID, dateFrom, now)
+	if err != nil {
+		logrus.Errorf("rest: GetNonReporters failed: %v\n", err)
+		return nil
+	}
+	names := []string{}
+	for _, member := range members {
+		name := member.UserID
+		if user, err := r.db.SelectUser(member.UserID); err == nil && user.UserName != "" {
+			name = user.UserName
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// buildDashboardChannel lays out heatmap and trend chart of the channel from report entries
+func buildDashboardChannel(channel model.Channel, entries []reporting.ReportEntry, nonReporters []string, blockers []model.Blocker, dateFrom, now time.Time) dashboardChannel {
+	first := time.Date(dateFrom.Year(), dateFrom.Month(), dateFrom.Day(), 0, 0, 0, 0, time.UTC)
+	today := now.Format("2006-01-02")
+	step := heatmapCell + heatmapGap
+	dc := dashboardChannel{Name: channel.ChannelName, NonReporters: nonReporters, Blockers: blockers}
+
+	days := []string{}
+	for day := first; day.Format("2006-01-02") <= today; day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+		if day.Weekday() == time.Monday || len(days) == 1 {
+			dc.Days = append(dc.Days, heatmapLabel{X: heatmapLabels + (len(days)-1)*step, Text: day.Format("01-02")})
+		}
+	}
+	column := map[string]int{}
+	for i, day := range days {
+		column[day] = i
+	}
+
+	rows := map[string]int{}
+	submitted := make([]int, len(days))
+	expected := make([]int, len(days))
+	for _, entry := range entries {
+		day := entry.Date.Format("2006-01-02")
+		x, ok := column[day]
+		if !ok {
+			continue
+		}
+		i, ok := rows[entry.UserID]
+		if !ok {
+			i = len(dc.Rows)
+			rows[entry.UserID] = i
+			member := entry.UserName
+			if member == "" {
+				member = entry.UserID
+			}
+			row := heatmapRow{Y: 20 + i*step, TextY: 20 + i*step + heatmapCell - 3, Member: member}
+			for j, d := range days {
+				row.Cells = append(row.Cells, heatmapCellView{X: heatmapLabels + j*step, Color: colorNotTracked, Title: d})
+			}
+			dc.Rows = append(dc.Rows, row)
+		}
+		cell := &dc.Rows[i].Cells[x]
+		expected[x]++
+		switch {
+		case entry.Status != reporting.StatusSubmitted:
+			cell.Color = colorMissed
+			cell.Title = day + ": " + reporting.StatusMissed
+		case entry.OnTime():
+			submitted[x]++
+			cell.Color = colorOnTime
+			cell.Title = day + ": " + reporting.StatusSubmitted
+		default:
+			submitted[x]++
+			cell.Color = colorLate
+			cell.Title = fmt.Sprintf("%v: %v, %v min late", day, reporting.StatusSubmitted, int64((entry.Lateness()+time.Minute-1)/time.Minute))
+		}
+	}
+	dc.Width = heatmapLabels + len(days)*step
+	dc.Height = 20 + len(dc.Rows)*step
+
+	points := []string{}
+	for i := range days {
+		if expected[i] == 0 {
+			continue
+		}
+		y := trendHeight - submitted[i]*trendHeight/expected[i]
+		points = append(points, fmt.Sprintf("%v,%v", i*step+heatmapCell/2, y))
+	}
+	dc.TrendWidth = len(days) * step
+	dc.Trend = strings.Join(points, " ")
+	return dc
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.T.DashboardTitle}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 24px; color: #1d1c1d; }
+section { margin-bottom: 40px; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+svg text { font-size: 11px; fill: #616061; }
+.legend span { display: inline-block; width: 12px; height: 12px; margin: 0 4px 0 12px; vertical-align: middle; }
+</style>
+</head>
+<body>
+<h1>{{.T.DashboardTitle}}</h1>
+<p>{{.Generated}}</p>
+<p class="legend"><span style="background:#2eb67d"></span>{{.T.DashboardOnTime}}<span style="background:#ecb22e"></span>{{.T.DashboardLate}}<span style="background:#e01e5a"></span>{{.T.DashboardMissed}}<span style="background:#ebedf0"></span>{{.T.DashboardNotTracked}}</p>
+{{range .Channels}}
+<section>
+<h2>#{{.Name}}</h2>
+<h3>{{$.T.DashboardHeatmap}}</h3>
+<svg width="{{.Width}}" height="{{.Height}}" xmlns="http://www.w3.org/2000/svg">
+{{range .Days}}<text x="{{.X}}" y="12">{{.Text}}</text>
+{{end}}{{range .Rows}}{{$y := .Y}}<text x="0" y="{{.TextY}}">{{.Member}}</text>
+{{range .Cells}}<rect x="{{.X}}" y="{{$y}}" width="14" height="14" rx="2" fill="{{.Color}}"><title>{{.Title}}</title></rect>
+{{end}}{{end}}</svg>
+<h3>{{$.T.DashboardTrend}}</h3>
+<svg width="{{.TrendWidth}}" height="62" xmlns="http://www.w3.org/2000/svg">
+<rect x="0" y="0" width="{{.TrendWidth}}" height="60" fill="#f8f8f8"></rect>
+<polyline points="{{.Trend}}" fill="none" stroke="#1264a3" stroke-width="2"></polyline>
+</svg>
+<h3>{{$.T.DashboardNonReporters}}</h3>
+{{if .NonReporters}}<ul>{{range .NonReporters}}<li>{{.}}</li>{{end}}</ul>{{else}}<p>{{$.T.DashboardEveryoneReported}}</p>{{end}}
+<h3>{{$.T.DashboardBlockers}}</h3>
+{{if .Blockers}}<ul>{{range .Blockers}}<li>{{.Created.Format "2006-01-02"}} &lt;@{{.UserID}}&gt;: {{.Text}}</li>{{end}}</ul>{{else}}<p>{{$.T.DashboardNoBlockers}}</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
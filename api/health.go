@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/storage"
+)
+
+// healthTimeout limits how long health checks wait for the database
+const healthTimeout = 2 * time.Second
+
+// Statuses of health checks
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+)
+
+type healthCheck struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// healthz reports whether Comedian is alive: RTM is not rejected by Slack and scheduled loops are running.
+// It fails when restart is needed
+func (r *REST) healthz(c echo.Context) error {
+	return respondHealth(c, map[string]healthCheck{
+		"rtm":       r.checkRTM(false),
+		"scheduler": r.checkScheduler(),
+	})
+}
+
+// readyz reports whether Comedian can serve: database is reachable and migrated, RTM is connected
+// and scheduled loops are running
+func (r *REST) readyz(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), healthTimeout)
+	defer cancel()
+	return respondHealth(c, map[string]healthCheck{
+		"database":   r.checkDatabase(ctx),
+		"migrations": r.checkMigrations(),
+		"rtm":        r.checkRTM(true),
+		"scheduler":  r.checkScheduler(),
+	})
+}
+
+func respondHealth(c echo.Context, checks map[string]healthCheck) error {
+	res := healthResponse{Status: healthOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status != healthOK {
+			res.Status = healthDegraded
+		}
+	}
+	if res.Status != healthOK {
+		return c.JSON(http.StatusServiceUnavailable, res)
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+func (r *REST) checkDatabase(ctx context.Context) healthCheck {
+	if err := r.db.Ping(ctx); err != nil {
+		return healthCheck{Status: healthDegraded, Detail: err.Error()}
+	}
+	return healthCheck{Status: healthOK}
+}
+
+// checkMigrations compares migration version applied to the database with the newest one shipped,
+// when migrations directory is not available only the applied version is reported
+func (r *REST) checkMigrations() healthCheck {
+	applied, err := r.db.MigrationVersion()
+	if err != nil {
+		return healthCheck{Status: healthDegraded, Detail: err.Error()}
+	}
+	latest, err := storage.LatestMigration(r.conf.MigrationsDir)
+	if err != nil {
+		return healthCheck{Status: healthOK, Detail: fmt.Sprintf("version %v", applied)}
+	}
+	if applied < latest {
+		return healthCheck{Status: healthDegraded, Detail: fmt.Sprintf("version %v, expected %v", applied, latest)}
+	}
+	return healthCheck{Status: healthOK, Detail: fmt.Sprintf("version %v", applied)}
+}
+
+// checkRTM fails when Slack rejected the token, and when connected is required also while RTM is reconnecting
+func (r *REST) checkRTM(connected bool) healthCheck {
+	state := r.slack.RTMState()
+	if state == chat.RTMInvalidAuth || connected && state != chat.RTMConnected {
+		return healthCheck{Status: healthDegraded, Detail: state}
+	}
+	return healthCheck{Status: healthOK, Detail: state}
+}
+
+func (r *REST) checkScheduler() healthCheck {
+	stalled := r.slack.StalledLoops(time.Now())
+	if len(stalled) > 0 {
+		return healthCheck{Status: healthDegraded, Detail: "stalled: " + strings.Join(stalled, ", ")}
+	}
+	return healthCheck{Status: healthOK}
+}
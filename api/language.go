@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/config"
+	"github.com/sirupsen/logrus"
+)
+
+// localizedCache holds localized copies of REST built for the config of the given version
+type localizedCache struct {
+	sync.Mutex
+	version int
+	byLang  map[string]*REST
+}
+
+// localized returns a copy of REST with current configuration which responds in the language
+// of the user in the channel. Copies are built once per language and rebuilt after config reload
+func (r *REST) localized(channelID, userID string) *REST {
+	if r.slack == nil {
+		return r
+	}
+	conf, version := r.slack.VersionedConfig()
+	lang, ok := config.ParseLanguage(r.slack.Language(channelID, userID))
+	if !ok {
+		lang = ""
+	}
+	if r.localizedCache == nil {
+		return r.localizedCopy(conf, lang)
+	}
+
+	cache := r.localizedCache
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.byLang == nil || cache.version != version {
+		cache.version = version
+		cache.byLang = map[string]*REST{}
+	}
+	if lr, ok := cache.byLang[lang]; ok {
+		return lr
+	}
+	lr := r.localizedCopy(conf, lang)
+	cache.byLang[lang] = lr
+	return lr
+}
+
+// localizedCopy builds a copy of REST with commands translated to the language
+func (r *REST) localizedCopy(conf config.Config, lang string) *REST {
+	t := conf.Translation(lang)
+	lr := *r
+	lr.conf = conf
+	lr.conf.Translate = t
+	if r.report != nil {
+		lr.report = r.report.Localized(t)
+	}
+	lr.registerCommands()
+	return &lr
+}
+
+func (r *REST) languageSet(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	userID := f.Get("user_id")
+
+	args := strings.Fields(strings.ToLower(ca.Text))
+	if len(args) == 0 {
+		return c.String(http.StatusOK, r.showLanguage(ca.ChannelID, userID))
+	}
+	forMe := len(args) == 2 && args[1] == "me"
+	if len(args) > 2 || (len(args) == 2 && !forMe) {
+		return c.String(http.StatusOK, r.conf.Translate.LanguageWrongFormat)
+	}
+	lang := ""
+	if args[0] != "default" {
+		l, ok := config.ParseLanguage(args[0])
+		if !ok {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongLanguage, args[0], strings.Join(config.Languages, ", ")))
+		}
+		lang = l
+	}
+
+	if forMe {
+		user, err := r.db.SelectUser(userID)
+		if err != nil {
+			logrus.Errorf("rest: SelectUser failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		user.Language = lang
+		_, err = r.db.UpdateUser(user)
+		if err != nil {
+			logrus.Errorf("rest: UpdateUser failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		// the answer is already in the new language
+		t := r.slack.Translation(ca.ChannelID, userID)
+		if lang == "" {
+			return c.String(http.StatusOK, t.LanguageUserReset)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(t.LanguageUserSet, lang))
+	}
+
+	if err := r.setChannelLanguage(ca.ChannelID, lang); err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	t := r.slack.Translation(ca.ChannelID, userID)
+	if lang == "" {
+		return c.String(http.StatusOK, t.LanguageChannelReset)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(t.LanguageChannelSet, lang))
+}
+
+// setChannelLanguage stores language of the channel, empty language resets it to the default one
+func (r *REST) setChannelLanguage(channelID, lang string) error {
+	channel, err := r.db.SelectChannel(channelID)
+	if err != nil {
+		logrus.Errorf("rest: SelectChannel failed: %v\n", err)
+		return err
+	}
+	channel.Language = lang
+	_, err = r.db.UpdateChannel(channel)
+	if err != nil {
+		logrus.Errorf("rest: UpdateChannel failed: %v\n", err)
+	}
+	return err
+}
+
+// defaultLanguage returns language of the config
+func (r *REST) defaultLanguage() string {
+	defaultLanguage, ok := config.ParseLanguage(r.conf.Language)
+	if !ok {
+		return r.conf.Language
+	}
+	return defaultLanguage
+}
+
+// showLanguage describes language of the channel and of the user
+func (r *REST) showLanguage(channelID, userID string) string {
+	channelLanguage := fmt.Sprintf(r.conf.Translate.LanguageDefault, r.defaultLanguage())
+	if channel, err := r.db.SelectChannel(channelID); err == nil && channel.Language != "" {
+		channelLanguage = channel.Language
+	}
+	userLanguage := r.conf.Translate.LanguageSameAsChannel
+	if user, err := r.db.SelectUser(userID); err == nil && user.Language != "" {
+		userLanguage = user.Language
+	}
+	return fmt.Sprintf(r.conf.Translate.LanguageShow, channelLanguage, userLanguage)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// expandMembers replaces "all" with human members of the channel and user groups with their members,
+// so /add gets user mentions only
+func (r *REST) expandMembers(channelID string, users []string) ([]string, error) {
+	expanded := []string{}
+	for _, u := range users {
+		var ids []string
+		var err error
+		switch {
+		case u == "all":
+			ids, err = r.slack.ChannelHumans(channelID)
+		case chat.IsUserGroupMention(u), strings.HasPrefix(u, "@"):
+			group, groupErr := r.slack.UserGroup(u)
+			if groupErr != nil {
+				// not a user group, let it fail as a wrong mention
+				expanded = append(expanded, u)
+				continue
+			}
+			ids, err = r.slack.UserGroupMembers(group.ID)
+		default:
+			expanded = append(expanded, u)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			expanded = append(expanded, r.mention(id))
+		}
+	}
+	return expanded, nil
+}
+
+// mention returns escaped user mention like <@U123|name>
+func (r *REST) mention(userID string) string {
+	user, err := r.db.SelectUser(userID)
+	if err != nil {
+		return "<@" + userID + "|" + userID + ">"
+	}
+	return "<@" + userID + "|" + user.UserName + ">"
+}
+
+func (r *REST) syncMembers(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	args := strings.Fields(ca.Text)
+	switch {
+	case len(args) == 0:
+		sync, err := r.db.SelectMemberSync(ca.ChannelID)
+		if err != nil {
+			return c.String(http.StatusOK, r.conf.Translate.SyncMembersNotSynced)
+		}
+		text := fmt.Sprintf(r.conf.Translate.SyncMembersCurrent, sync.UserGroupHandle, sync.LastSynced.Format("2006-01-02 15:04"))
+		diff, err := r.slack.MemberSyncDiff(ca.ChannelID, sync.UserGroupID)
+		if err != nil {
+			logrus.Errorf("rest: MemberSyncDiff failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, text+r.memberDiffText(sync.UserGroupHandle, diff))
+	case len(args) == 1 && args[0] == "remove":
+		sync, err := r.db.SelectMemberSync(ca.ChannelID)
+		if err != nil {
+			return c.String(http.StatusOK, r.conf.Translate.SyncMembersNotSynced)
+		}
+		err = r.db.DeleteMemberSync(ca.ChannelID)
+		if err != nil {
+			logrus.Errorf("rest: DeleteMemberSync failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.SyncMembersRemoved, sync.UserGroupHandle))
+	case len(args) > 2 || len(args) == 2 && args[1] != "apply":
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+
+	group, err := r.slack.UserGroup(args[0])
+	if err != nil {
+		logrus.Errorf("rest: UserGroup failed: %v\n", err)
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongUserGroup, args[0]))
+	}
+	diff, err := r.slack.MemberSyncDiff(ca.ChannelID, group.ID)
+	if err != nil {
+		logrus.Errorf("rest: MemberSyncDiff failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if len(args) == 1 {
+		return c.String(http.StatusOK, r.memberDiffText(group.Handle, diff)+fmt.Sprintf(r.conf.Translate.SyncMembersConfirm, group.Handle))
+	}
+
+	r.slack.ApplyMemberDiff(ca.ChannelID, diff)
+	_, err = r.db.CreateMemberSync(model.MemberSync{
+		ChannelID:       ca.ChannelID,
+		UserGroupID:     group.ID,
+		UserGroupHandle: group.Handle,
+		LastSynced:      time.Now(),
+	})
+	if err != nil {
+		logrus.Errorf("rest: CreateMemberSync failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, r.memberDiffText(group.Handle, diff)+fmt.Sprintf(r.conf.Translate.SyncMembersApplied, group.Handle))
+}
+
+// memberDiffText describes changes sync with the user group makes
+func (r *REST) memberDiffText(handle string, diff chat.MemberDiff) string {
+	text := ""
+	if diff.Empty() {
+		text = fmt.Sprintf(r.conf.Translate.SyncMembersNoChanges, handle)
+	} else {
+		text = fmt.Sprintf(r.conf.Translate.SyncMembersDiffHead, handle)
+		if len(diff.Add) > 0 {
+			text += fmt.Sprintf(r.conf.Translate.SyncMembersAdd, mentions(diff.Add))
+		}
+		if len(diff.Remove) > 0 {
+			text += fmt.Sprintf(r.conf.Translate.SyncMembersRemove, mentions(diff.Remove))
+		}
+	}
+	if len(diff.NotInChannel) > 0 {
+		text += fmt.Sprintf(r.conf.Translate.SyncMembersNotInChannel, mentions(diff.NotInChannel))
+	}
+	return text
+}
+
+func mentions(userIDs []string) string {
+	parts := []string{}
+	for _, userID := range userIDs {
+		parts = append(parts, "<@"+userID+">")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	commandsTotal   = metrics.NewCounter("comedian_commands_total", "Slash commands handled, by command and result.", "command", "result")
+	commandDuration = metrics.NewHistogram("comedian_command_duration_seconds", "Duration of slash command handlers in seconds.", metrics.DefaultBuckets, "command")
+	nonReporters    = metrics.NewGauge("comedian_non_reporters", "Members who have not submitted standup today, by channel ID.", "channel")
+)
+
+// metrics exposes metrics in Prometheus text format. When metrics token is configured,
+// scrapers pass it as 'Authorization: Bearer <token>'
+func (r *REST) metrics(c echo.Context) error {
+	if r.conf.MetricsToken != "" {
+		header := []byte(c.Request().Header.Get(echo.HeaderAuthorization))
+		if subtle.ConstantTimeCompare(header, []byte("Bearer "+r.conf.MetricsToken)) != 1 {
+			return c.NoContent(http.StatusUnauthorized)
+		}
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	_, err := metrics.DefaultRegistry.WriteTo(c.Response())
+	return err
+}
+
+// updateNonReporters counts today's non reporters of every active channel. It is scheduled
+// instead of run on scrape, so that scrapes do not query the database for every channel
+func (r *REST) updateNonReporters() {
+	channels, err := r.db.GetAllChannels()
+	if err != nil {
+		logrus.Errorf("rest: GetAllChannels failed: %v\n", err)
+		return
+	}
+	now := time.Now()
+	dateFrom := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	nonReporters.Reset()
+	for _, channel := range channels {
+		if channel.Archived {
+			continue
+		}
+		members, err := r.db.GetNonReporters(channel.ChannelID, dateFrom, now)
+		if err != nil {
+			logrus.Errorf("rest: GetNonReporters failed: %v\n", err)
+			continue
+		}
+		nonReporters.Set(float64(len(members)), channel.ChannelID)
+	}
+}
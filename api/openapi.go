@@ -0,0 +1,186 @@
+package api
+
+// openAPIDocument describes JSON API served under /api/v1
+const openAPIDocument = `{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "Comedian API",
+    "version": "1.0.0",
+    "description": "Read-only access to channels, members, timetables, standups and reports. Tokens are created with /api_token in Slack and passed as 'Authorization: Bearer <token>'. Admin tokens access everything, PM tokens access their channel, viewer tokens cannot read standup texts."
+  },
+  "servers": [{"url": "/api/v1"}],
+  "security": [{"bearer": []}],
+  "paths": {
+    "/channels": {
+      "get": {
+        "summary": "List channels",
+        "parameters": [{"$ref": "#/components/parameters/page"}, {"$ref": "#/components/parameters/per_page"}],
+        "responses": {
+          "200": {"description": "Channels", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ChannelPage"}}}},
+          "401": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/channels/{channel}": {
+      "get": {
+        "summary": "Get channel",
+        "parameters": [{"$ref": "#/components/parameters/channel"}],
+        "responses": {
+          "200": {"description": "Channel", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Channel"}}}},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/channels/{channel}/members": {
+      "get": {
+        "summary": "List channel members",
+        "parameters": [{"$ref": "#/components/parameters/channel"}, {"$ref": "#/components/parameters/page"}, {"$ref": "#/components/parameters/per_page"}],
+        "responses": {
+          "200": {"description": "Members", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/MemberPage"}}}},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/channels/{channel}/timetables": {
+      "get": {
+        "summary": "List timetables of channel members",
+        "parameters": [{"$ref": "#/components/parameters/channel"}, {"$ref": "#/components/parameters/page"}, {"$ref": "#/components/parameters/per_page"}],
+        "responses": {
+          "200": {"description": "Timetables", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TimeTablePage"}}}},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/channels/{channel}/standups": {
+      "get": {
+        "summary": "List standups submitted in the channel, admin and PM tokens only",
+        "parameters": [{"$ref": "#/components/parameters/channel"}, {"$ref": "#/components/parameters/from"}, {"$ref": "#/components/parameters/to"}, {"$ref": "#/components/parameters/page"}, {"$ref": "#/components/parameters/per_page"}],
+        "responses": {
+          "200": {"description": "Standups", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/StandupPage"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/channels/{channel}/report": {
+      "get": {
+        "summary": "Report with an entry per day and member, standup texts are empty for viewer tokens",
+        "parameters": [{"$ref": "#/components/parameters/channel"}, {"$ref": "#/components/parameters/from"}, {"$ref": "#/components/parameters/to"}, {"$ref": "#/components/parameters/page"}, {"$ref": "#/components/parameters/per_page"}],
+        "responses": {
+          "200": {"description": "Report entries", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ReportPage"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer"}
+    },
+    "parameters": {
+      "channel": {"name": "channel", "in": "path", "required": true, "description": "Slack channel ID", "schema": {"type": "string"}},
+      "page": {"name": "page", "in": "query", "description": "Page number starting from 1", "schema": {"type": "integer", "minimum": 1, "default": 1}},
+      "per_page": {"name": "per_page", "in": "query", "description": "Page size", "schema": {"type": "integer", "minimum": 1, "maximum": 200, "default": 50}},
+      "from": {"name": "from", "in": "query", "description": "First day of the period, 6 days before 'to' by default", "schema": {"type": "string", "format": "date"}},
+      "to": {"name": "to", "in": "query", "description": "Last day of the period, today by default. Period is at most 366 days", "schema": {"type": "string", "format": "date"}}
+    },
+    "responses": {
+      "Error": {"description": "Error", "content": {"application/json": {"schema": {"type": "object", "properties": {"error": {"type": "string"}}}}}}
+    },
+    "schemas": {
+      "Channel": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "channel_name": {"type": "string"},
+          "channel_id": {"type": "string"},
+          "time": {"type": "integer", "description": "Standup deadline as unix time"},
+          "interview": {"type": "boolean"},
+          "threaded": {"type": "boolean"},
+          "archived": {"type": "boolean"}
+        }
+      },
+      "Member": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "user_id": {"type": "string"},
+          "user_name": {"type": "string"},
+          "role_in_channel": {"type": "string"},
+          "standup_time": {"type": "integer", "description": "Individual standup deadline as unix time"},
+          "created": {"type": "string", "format": "date-time"}
+        }
+      },
+      "TimeTable": {
+        "type": "object",
+        "description": "Deadlines of the member by weekday as unix time, 0 means day off",
+        "properties": {
+          "user_id": {"type": "string"},
+          "id": {"type": "integer"},
+          "channel_member_id": {"type": "integer"},
+          "created": {"type": "string", "format": "date-time"},
+          "modified": {"type": "string", "format": "date-time"},
+          "monday": {"type": "integer"},
+          "tuesday": {"type": "integer"},
+          "wednesday": {"type": "integer"},
+          "thursday": {"type": "integer"},
+          "friday": {"type": "integer"},
+          "saturday": {"type": "integer"},
+          "sunday": {"type": "integer"}
+        }
+      },
+      "Standup": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "created": {"type": "string", "format": "date-time"},
+          "modified": {"type": "string", "format": "date-time"},
+          "channelId": {"type": "string"},
+          "userId": {"type": "string"},
+          "comment": {"type": "string"},
+          "message_ts": {"type": "string"}
+        }
+      },
+      "ReportEntry": {
+        "type": "object",
+        "properties": {
+          "date": {"type": "string", "format": "date"},
+          "channel_id": {"type": "string"},
+          "channel_name": {"type": "string"},
+          "user_id": {"type": "string"},
+          "user_name": {"type": "string"},
+          "status": {"type": "string", "enum": ["submitted", "missed"]},
+          "submitted": {"type": "string"},
+          "deadline": {"type": "string"},
+          "lateness_minutes": {"type": "string"},
+          "standup": {"type": "string"}
+        }
+      },
+      "Page": {
+        "type": "object",
+        "properties": {
+          "page": {"type": "integer"},
+          "per_page": {"type": "integer"},
+          "total": {"type": "integer"}
+        }
+      },
+      "ChannelPage": {"allOf": [{"$ref": "#/components/schemas/Page"}, {"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/Channel"}}}}]},
+      "MemberPage": {"allOf": [{"$ref": "#/components/schemas/Page"}, {"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/Member"}}}}]},
+      "TimeTablePage": {"allOf": [{"$ref": "#/components/schemas/Page"}, {"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/TimeTable"}}}}]},
+      "StandupPage": {"allOf": [{"$ref": "#/components/schemas/Page"}, {"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/Standup"}}}}]},
+      "ReportPage": {"allOf": [{"$ref": "#/components/schemas/Page"}, {"type": "object", "properties": {"data": {"type": "array", "items": {"$ref": "#/components/schemas/ReportEntry"}}}}]}
+    }
+  }
+}
+`
@@ -6,13 +6,16 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/schema"
+	"github.com/jasonlvhit/gocron"
 	"github.com/labstack/echo"
 	"github.com/maddevsio/comedian/chat"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
 	"github.com/maddevsio/comedian/model"
 	"github.com/maddevsio/comedian/reporting"
 	"github.com/maddevsio/comedian/storage"
@@ -30,6 +33,10 @@ type REST struct {
 	report  *reporting.Reporter
 	slack   *chat.Slack
 	api     *slack.Client
+
+	commands []command
+	// localizedCache keeps copies of REST with commands already built for every language
+	localizedCache *localizedCache
 }
 
 // FullSlackForm struct used for parsing full payload from slack
@@ -40,27 +47,7 @@ type FullSlackForm struct {
 	ChannelName string `schema:"channel_name"`
 }
 
-const (
-	commandAdd    = "/add"
-	commandDelete = "/delete"
-	commandList   = "/list"
-
-	commandAddTime    = "/standup_time_set"
-	commandRemoveTime = "/standup_time_remove"
-	commandListTime   = "/standup_time"
-
-	commandAddTimeTable    = "/timetable_set"
-	commandRemoveTimeTable = "/timetable_remove"
-	commandShowTimeTable   = "/timetable_show"
-
-	commandReportByProject       = "/report_by_project"
-	commandReportByUser          = "/report_by_user"
-	commandReportByUserInProject = "/report_by_user_in_project"
-
-	commandHelp = "/helper"
-)
-
-//ResponseText is Comedian API response text message to be displayed
+// ResponseText is Comedian API response text message to be displayed
 var ResponseText string
 
 // NewRESTAPI creates API for Slack commands
@@ -77,85 +64,48 @@ func NewRESTAPI(slack *chat.Slack) (*REST, error) {
 		db:      slack.DB,
 		slack:   slack,
 		api:     slack.API,
-		conf:    slack.Conf,
+		conf:    slack.Config(),
+
+		localizedCache: &localizedCache{},
 	}
 
+	r.registerCommands()
 	r.initEndpoints()
+	slack.Commands = r
 	return r, nil
 }
 
 func (r *REST) initEndpoints() {
 	r.echo.POST("/commands", r.handleCommands)
+	r.echo.GET("/dashboard", r.dashboard)
+	r.initAPIv1()
+	r.echo.GET("/metrics", r.metrics)
+	r.echo.GET("/healthz", r.healthz)
+	r.echo.GET("/readyz", r.readyz)
 }
 
-// Start starts http server
+// Start starts http server and schedules metrics which are too costly to collect on scrape
 func (r *REST) Start() error {
+	gocron.Every(1).Minute().Do(metrics.Job("non_reporters", r.updateNonReporters))
 	return r.echo.Start(r.conf.HTTPBindAddr)
 }
 
-func (r *REST) handleCommands(c echo.Context) error {
-	form, err := c.FormParams()
-	if err != nil {
-		logrus.Errorf("rest: c.FormParams failed: %v\n", err)
-	}
-	command := form.Get("command")
-
-	switch command {
-	case commandHelp:
-		return r.helpCommand(c, form)
-	case commandAdd:
-		return r.addCommand(c, form)
-	case commandList:
-		return r.listCommand(c, form)
-	case commandDelete:
-		return r.deleteCommand(c, form)
-	case commandAddTime:
-		return r.addTime(c, form)
-	case commandRemoveTime:
-		return r.removeTime(c, form)
-	case commandListTime:
-		return r.listTime(c, form)
-	case commandAddTimeTable:
-		return r.addTimeTable(c, form)
-	case commandRemoveTimeTable:
-		return r.removeTimeTable(c, form)
-	case commandShowTimeTable:
-		return r.showTimeTable(c, form)
-	case commandReportByProject:
-		return r.reportByProject(c, form)
-	case commandReportByUser:
-		return r.reportByUser(c, form)
-	case commandReportByUserInProject:
-		return r.reportByProjectAndUser(c, form)
-	default:
-		return c.String(http.StatusNotImplemented, "Not implemented")
-	}
-}
-
-func (r *REST) helpCommand(c echo.Context, f url.Values) error {
-	return c.String(http.StatusOK, r.conf.Translate.HelpCommand)
-}
-
 func (r *REST) addCommand(c echo.Context, f url.Values) error {
-	members, role, channel, accessLevel, err := r.processCommand(c, f)
+	members, role, channel, err := r.processCommand(c, f)
+	if err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	members, err = r.expandMembers(channel, members)
 	if err != nil {
+		logrus.Errorf("rest: expandMembers failed: %v\n", err)
 		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
 	}
 	switch role {
 	case "admin", "админ":
-		if accessLevel > 2 {
-			return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastAdmin)
-		}
 		return c.String(http.StatusOK, r.addAdmins(members))
 	case "developer", "разработчик", "":
-		if accessLevel > 3 {
-			return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-		}
 		return c.String(http.StatusOK, r.addMembers(members, "developer", channel))
 	case "pm", "пм":
-		if accessLevel > 2 {
-			return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastAdmin)
-		}
 		return c.String(http.StatusOK, r.addMembers(members, "pm", channel))
 	default:
 		return c.String(http.StatusOK, r.conf.Translate.NeedCorrectUserRole)
@@ -186,20 +136,14 @@ func (r *REST) listCommand(c echo.Context, f url.Values) error {
 
 func (r *REST) deleteCommand(c echo.Context, f url.Values) error {
 
-	users, role, channel, accessLevel, err := r.processCommand(c, f)
+	users, role, channel, err := r.processCommand(c, f)
 	if err != nil {
 		return c.String(http.StatusOK, err.Error())
 	}
 	switch role {
 	case "admin", "админ":
-		if accessLevel > 2 {
-			return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastAdmin)
-		}
 		return c.String(http.StatusOK, r.deleteAdmins(users))
 	case "developer", "разработчик", "pm", "пм", "":
-		if accessLevel > 3 {
-			return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-		}
 		return c.String(http.StatusOK, r.deleteMembers(users, channel))
 	default:
 		return c.String(http.StatusOK, r.conf.Translate.NeedCorrectUserRole)
@@ -268,7 +212,7 @@ func (r *REST) addAdmins(users []string) string {
 		}
 		user.Role = "admin"
 		r.db.UpdateUser(user)
-		message := r.conf.Translate.PMAssigned
+		message := r.slack.Translation("", userID).PMAssigned
 		err = r.slack.SendUserMessage(userID, message)
 		if err != nil {
 			logrus.Errorf("rest: SendUserMessage failed: %v\n", err)
@@ -378,7 +322,7 @@ func (r *REST) deleteAdmins(users []string) string {
 		}
 		user.Role = ""
 		r.db.UpdateUser(user)
-		message := fmt.Sprintf(r.conf.Translate.PMRemoved)
+		message := fmt.Sprintf(r.slack.Translation("", userID).PMRemoved)
 		err = r.slack.SendUserMessage(userID, message)
 		if err != nil {
 			logrus.Errorf("rest: SendUserMessage failed: %v\n", err)
@@ -403,12 +347,6 @@ func (r *REST) addTime(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-	}
-
 	timeInt, err := utils.ParseTimeTextToInt(ca.Text)
 	if err != nil {
 		return c.String(http.StatusOK, err.Error())
@@ -435,12 +373,6 @@ func (r *REST) removeTime(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-	}
-
 	err = r.db.DeleteStandupTime(ca.ChannelID)
 	if err != nil {
 		logrus.Errorf("rest: DeleteStandupTime failed: %v\n", err)
@@ -475,12 +407,6 @@ func (r *REST) addTimeTable(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-	}
-
 	usersText, weekdays, time, err := utils.SplitTimeTalbeCommand(ca.Text, r.conf.Translate.DaysDivider, r.conf.Translate.TimeDivider)
 	if err != nil {
 		return c.String(http.StatusOK, err.Error())
@@ -518,7 +444,7 @@ func (r *REST) addTimeTable(c echo.Context, f url.Values) error {
 				continue
 			}
 			logrus.Infof("Timetable created id:%v", ttNew.ID)
-			c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableCreated, userID, ttNew.Show()))
+			c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableCreated, userID, ttNew.Show(r.conf.Translate)))
 			continue
 		}
 		tt = utils.PrepareTimeTable(tt, weekdays, time)
@@ -528,7 +454,7 @@ func (r *REST) addTimeTable(c echo.Context, f url.Values) error {
 			continue
 		}
 		logrus.Infof("Timetable updated id:%v", tt.ID)
-		c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableUpdated, userID, tt.Show()))
+		c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableUpdated, userID, tt.Show(r.conf.Translate)))
 	}
 	return nil
 }
@@ -559,7 +485,7 @@ func (r *REST) showTimeTable(c echo.Context, f url.Values) error {
 			c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.NoTimetableSet, userName))
 			continue
 		}
-		c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableShow, userName, tt.Show()))
+		c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.TimetableShow, userName, tt.Show(r.conf.Translate)))
 	}
 	return nil
 }
@@ -571,12 +497,6 @@ func (r *REST) removeTimeTable(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
-	}
-
 	users := strings.Split(ca.Text, " ")
 	rg, _ := regexp.Compile("<@([a-z0-9]+)|([a-z0-9]+)>")
 	for _, u := range users {
@@ -613,13 +533,10 @@ func (r *REST) reportByProject(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPM)
+	commandParams, format, err := r.reportFormat(strings.Fields(ca.Text))
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
 	}
-
-	commandParams := strings.Fields(ca.Text)
 	if len(commandParams) != 3 {
 		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
 	}
@@ -647,22 +564,15 @@ func (r *REST) reportByProject(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	report, err := r.report.StandupReportByProject(channel, dateFrom, dateTo)
-	if err != nil {
-		logrus.Errorf("rest: StandupReportByProject: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
-	}
+	return r.respondAsync(c, f, func() string {
+		report, err := r.report.StandupReportByProject(channel, dateFrom, dateTo)
+		if err != nil {
+			logrus.Errorf("rest: StandupReportByProject: %v\n", err)
+			return err.Error()
+		}
 
-	text := ""
-	text += report.ReportHead
-	if len(report.ReportBody) == 0 {
-		text += r.conf.Translate.ReportNoData
-		return c.String(http.StatusOK, text)
-	}
-	for _, t := range report.ReportBody {
-		text += t.Text
-	}
-	return c.String(http.StatusOK, text)
+		return r.reportResult(report, format, f.Get("user_id"), "report_"+channel.ChannelName+"_"+commandParams[1]+"_"+commandParams[2])
+	})
 }
 
 func (r *REST) reportByUser(c echo.Context, f url.Values) error {
@@ -672,7 +582,10 @@ func (r *REST) reportByUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	commandParams := strings.Fields(ca.Text)
+	commandParams, format, err := r.reportFormat(strings.Fields(ca.Text))
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
 	if len(commandParams) != 3 {
 		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
 	}
@@ -682,12 +595,6 @@ func (r *REST) reportByUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, "User does not exist!")
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if f.Get("user_id") != user.UserID && accessLevel > 2 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastAdminOrOwner)
-	}
-
 	dateFrom, err := time.Parse("2006-01-02", commandParams[1])
 	if err != nil {
 		logrus.Errorf("rest: time.Parse failed: %v\n", err)
@@ -699,22 +606,15 @@ func (r *REST) reportByUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	report, err := r.report.StandupReportByUser(user.UserID, dateFrom, dateTo)
-	if err != nil {
-		logrus.Errorf("rest: StandupReportByUser failed: %v\n", err)
-		return c.String(http.StatusOK, err.Error())
-	}
+	return r.respondAsync(c, f, func() string {
+		report, err := r.report.StandupReportByUser(user.UserID, dateFrom, dateTo)
+		if err != nil {
+			logrus.Errorf("rest: StandupReportByUser failed: %v\n", err)
+			return err.Error()
+		}
 
-	text := ""
-	text += report.ReportHead
-	if len(report.ReportBody) == 0 {
-		text += r.conf.Translate.ReportNoData
-		return c.String(http.StatusOK, text)
-	}
-	for _, t := range report.ReportBody {
-		text += t.Text
-	}
-	return c.String(http.StatusOK, text)
+		return r.reportResult(report, format, f.Get("user_id"), "report_"+user.UserName+"_"+commandParams[1]+"_"+commandParams[2])
+	})
 }
 
 func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
@@ -724,7 +624,10 @@ func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	commandParams := strings.Fields(ca.Text)
+	commandParams, format, err := r.reportFormat(strings.Fields(ca.Text))
+	if err != nil {
+		return c.String(http.StatusOK, err.Error())
+	}
 	if len(commandParams) != 4 {
 		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
 	}
@@ -753,12 +656,6 @@ func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.CanNotFindMember, user.UserID))
 	}
 
-	accessLevel, _ := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	logrus.Infof("Access level for %v in %v is %v", f.Get("user_id"), f.Get("channel_id"), accessLevel)
-	if (f.Get("user_id") != member.UserID && f.Get("channel_id") != member.ChannelID) && accessLevel > 3 {
-		return c.String(http.StatusOK, r.conf.Translate.AccessAtLeastPMOrOwner)
-	}
-
 	dateFrom, err := time.Parse("2006-01-02", commandParams[2])
 	if err != nil {
 		logrus.Errorf("rest: time.Parse failed: %v\n", err)
@@ -770,39 +667,654 @@ func (r *REST) reportByProjectAndUser(c echo.Context, f url.Values) error {
 		return c.String(http.StatusOK, err.Error())
 	}
 
-	report, err := r.report.StandupReportByProjectAndUser(channel, member.UserID, dateFrom, dateTo)
+	return r.respondAsync(c, f, func() string {
+		report, err := r.report.StandupReportByProjectAndUser(channel, member.UserID, dateFrom, dateTo)
+		if err != nil {
+			logrus.Errorf("rest: StandupReportByProjectAndUser failed: %v\n", err)
+			return err.Error()
+		}
+
+		return r.reportResult(report, format, f.Get("user_id"), "report_"+channel.ChannelName+"_"+user.UserName+"_"+commandParams[2]+"_"+commandParams[3])
+	})
+}
+
+// reportFormat removes format=... argument from report command params and returns the format
+func (r *REST) reportFormat(params []string) ([]string, string, error) {
+	rest := []string{}
+	format := ""
+	for _, param := range params {
+		if !strings.HasPrefix(strings.ToLower(param), "format=") {
+			rest = append(rest, param)
+			continue
+		}
+		format = strings.TrimPrefix(strings.ToLower(param), "format=")
+		if format == "md" {
+			format = reporting.FormatMarkdown
+		}
+		known := false
+		for _, f := range reporting.Formats {
+			if f == format {
+				known = true
+			}
+		}
+		if !known {
+			return rest, "", fmt.Errorf(r.conf.Translate.WrongReportFormat, strings.Join(reporting.Formats, ", "))
+		}
+	}
+	return rest, format, nil
+}
+
+// reportResult renders report as a message, or uploads it as a file if format is set
+func (r *REST) reportResult(report reporting.Report, format, userID, filename string) string {
+	if format == "" {
+		return report.Text(r.conf.Translate.ReportNoData)
+	}
+	content, filetype, extension, err := report.Export(format, r.conf.Translate)
+	if err != nil {
+		logrus.Errorf("rest: report.Export failed: %v\n", err)
+		return r.conf.Translate.SomethingWentWrong
+	}
+	err = r.slack.UploadSnippet(userID, filename+"."+extension, filetype, content)
+	if err != nil {
+		logrus.Errorf("rest: UploadSnippet failed: %v\n", err)
+		return r.conf.Translate.SomethingWentWrong
+	}
+	return r.conf.Translate.ReportExported
+}
+
+// respondAsync acknowledges the command right away and sends its result to response_url
+// when it is ready, so building long reports does not hit Slack's 3 seconds timeout
+func (r *REST) respondAsync(c echo.Context, f url.Values, result func() string) error {
+	responseURL := f.Get("response_url")
+	if responseURL == "" {
+		return c.String(http.StatusOK, result())
+	}
+	userID := f.Get("user_id")
+	go func() {
+		err := r.slack.SendResponse(responseURL, userID, result())
+		if err != nil {
+			logrus.Errorf("rest: SendResponse failed: %v\n", err)
+		}
+	}()
+	return c.String(http.StatusOK, r.conf.Translate.ReportInProgress)
+}
+
+func (r *REST) setStandupRules(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	commandParams := strings.Fields(ca.Text)
+	if len(commandParams) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+	}
+
+	rules := r.slack.StandupRules(ca.ChannelID)
+	sections, err := rules.ListSections()
 	if err != nil {
-		logrus.Errorf("rest: StandupReportByProjectAndUser failed: %v\n", err)
+		logrus.Errorf("rest: ListSections failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+
+	switch commandParams[0] {
+	case "reset":
+		err := r.db.DeleteStandupRules(ca.ChannelID)
+		if err != nil {
+			logrus.Errorf("rest: DeleteStandupRules failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, r.conf.Translate.StandupRulesReset)
+	case "section":
+		if len(commandParams) < 3 {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+		}
+		section, err := parseStandupSection(commandParams[1], strings.Join(commandParams[2:], " "))
+		if err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesWrongPattern, err))
+		}
+		if len(section.Keywords) == 0 && section.Pattern == "" {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+		}
+		sections = addStandupSection(rules, sections, section)
+	case "remove":
+		if len(commandParams) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+		}
+		left := []model.StandupSection{}
+		for _, section := range sections {
+			if section.Name != strings.ToLower(commandParams[1]) {
+				left = append(left, section)
+			}
+		}
+		if len(left) == len(sections) {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesNoSuchSection, commandParams[1]))
+		}
+		sections = left
+	case "min_length":
+		if len(commandParams) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+		}
+		minLength, err := strconv.Atoi(commandParams[1])
+		if err != nil || minLength < 0 {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongMinLength)
+		}
+		rules.MinLength = minLength
+	case "ticket":
+		if len(commandParams) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+		}
+		if commandParams[1] == "off" {
+			rules.TicketPattern = ""
+			break
+		}
+		if _, err := regexp.Compile(commandParams[1]); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesWrongPattern, err))
+		}
+		rules.TicketPattern = commandParams[1]
+	default:
+		return c.String(http.StatusOK, r.conf.Translate.StandupRulesWrongFormat)
+	}
+
+	err = rules.SetSections(sections)
+	if err != nil {
+		logrus.Errorf("rest: SetSections failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if rules.ID == 0 {
+		rules, err = r.db.CreateStandupRules(rules)
+	} else {
+		rules, err = r.db.UpdateStandupRules(rules)
+	}
+	if err != nil {
+		logrus.Errorf("rest: saving standup rules failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesUpdated, r.describeStandupRules(rules)))
+}
+
+func (r *REST) showStandupRules(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
 		return c.String(http.StatusOK, err.Error())
 	}
 
+	rules := r.slack.StandupRules(ca.ChannelID)
+	if rules.ID == 0 {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesShowDefault, r.describeStandupRules(rules)))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.StandupRulesShow, r.describeStandupRules(rules)))
+}
+
+func (r *REST) describeStandupRules(rules model.StandupRules) string {
 	text := ""
-	text += report.ReportHead
-	if len(report.ReportBody) == 0 {
-		text += r.conf.Translate.ReportNoData
-		return c.String(http.StatusOK, text)
+	sections, err := rules.ListSections()
+	if err != nil {
+		logrus.Errorf("rest: ListSections failed: %v\n", err)
+	}
+	for _, section := range sections {
+		text += fmt.Sprintf(r.conf.Translate.StandupRulesSection, section.Name, chat.DescribeSection(section))
+	}
+	if len(sections) == 0 {
+		text += r.conf.Translate.StandupRulesNoSections
+	}
+	if rules.MinLength > 0 {
+		text += fmt.Sprintf(r.conf.Translate.StandupRulesMinLength, r.conf.Translate.Plural(config.PluralCharacters, rules.MinLength))
+	}
+	if rules.TicketPattern != "" {
+		text += fmt.Sprintf(r.conf.Translate.StandupRulesTicket, rules.TicketPattern)
+	}
+	return text
+}
+
+// addStandupSection adds the section or replaces the one with the same name. Channels without
+// their own rules check built-in sections, the first custom section replaces them instead of
+// extending them, so the channel requires only sections it has set
+func addStandupSection(rules model.StandupRules, sections []model.StandupSection, section model.StandupSection) []model.StandupSection {
+	if rules.ID == 0 {
+		return []model.StandupSection{section}
+	}
+	for i := range sections {
+		if sections[i].Name == section.Name {
+			sections[i] = section
+			return sections
+		}
+	}
+	return append(sections, section)
+}
+
+// parseStandupSection builds section from its definition which is either
+// a comma separated list of keywords or a regular expression wrapped in slashes
+func parseStandupSection(name, definition string) (model.StandupSection, error) {
+	section := model.StandupSection{Name: strings.ToLower(name)}
+	if len(definition) > 2 && strings.HasPrefix(definition, "/") && strings.HasSuffix(definition, "/") {
+		pattern := definition[1 : len(definition)-1]
+		if _, err := regexp.Compile(pattern); err != nil {
+			return section, err
+		}
+		section.Pattern = pattern
+		return section, nil
+	}
+	for _, keyword := range strings.Split(definition, ",") {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" {
+			section.Keywords = append(section.Keywords, keyword)
+		}
+	}
+	return section, nil
+}
+
+func (r *REST) interviewMode(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	channel, err := r.db.SelectChannel(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: SelectChannel failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+
+	mode := strings.TrimSpace(ca.Text)
+	switch mode {
+	case "":
+		if channel.Interview {
+			return c.String(http.StatusOK, r.conf.Translate.InterviewModeShowOn)
+		}
+		return c.String(http.StatusOK, r.conf.Translate.InterviewModeShowOff)
+	case "on", "off":
+		channel.Interview = mode == "on"
+		_, err := r.db.UpdateChannel(channel)
+		if err != nil {
+			logrus.Errorf("rest: UpdateChannel failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		if channel.Interview {
+			return c.String(http.StatusOK, r.conf.Translate.InterviewModeOn)
+		}
+		return c.String(http.StatusOK, r.conf.Translate.InterviewModeOff)
+	default:
+		return c.String(http.StatusOK, r.conf.Translate.InterviewModeWrongFormat)
+	}
+}
+
+func (r *REST) threadMode(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	channel, err := r.db.SelectChannel(ca.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: SelectChannel failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+
+	mode := strings.TrimSpace(ca.Text)
+	switch mode {
+	case "":
+		if channel.Threaded {
+			return c.String(http.StatusOK, r.conf.Translate.ThreadModeShowOn)
+		}
+		return c.String(http.StatusOK, r.conf.Translate.ThreadModeShowOff)
+	case "on", "off":
+		channel.Threaded = mode == "on"
+		_, err := r.db.UpdateChannel(channel)
+		if err != nil {
+			logrus.Errorf("rest: UpdateChannel failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		if channel.Threaded {
+			return c.String(http.StatusOK, r.conf.Translate.ThreadModeOn)
+		}
+		return c.String(http.StatusOK, r.conf.Translate.ThreadModeOff)
+	default:
+		return c.String(http.StatusOK, r.conf.Translate.ThreadModeWrongFormat)
+	}
+}
+
+func (r *REST) myStats(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	dateFrom, dateTo, err := statsPeriod(ca.Text, time.Now())
+	if err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.WrongStatsPeriod)
+	}
+	userID := f.Get("user_id")
+
+	return r.respondAsync(c, f, func() string {
+		stats, err := r.report.UserStats(userID, dateFrom, dateTo)
+		if err != nil {
+			logrus.Errorf("rest: UserStats failed: %v\n", err)
+			return err.Error()
+		}
+		text := fmt.Sprintf(r.conf.Translate.MyStatsHead, dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02"))
+		if len(stats.Channels) == 0 {
+			return text + r.conf.Translate.MyStatsNoChannels
+		}
+		text += fmt.Sprintf(r.conf.Translate.MyStatsChannels, "#"+strings.Join(stats.Channels, ", #"))
+		text += fmt.Sprintf(r.conf.Translate.MyStatsSubmitted, stats.Submitted, stats.Days, stats.SubmissionRate())
+		text += fmt.Sprintf(r.conf.Translate.MyStatsStreak, r.conf.Translate.Plural(config.PluralDays, stats.Streak), r.conf.Translate.Plural(config.PluralDays, stats.LongestStreak))
+		text += fmt.Sprintf(r.conf.Translate.MyStatsPunctuality, stats.PunctualityRate(), stats.AverageLatenessMinutes())
+		text += fmt.Sprintf(r.conf.Translate.MyStatsExcused, stats.Excused)
+		return text
+	})
+}
+
+// statsPeriod parses period of /my_stats: week, month, year, number of days
+// or two dates. Last 30 days are used by default
+func statsPeriod(text string, now time.Time) (time.Time, time.Time, error) {
+	params := strings.Fields(text)
+	days := 30
+	switch {
+	case len(params) == 0:
+	case len(params) == 2:
+		dateFrom, err := time.Parse("2006-01-02", params[0])
+		if err != nil {
+			return now, now, err
+		}
+		dateTo, err := time.Parse("2006-01-02", params[1])
+		return dateFrom, dateTo, err
+	case len(params) > 2:
+		return now, now, errors.New("too many arguments")
+	case params[0] == "week":
+		days = 7
+	case params[0] == "month":
+		days = 30
+	case params[0] == "year":
+		days = 365
+	default:
+		n, err := strconv.Atoi(strings.TrimSuffix(params[0], "d"))
+		if err != nil || n <= 0 {
+			return now, now, errors.New("wrong period")
+		}
+		days = n
+	}
+	return now.AddDate(0, 0, 1-days), now, nil
+}
+
+func (r *REST) blockers(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	commandParams := strings.Fields(ca.Text)
+	channelID := ca.ChannelID
+	switch {
+	case len(commandParams) == 2 && commandParams[0] == "resolve":
+		return r.resolveBlocker(c, f, commandParams[1])
+	case len(commandParams) == 1:
+		if !strings.HasPrefix(commandParams[0], "#") && !strings.HasPrefix(commandParams[0], "<#") {
+			return c.String(http.StatusOK, r.conf.Translate.BlockersWrongFormat)
+		}
+		channelID, err = r.parseRecipient(commandParams[0])
+		if err != nil {
+			logrus.Errorf("rest: parseRecipient failed: %v\n", err)
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.BlockersNoSuchChannel, commandParams[0]))
+		}
+	case len(commandParams) != 0:
+		return c.String(http.StatusOK, r.conf.Translate.BlockersWrongFormat)
+	}
+	blockers, err := r.db.ListOpenBlockers(channelID)
+	if err != nil {
+		logrus.Errorf("rest: ListOpenBlockers failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if len(blockers) == 0 {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.BlockersNoOpen, channelID))
+	}
+	text := fmt.Sprintf(r.conf.Translate.BlockersHeader, channelID)
+	for _, blocker := range blockers {
+		text += fmt.Sprintf(r.conf.Translate.BlockersItem, blocker.ID, blocker.UserID, blocker.Created.Format("2006-01-02"), blocker.Text)
+	}
+	return c.String(http.StatusOK, text)
+}
+
+// blockersChannel returns channel blockers are listed in, it is named like #general
+func (r *REST) blockersChannel(param string) (string, error) {
+	if !strings.HasPrefix(param, "#") && !strings.HasPrefix(param, "<#") {
+		return "", errors.New(r.conf.Translate.BlockersWrongFormat)
+	}
+	channelID, err := r.parseRecipient(param)
+	if err != nil {
+		return "", fmt.Errorf(r.conf.Translate.BlockersNoSuchChannel, param)
+	}
+	return channelID, nil
+}
+
+// blockerChannel returns channel of the blocker with the id
+func (r *REST) blockerChannel(param string) (string, error) {
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return "", errors.New(r.conf.Translate.BlockersWrongFormat)
+	}
+	blocker, err := r.db.SelectBlocker(id)
+	if err != nil {
+		return "", fmt.Errorf(r.conf.Translate.BlockersNotFound, id)
+	}
+	return blocker.ChannelID, nil
+}
+
+func (r *REST) resolveBlocker(c echo.Context, f url.Values, param string) error {
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.BlockersWrongFormat)
 	}
-	for _, t := range report.ReportBody {
-		text += t.Text
+	blocker, err := r.db.SelectBlocker(id)
+	if err != nil {
+		logrus.Errorf("rest: SelectBlocker failed: %v\n", err)
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.BlockersNotFound, id))
+	}
+
+	if blocker.Resolved {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.BlockersResolved, id))
+	}
+	blocker.Resolved = true
+	blocker.ResolvedBy = f.Get("user_id")
+	_, err = r.db.UpdateBlocker(blocker)
+	if err != nil {
+		logrus.Errorf("rest: UpdateBlocker failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	r.slack.SendUserMessage(blocker.UserID, fmt.Sprintf(r.slack.Translation(blocker.ChannelID, blocker.UserID).BlockersResolvedNotify, blocker.ChannelID, blocker.ResolvedBy))
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.BlockersResolved, id))
+}
+
+func (r *REST) deliveryStatus(c echo.Context, f url.Values) error {
+	_, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	counts, err := r.db.CountOutboundMessages()
+	if err != nil {
+		logrus.Errorf("rest: CountOutboundMessages failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	text := fmt.Sprintf(r.conf.Translate.DeliveryStatus, counts["pending"], counts["sent"], counts["failed"])
+	for _, status := range []string{"pending", "failed"} {
+		messages, err := r.db.ListOutboundMessagesByStatus(status, 5)
+		if err != nil {
+			logrus.Errorf("rest: ListOutboundMessagesByStatus failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		for _, m := range messages {
+			recipient := m.ChannelID
+			if recipient == "" {
+				recipient = m.UserID
+			}
+			text += fmt.Sprintf(r.conf.Translate.DeliveryStatusMessage, m.ID, m.Status, m.Kind, recipient, m.Attempts, m.LastError)
+		}
 	}
 	return c.String(http.StatusOK, text)
 }
 
-func (r *REST) getAccessLevel(userID, channelID string) (int, error) {
-	user, err := r.db.SelectUser(userID)
+func (r *REST) digestSet(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
 	if err != nil {
-		return 0, err
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+	args := strings.Fields(ca.Text)
+	if len(args) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	if args[0] == "remove" {
+		if len(args) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.DigestNotFound, args[1]))
+		}
+		if _, err := r.db.SelectDigest(id); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.DigestNotFound, args[1]))
+		}
+		err = r.db.DeleteDigest(id)
+		if err != nil {
+			logrus.Errorf("rest: DeleteDigest failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.DigestRemoved, id))
 	}
-	if userID == r.conf.ManagerSlackUserID {
-		return 1, nil
+
+	digest := model.Digest{ChannelID: ca.ChannelID, Period: args[0], Time: r.conf.ReportTime}
+	if digest.Period != model.DigestWeekly && digest.Period != model.DigestMonthly {
+		return c.String(http.StatusOK, r.conf.Translate.WrongDigestPeriod)
 	}
-	if user.IsAdmin() {
-		return 2, nil
+	recipients := []string{}
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "all":
+			digest.ChannelID = ""
+		case args[i] == "to":
+			continue
+		case args[i] == "at" && i+1 < len(args):
+			i++
+			if _, _, err := utils.FormatTime(args[i]); err != nil {
+				return c.String(http.StatusOK, r.conf.Translate.WrongDigestTime)
+			}
+			digest.Time = args[i]
+		default:
+			recipient, err := r.parseRecipient(args[i])
+			if err != nil {
+				return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDigestRecipient, args[i]))
+			}
+			recipients = append(recipients, recipient)
+		}
 	}
-	if r.db.UserIsPMForProject(userID, channelID) {
-		return 3, nil
+	if len(recipients) == 0 {
+		recipients = append(recipients, ca.ChannelID)
+		if digest.ChannelID == "" {
+			recipients = []string{r.conf.ReportingChannel}
+		}
 	}
-	return 4, nil
+	digest.Recipients = strings.Join(recipients, ",")
+
+	digests, err := r.db.ListDigests()
+	if err != nil {
+		logrus.Errorf("rest: ListDigests failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	for _, d := range digests {
+		if d.ChannelID == digest.ChannelID && d.Period == digest.Period {
+			digest.ID = d.ID
+			digest.LastSent = d.LastSent
+		}
+	}
+	if digest.ID != 0 {
+		digest, err = r.db.UpdateDigest(digest)
+	} else {
+		digest, err = r.db.CreateDigest(digest)
+	}
+	if err != nil {
+		logrus.Errorf("rest: saving digest failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.DigestSaved, r.describeDigest(digest)))
+}
+
+// digestChannel returns channel of the digest with the id, digests of all channels have no channel
+func (r *REST) digestChannel(param string) (string, error) {
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf(r.conf.Translate.DigestNotFound, param)
+	}
+	digest, err := r.db.SelectDigest(id)
+	if err != nil {
+		return "", fmt.Errorf(r.conf.Translate.DigestNotFound, param)
+	}
+	return digest.ChannelID, nil
+}
+
+func (r *REST) digestList(c echo.Context, f url.Values) error {
+	_, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	digests, err := r.db.ListDigests()
+	if err != nil {
+		logrus.Errorf("rest: ListDigests failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if len(digests) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.DigestListEmpty)
+	}
+	text := r.conf.Translate.DigestListHead
+	for _, digest := range digests {
+		text += r.describeDigest(digest) + "\n"
+	}
+	return c.String(http.StatusOK, text)
+}
+
+// describeDigest returns digest id, period, channel, time and recipients in a line
+func (r *REST) describeDigest(digest model.Digest) string {
+	channel := r.conf.Translate.DigestAllChannels
+	if digest.ChannelID != "" {
+		channel = "<#" + digest.ChannelID + ">"
+	}
+	return fmt.Sprintf(r.conf.Translate.DigestItem, digest.ID, digest.Period, channel, digest.Time, mentionRecipients(digest.RecipientsList()))
+}
+
+// mentionRecipients returns mentions of channels and users messages are sent to
+func mentionRecipients(recipients []string) string {
+	mentions := []string{}
+	for _, recipient := range recipients {
+		if strings.HasPrefix(recipient, "U") || strings.HasPrefix(recipient, "W") {
+			mentions = append(mentions, "<@"+recipient+">")
+			continue
+		}
+		mentions = append(mentions, "<#"+recipient+">")
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// parseRecipient returns ID of channel or user mentioned like <#C123|name>, <@U123|name>, #name or @name
+func (r *REST) parseRecipient(mention string) (string, error) {
+	switch {
+	case strings.HasPrefix(mention, "<#") || strings.HasPrefix(mention, "<@"):
+		id := strings.TrimSuffix(mention[2:], ">")
+		return strings.Split(id, "|")[0], nil
+	case strings.HasPrefix(mention, "#"):
+		return r.db.GetChannelID(strings.TrimPrefix(mention, "#"))
+	case strings.HasPrefix(mention, "@"):
+		user, err := r.db.SelectUserByUserName(strings.TrimPrefix(mention, "@"))
+		return user.UserID, err
+	}
+	return "", errors.New("unknown recipient")
 }
 
 func (r *REST) comedianIsInChannel(channelID string) bool {
@@ -828,26 +1340,21 @@ func (r *REST) validateRequest(c echo.Context, f url.Values) (FullSlackForm, err
 	return ca, nil
 }
 
-func (r *REST) processCommand(c echo.Context, f url.Values) ([]string, string, string, int, error) {
+func (r *REST) processCommand(c echo.Context, f url.Values) ([]string, string, string, error) {
 	ca, err := r.validateRequest(c, f)
 	if err != nil {
 		logrus.Errorf("Validate Request Failed: %v", err)
-		return []string{}, "", "", 0, err
+		return []string{}, "", "", err
 	}
 
-	accessLevel, err := r.getAccessLevel(f.Get("user_id"), f.Get("channel_id"))
-	if err != nil {
-		logrus.Errorf("getAccessLevel Failed: %v", err)
-		return []string{}, "", "", 0, err
-	}
 	parts := strings.Split(ca.Text, "/")
 
 	if len(parts) > 1 {
 		users := strings.Split(strings.TrimSpace(parts[0]), " ")
-		return users, strings.TrimSpace(parts[1]), ca.ChannelID, accessLevel, nil
+		return users, strings.TrimSpace(parts[1]), ca.ChannelID, nil
 	}
 	users := strings.Split(ca.Text, " ")
-	return users, "developer", ca.ChannelID, accessLevel, nil
+	return users, "developer", ca.ChannelID, nil
 }
 
 // Validate validates struct
@@ -1,9 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -12,7 +15,11 @@ import (
 	"github.com/labstack/echo"
 	"github.com/maddevsio/comedian/chat"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/fakeslack"
 	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/maddevsio/comedian/storage"
+	"github.com/nlopes/slack"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
@@ -64,17 +71,17 @@ func TestHandleCommands(t *testing.T) {
 	}{
 		{"SuperAdminID", "TestChannelID", "TestChannel", "", "", "Not implemented"},
 
-		{"", "TestChannelID", "TestChannel", "add", "<@userID1|userName>", "Something went wrong. Please, try again later or report the problem to chatbot support!"},
+		{"", "TestChannelID", "TestChannel", "add", "<@userID1|userName>", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@userID1|userName>", "Members are assigned: <@userID1|userName>\n"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@userID2|userName> / admin", "Users are assigned as admins: <@userID2|userName>\n"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@userID2|userName> / wrongUserRole", "Please, check correct role name (admin, developer, pm)"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@userID3|userName> / developer", "Members are assigned: <@userID3|userName>\n"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@userID4|userName> / pm", "Members are assigned: <@userID4|userName>\n"},
 
-		{"userID", "TestChannelID", "TestChannel", "add", "<@userID1|userName>", "Access Denied! You need to be at least PM in this project to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "add", "<@userID2|userName> / admin", "Access Denied! You need to be at least admin in this slack to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "add", "<@userID3|userName> / developer", "Access Denied! You need to be at least PM in this project to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "add", "<@userID4|userName> / pm", "Access Denied! You need to be at least admin in this slack to use this command!"},
+		{"userID", "TestChannelID", "TestChannel", "add", "<@userID1|userName>", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "add", "<@userID2|userName> / admin", "Access Denied! You need `roles.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "add", "<@userID3|userName> / developer", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "add", "<@userID4|userName> / pm", "Access Denied! You need `roles.manage` permission to use this command. Run `/roles` to see roles which have it"},
 
 		{"SuperAdminID", "", "", "delete", "<@userID1|userName>", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@userID1|userName>", "The following members were removed: <@userID1|userName>\n"},
@@ -83,10 +90,10 @@ func TestHandleCommands(t *testing.T) {
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@userID3|userName> / developer", "The following members were removed: <@userID3|userName>\n"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@userID4|userName> / pm", "The following members were removed: <@userID4|userName>\n"},
 
-		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID1|userName>", "Access Denied! You need to be at least PM in this project to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID2|userName> / admin", "Access Denied! You need to be at least admin in this slack to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID3|userName> / developer", "Access Denied! You need to be at least PM in this project to use this command!"},
-		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID4|userName> / pm", "Access Denied! You need to be at least PM in this project to use this command!"},
+		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID1|userName>", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID2|userName> / admin", "Access Denied! You need `roles.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID3|userName> / developer", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"userID", "TestChannelID", "TestChannel", "delete", "<@userID4|userName> / pm", "Access Denied! You need `members.manage` permission to use this command. Run `/roles` to see roles which have it"},
 
 		{"SuperAdminID", "WrongChannelID", "TestChannel", "list", "", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "list", "", "No standupers in this channel! To add one, please, use `/add` slash command"},
@@ -259,6 +266,13 @@ func TestHandleTimeCommands(t *testing.T) {
 		StandupTime: int64(0),
 	})
 
+	otherChannel, err := rest.db.CreateChannel(model.Channel{
+		ChannelName: "OtherChannel",
+		ChannelID:   "OtherChannelID",
+		StandupTime: int64(0),
+	})
+	assert.NoError(t, err)
+
 	testCases := []struct {
 		senderID     string
 		channelID    string
@@ -270,7 +284,7 @@ func TestHandleTimeCommands(t *testing.T) {
 		{"testID", "TestChannelID", "TestChannel", "standup_time", "", "No standup time set for this channel yet! Please, add a standup time using `/standup_time_set` command!"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "standup_time", "", "No standup time set for this channel yet! Please, add a standup time using `/standup_time_set` command!"},
 		{"SuperAdminID", "wrongchannel", "xyz", "standup_time", "", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
-		{"testID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "Access Denied! You need to be at least PM in this project to use this command!"},
+		{"testID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@testID|testUser> / pm", "Members are assigned: <@testID|testUser>\n"},
 		{"testID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "<!date^1539151500^Standup time set at {time}|Standup time set at 12:00>"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@testID|testUser> / pm", "The following members were removed: <@testID|testUser>\n"},
@@ -283,9 +297,18 @@ func TestHandleTimeCommands(t *testing.T) {
 		{"SuperAdminID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "<!date^1539151500^Standup time set at {time}|Standup time set at 12:00>"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "standup_time", "", "<!date^1539151500^Standup time is {time}|Standup time set at 12:00>"},
 		{"SuperAdminID", "wrongchannel", "xyz", "standup_time_remove", "", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
-		{"testID", "TestChannelID", "TestChannel", "standup_time_remove", "", "Access Denied! You need to be at least PM in this project to use this command!"},
+		{"testID", "TestChannelID", "TestChannel", "standup_time_remove", "", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "standup_time_remove", "", "standup time for this channel removed, but there are people marked as a standuper."},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@testID|testUser> / developer", "The following members were removed: <@testID|testUser>\n"},
+
+		{"SuperAdminID", "TestChannelID", "TestChannel", "roles", "bind <@testID|testUser> pm <#OtherChannelID|OtherChannel>", "<@testID> is now pm in <#OtherChannelID>"},
+		{"testID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
+		{"testID", "OtherChannelID", "OtherChannel", "standup_time_remove", "", "standup time for OtherChannel channel deleted"},
+		{"SuperAdminID", "TestChannelID", "TestChannel", "roles", "bind <@testID|testUser> pm <#TestChannelID|TestChannel>", "<@testID> is now pm in <#TestChannelID>"},
+		{"testID", "TestChannelID", "TestChannel", "standup_time_set", "12:05", "<!date^1539151500^Standup time at {time} added, but there is no standup users for this channel|Standup time at 12:00 added, but there is no standup users for this channel>"},
+		{"SuperAdminID", "TestChannelID", "TestChannel", "roles", "unbind <@testID|testUser> pm <#TestChannelID|TestChannel>", "<@testID> is no longer pm in <#TestChannelID>"},
+		{"SuperAdminID", "TestChannelID", "TestChannel", "roles", "unbind <@testID|testUser> pm <#OtherChannelID|OtherChannel>", "<@testID> is no longer pm in <#OtherChannelID>"},
+		{"testID", "TestChannelID", "TestChannel", "standup_time_remove", "", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
 	}
 
 	for _, tt := range testCases {
@@ -306,6 +329,7 @@ func TestHandleTimeCommands(t *testing.T) {
 	}
 
 	assert.NoError(t, rest.db.DeleteChannel(channel.ID))
+	assert.NoError(t, rest.db.DeleteChannel(otherChannel.ID))
 	assert.NoError(t, rest.db.DeleteUser(admin.ID))
 	assert.NoError(t, rest.db.DeleteUser(user.ID))
 
@@ -354,7 +378,7 @@ func TestTimeTableCommand(t *testing.T) {
 		response     string
 	}{
 		{"testID", "", "", "timetable_set", "", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
-		{"testID", "TestChannelID", "TestChannel", "timetable_set", "", "Access Denied! You need to be at least PM in this project to use this command!"},
+		{"testID", "TestChannelID", "TestChannel", "timetable_set", "", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@testID|testUser> / pm", "Members are assigned: <@testID|testUser>\n"},
 		{"testID", "TestChannelID", "TestChannel", "timetable_set", "12:05", "Sorry, could not understand where are the standupers and where is the rest of the command. Please, check the text for mistakes and try again"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@testID|testUser> / pm", "The following members were removed: <@testID|testUser>\n"},
@@ -375,7 +399,7 @@ func TestTimeTableCommand(t *testing.T) {
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@testID|testUser> / developer", "The following members were removed: <@testID|testUser>\n"},
 
 		{"testID", "", "", "timetable_remove", "", "I do not have this channel in my database... Please, reinvite me if I am already here and try again!"},
-		{"testID", "TestChannelID", "TestChannel", "timetable_remove", "", "Access Denied! You need to be at least PM in this project to use this command!"},
+		{"testID", "TestChannelID", "TestChannel", "timetable_remove", "", "Access Denied! You need `schedule.manage` permission to use this command. Run `/roles` to see roles which have it"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "add", "<@testID|testUser> / pm", "Members are assigned: <@testID|testUser>\n"},
 		{"testID", "TestChannelID", "TestChannel", "timetable_remove", "", "Seems like you misspelled username. Please, check and try command again!"},
 		{"SuperAdminID", "TestChannelID", "TestChannel", "delete", "<@testID|testUser> / pm", "The following members were removed: <@testID|testUser>\n"},
@@ -411,6 +435,10 @@ func TestTimeTableCommand(t *testing.T) {
 func TestHandleReportByProjectCommands(t *testing.T) {
 	ReportByProjectEmptyText := "user_id=SuperAdminID&command=/report_by_project&channel_name=privatechannel&channel_id=chanid&channel_name=channame&text="
 	ReportByProject := "user_id=SuperAdminID&command=/report_by_project&channel_name=privatechannel&channel_id=chanid&channel_name=channame&text=#chanName 2018-06-25 2018-06-26"
+	ReportByProjectViewer := "user_id=viewerID&command=/report_by_project&channel_id=chanid&channel_name=chanName&text=#chanName 2018-06-25 2018-06-26"
+	ReportByProjectOtherViewer := "user_id=viewerID&command=/report_by_project&channel_id=chanid&channel_name=chanName&text=#otherChanName 2018-06-25 2018-06-26"
+	BindReportViewer := "user_id=SuperAdminID&command=/roles&channel_id=chanid&channel_name=chanName&text=bind <@viewerID|viewer> report_viewer <#chanid|chanName>"
+	UnbindReportViewer := "user_id=SuperAdminID&command=/roles&channel_id=chanid&channel_name=chanName&text=unbind <@viewerID|viewer> report_viewer <#chanid|chanName>"
 
 	c, err := config.Get()
 	c.ManagerSlackUserID = "SuperAdminID"
@@ -424,6 +452,13 @@ func TestHandleReportByProjectCommands(t *testing.T) {
 		StandupTime: int64(0),
 	})
 
+	otherChannel, err := rest.db.CreateChannel(model.Channel{
+		ChannelName: "otherChanName",
+		ChannelID:   "otherchanid",
+		StandupTime: int64(0),
+	})
+	assert.NoError(t, err)
+
 	admin, err := rest.db.CreateUser(model.User{
 		UserName: "Admin",
 		UserID:   "SuperAdminID",
@@ -431,6 +466,12 @@ func TestHandleReportByProjectCommands(t *testing.T) {
 	})
 	assert.NoError(t, err)
 
+	viewer, err := rest.db.CreateUser(model.User{
+		UserName: "viewer",
+		UserID:   "viewerID",
+	})
+	assert.NoError(t, err)
+
 	testCases := []struct {
 		title        string
 		command      string
@@ -439,6 +480,11 @@ func TestHandleReportByProjectCommands(t *testing.T) {
 	}{
 		{"empty text", ReportByProjectEmptyText, http.StatusOK, "Wrong number of arguments"},
 		{"correct", ReportByProject, http.StatusOK, "Full Report on project #chanName from 2018-06-25 to 2018-06-26:\n\nNo standup data for this period\n"},
+		{"viewer without role", ReportByProjectViewer, http.StatusOK, "Access Denied! You need `reports.view` permission to use this command. Run `/roles` to see roles which have it"},
+		{"bind report viewer", BindReportViewer, http.StatusOK, "<@viewerID> is now report_viewer in <#chanid>"},
+		{"report viewer", ReportByProjectViewer, http.StatusOK, "Full Report on project #chanName from 2018-06-25 to 2018-06-26:\n\nNo standup data for this period\n"},
+		{"report viewer in other channel", ReportByProjectOtherViewer, http.StatusOK, "Access Denied! You need `reports.view` permission to use this command. Run `/roles` to see roles which have it"},
+		{"unbind report viewer", UnbindReportViewer, http.StatusOK, "<@viewerID> is no longer report_viewer in <#chanid>"},
 	}
 
 	for _, tt := range testCases {
@@ -452,7 +498,9 @@ func TestHandleReportByProjectCommands(t *testing.T) {
 	}
 
 	assert.NoError(t, rest.db.DeleteChannel(channel.ID))
+	assert.NoError(t, rest.db.DeleteChannel(otherChannel.ID))
 	assert.NoError(t, rest.db.DeleteUser(admin.ID))
+	assert.NoError(t, rest.db.DeleteUser(viewer.ID))
 
 }
 
@@ -629,23 +677,75 @@ func TestHandleHelpCommand(t *testing.T) {
 		StandupTime: int64(0),
 	})
 
-	command := "user_id=SuperAdminID&command=/helper&channel_id=TestChannelID&channel_name=TestChannel&text="
+	testCases := []struct {
+		command  string
+		text     string
+		response string
+	}{
+		{"/helper", "", c.Translate.HelpUsage},
+		{"/comedian", "", c.Translate.HelpUsage},
+		{"/comedian", "help", c.Translate.HelpUsage},
+		{"/comedian", "help add", fmt.Sprintf(c.Translate.HelpDetails, "add @user1 @user2 | @usergroup | all [/ developer | pm | admin]", c.Translate.HelpAdd)},
+		{"/comedian", "help удалить", fmt.Sprintf(c.Translate.HelpAliases, "/delete, удалить")},
+		{"/comedian", "help thread_mode", fmt.Sprintf(c.Translate.HelpDetails, "thread_mode [on | off]", c.Translate.HelpThreadMode)},
+		{"/comedian", "help unknown", fmt.Sprintf(c.Translate.UnknownCommand, "unknown")},
+		{"/comedian", "unknown", fmt.Sprintf(c.Translate.UnknownCommand, "unknown")},
+	}
 
-	body := "Hello! Bellow you can see the list of commands and how to use them:\n`/add` /add @user1 @user2 / role ('admin'|'pm'|'developer'|''). You can add users with no role as well\n`/list` /list role ('admin'|'pm'|'developer'|'') lists users with the selected role\n`/delete` /delete @user1 @user2 / role ('admin'|'pm'|'developer'|'') unassigns users with selected roles\n"
-	context, rec := getContext(command)
-	err = rest.handleCommands(context)
-	if err != nil {
-		logrus.Errorf("handleCommands failed. Error: %v\n", err)
+	for _, tt := range testCases {
+		command := fmt.Sprintf("user_id=SuperAdminID&command=%s&channel_id=TestChannelID&channel_name=TestChannel&text=%s", tt.command, tt.text)
+		context, rec := getContext(command)
+		err = rest.handleCommands(context)
+		if err != nil {
+			logrus.Errorf("handleCommands failed. Error: %v\n", err)
+		}
+		assert.Equal(t, 200, rec.Code)
+		assert.Contains(t, rec.Body.String(), tt.response)
 	}
-	assert.Equal(t, 200, rec.Code)
-	assert.Equal(t, body, rec.Body.String())
 
 	assert.NoError(t, rest.db.DeleteChannel(channel.ID))
 	assert.NoError(t, rest.db.DeleteUser(admin.ID))
 
 }
 
-func TestUserHasAccess(t *testing.T) {
+func TestBlockersCommand(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.ManagerSlackUserID = "SuperAdminID"
+	slack, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(slack)
+	assert.NoError(t, err)
+
+	channel, err := rest.db.CreateChannel(model.Channel{ChannelName: "TestChannel", ChannelID: "TestChannelID"})
+	assert.NoError(t, err)
+	otherChannel, err := rest.db.CreateChannel(model.Channel{ChannelName: "OtherChannel", ChannelID: "OtherChannelID"})
+	assert.NoError(t, err)
+	blocker, err := rest.db.CreateBlocker(model.Blocker{ChannelID: "OtherChannelID", UserID: "userID", StandupID: 1, Text: "staging is down"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		text     string
+		response string
+	}{
+		{"", "There are no open blockers in <#TestChannelID>"},
+		{"<#OtherChannelID|OtherChannel>", fmt.Sprintf("Open blockers in <#OtherChannelID>:\n%v. <@userID> (%v): staging is down\n", blocker.ID, time.Now().UTC().Format("2006-01-02"))},
+		{"#OtherChannel", fmt.Sprintf("Open blockers in <#OtherChannelID>:\n%v. <@userID> (%v): staging is down\n", blocker.ID, time.Now().UTC().Format("2006-01-02"))},
+		{"#UnknownChannel", "I do not know channel #UnknownChannel"},
+		{"OtherChannel", "Please, use `/blockers [#channel]` to list open blockers or `/blockers resolve <id>` to resolve one"},
+	}
+	for _, tt := range testCases {
+		context, response := getContext(fmt.Sprintf("user_id=userID&channel_id=TestChannelID&channel_name=TestChannel&command=/blockers&text=%s", tt.text))
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, tt.response, response.Body.String(), tt.text)
+	}
+
+	assert.NoError(t, rest.db.DeleteBlocker(blocker.ID))
+	assert.NoError(t, rest.db.DeleteChannel(channel.ID))
+	assert.NoError(t, rest.db.DeleteChannel(otherChannel.ID))
+}
+
+func TestUserRoles(t *testing.T) {
 	c, err := config.Get()
 	c.ManagerSlackUserID = "SUPERADMINID"
 	assert.NoError(t, err)
@@ -654,9 +754,7 @@ func TestUserHasAccess(t *testing.T) {
 	r, err := NewRESTAPI(slack)
 	assert.NoError(t, err)
 
-	accessLevel, err := r.getAccessLevel("RANDOMID", "RANDOMCHAN")
-	assert.Error(t, err)
-	assert.Equal(t, 0, accessLevel)
+	assert.Equal(t, []string{model.RoleViewer}, r.userRoles("RANDOMID", "RANDOMCHAN"))
 
 	superAdmin, err := r.db.CreateUser(model.User{
 		UserID:   "SUPERADMINID",
@@ -664,10 +762,7 @@ func TestUserHasAccess(t *testing.T) {
 		Role:     "",
 	})
 	assert.NoError(t, err)
-
-	accessLevel, err = r.getAccessLevel(superAdmin.UserID, "RANDOMCHAN")
-	assert.NoError(t, err)
-	assert.Equal(t, 1, accessLevel)
+	assert.Equal(t, []string{model.RoleViewer, model.RoleAdmin}, r.userRoles(superAdmin.UserID, "RANDOMCHAN"))
 
 	admin, err := r.db.CreateUser(model.User{
 		UserID:   "ADMINID",
@@ -675,10 +770,7 @@ func TestUserHasAccess(t *testing.T) {
 		Role:     "admin",
 	})
 	assert.NoError(t, err)
-
-	accessLevel, err = r.getAccessLevel(admin.UserID, "RANDOMCHAN")
-	assert.NoError(t, err)
-	assert.Equal(t, 2, accessLevel)
+	assert.Equal(t, []string{model.RoleViewer, model.RoleAdmin}, r.userRoles(admin.UserID, "RANDOMCHAN"))
 
 	pmUser, err := r.db.CreateUser(model.User{
 		UserID:   "PMID",
@@ -693,27 +785,601 @@ func TestUserHasAccess(t *testing.T) {
 		RoleInChannel: "pm",
 	})
 	assert.NoError(t, err)
-
-	accessLevel, err = r.getAccessLevel(pm.UserID, "RANDOMCHAN")
-	assert.NoError(t, err)
-	assert.Equal(t, 3, accessLevel)
+	assert.Equal(t, []string{model.RoleViewer, model.RolePM}, r.userRoles(pm.UserID, "RANDOMCHAN"))
+	assert.Equal(t, []string{model.RoleViewer}, r.userRoles(pm.UserID, "OTHERCHAN"))
 
 	user, err := r.db.CreateUser(model.User{
 		UserID:   "USERID",
 		UserName: "User",
 		Role:     "",
 	})
-
-	accessLevel, err = r.getAccessLevel(user.UserID, "RANDOMCHAN")
 	assert.NoError(t, err)
-	assert.Equal(t, 4, accessLevel)
+	assert.Equal(t, []string{model.RoleViewer}, r.userRoles(user.UserID, "RANDOMCHAN"))
 
 	assert.NoError(t, r.db.DeleteUser(admin.ID))
 	assert.NoError(t, r.db.DeleteUser(pmUser.ID))
 	assert.NoError(t, r.db.DeleteUser(user.ID))
 	assert.NoError(t, r.db.DeleteUser(superAdmin.ID))
 	assert.NoError(t, r.db.DeleteChannelMember(pmUser.UserID, "RANDOMCHAN"))
+}
+
+func TestCan(t *testing.T) {
+	c, err := config.Get()
+	c.ManagerSlackUserID = "SUPERADMINID"
+	assert.NoError(t, err)
+	slack, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	r, err := NewRESTAPI(slack)
+	assert.NoError(t, err)
+
+	admin, err := r.db.CreateUser(model.User{UserID: "ADMINID", UserName: "Admin", Role: "admin"})
+	assert.NoError(t, err)
+	pmUser, err := r.db.CreateUser(model.User{UserID: "PMID", UserName: "futurePM"})
+	assert.NoError(t, err)
+	_, err = r.db.CreateChannelMember(model.ChannelMember{UserID: pmUser.UserID, ChannelID: "RANDOMCHAN", RoleInChannel: "pm"})
+	assert.NoError(t, err)
+	user, err := r.db.CreateUser(model.User{UserID: "USERID", UserName: "User"})
+	assert.NoError(t, err)
+	role, err := r.db.CreateRole(model.Role{Name: "auditor", Permissions: model.PermReportsView})
+	assert.NoError(t, err)
+	_, err = r.db.CreateRoleBinding(model.RoleBinding{UserID: user.UserID, Role: role.Name, ChannelID: "RANDOMCHAN"})
+	assert.NoError(t, err)
+	viewer, err := r.db.CreateUser(model.User{UserID: "VIEWERID", UserName: "Viewer"})
+	assert.NoError(t, err)
+	_, err = r.db.CreateRoleBinding(model.RoleBinding{UserID: viewer.UserID, Role: "report_viewer"})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		userID     string
+		channelID  string
+		permission string
+		can        bool
+	}{
+		{"ADMINID", "RANDOMCHAN", model.PermWorkspaceManage, true},
+		{"ADMINID", "", model.PermRolesManage, true},
+		{"PMID", "RANDOMCHAN", model.PermScheduleManage, true},
+		{"PMID", "RANDOMCHAN", model.PermRolesManage, false},
+		{"PMID", "OTHERCHAN", model.PermScheduleManage, false},
+		{"USERID", "RANDOMCHAN", model.PermReportsView, true},
+		{"USERID", "OTHERCHAN", model.PermReportsView, false},
+		{"USERID", "RANDOMCHAN", model.PermMembersView, true},
+		{"USERID", "RANDOMCHAN", model.PermMembersManage, false},
+		{"UNKNOWNID", "RANDOMCHAN", model.PermMembersView, true},
+		{"UNKNOWNID", "RANDOMCHAN", model.PermScheduleManage, false},
+		{"VIEWERID", "RANDOMCHAN", model.PermReportsView, true},
+		{"VIEWERID", "OTHERCHAN", model.PermReportsView, true},
+		{"VIEWERID", "", model.PermReportsView, true},
+		{"VIEWERID", "RANDOMCHAN", model.PermScheduleManage, false},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.can, r.can(tt.userID, tt.channelID, tt.permission), fmt.Sprintf("%v %v in %v", tt.userID, tt.permission, tt.channelID))
+	}
+
+	assert.NoError(t, r.db.DeleteRole(role.Name))
+	assert.NoError(t, r.db.DeleteUser(admin.ID))
+	assert.NoError(t, r.db.DeleteUser(pmUser.ID))
+	assert.NoError(t, r.db.DeleteUser(user.ID))
+	assert.NoError(t, r.db.DeleteUser(viewer.ID))
+	assert.NoError(t, r.db.DeleteRoleBinding(viewer.UserID, "report_viewer", ""))
+	assert.NoError(t, r.db.DeleteChannelMember(pmUser.UserID, "RANDOMCHAN"))
+}
+
+func TestCommandPermissions(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	rest := &REST{conf: c}
+	rest.registerCommands()
+	for _, cmd := range rest.commands {
+		if cmd.Name == "help" {
+			assert.Equal(t, "", cmd.Access.Permission)
+			continue
+		}
+		assert.Equal(t, true, model.KnownPermission(cmd.Access.Permission), cmd.Name)
+		for key, a := range cmd.ArgAccess {
+			assert.Equal(t, true, a.Permission == "" || model.KnownPermission(a.Permission), cmd.Name+" "+key)
+		}
+	}
+	for name, permissions := range model.BuiltinRoles {
+		for _, permission := range permissions {
+			assert.Equal(t, true, model.KnownPermission(permission), name)
+		}
+	}
+}
+
+func TestCommandAccess(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	rest := &REST{conf: c}
+	rest.registerCommands()
+
+	testCases := []struct {
+		command     string
+		text        string
+		permissions []string
+	}{
+		{"add", "<@userID1|user>", []string{model.PermMembersManage}},
+		{"add", "<@userID1|user> / pm", []string{model.PermRolesManage}},
+		{"add", "<@userID1|user> /admin", []string{model.PermRolesManage}},
+		{"delete", "<@userID1|user> / pm", []string{model.PermMembersManage}},
+		{"thread_mode", "", []string{model.PermScheduleView}},
+		{"thread_mode", "on", []string{model.PermScheduleManage}},
+		{"language_set", "", []string{""}},
+		{"language_set", "ru me", []string{""}},
+		{"language_set", "ru", []string{model.PermScheduleManage}},
+		{"channel_settings", "show", []string{model.PermScheduleView}},
+		{"channel_settings", "set report_time 10:00", []string{model.PermScheduleManage}},
+		{"roles", "bind <@userID1|user> remove", []string{model.PermRolesManage, model.PermRolesManage}},
+		{"digest_set", "weekly all at 10:00", []string{model.PermWorkspaceManage}},
+	}
+	for _, tt := range testCases {
+		cmd, ok := rest.findCommand(tt.command)
+		assert.Equal(t, true, ok, tt.command)
+		permissions := []string{}
+		for _, a := range cmd.accessFor(strings.Fields(tt.text)) {
+			permissions = append(permissions, a.Permission)
+		}
+		assert.Equal(t, tt.permissions, permissions, tt.command+" "+tt.text)
+	}
+
+	for _, name := range []string{"api_token", "webhook_set", "webhook_list", "delivery_status"} {
+		cmd, _ := rest.findCommand(name)
+		channelID, err := rest.accessChannel(cmd.Access, "CHANID", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "", channelID, name)
+	}
+
+	roles, _ := rest.findCommand("roles")
+	channelID, err := rest.accessChannel(roles.ArgAccess["bind"], "CHANID", []string{"bind", "<@userID1|user>", "pm"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", channelID)
+	channelID, err = rest.accessChannel(roles.ArgAccess["bind"], "CHANID", []string{"bind", "<@userID1|user>", "pm", "<#OTHERID|other>"})
+	assert.NoError(t, err)
+	assert.Equal(t, "OTHERID", channelID)
+	channelID, err = rest.accessChannel(roles.Access, "CHANID", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "CHANID", channelID)
+}
+
+func TestWorkspaceCommandsAccess(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	slack, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(slack)
+	assert.NoError(t, err)
+
+	channel, err := rest.db.CreateChannel(model.Channel{ChannelName: "adminchan", ChannelID: "ADMINCHAN"})
+	assert.NoError(t, err)
+	user, err := rest.db.CreateUser(model.User{UserID: "CHANADMINID", UserName: "chanadmin"})
+	assert.NoError(t, err)
+	// admin of one channel only, it does not give workspace permissions
+	_, err = rest.db.CreateRoleBinding(model.RoleBinding{UserID: user.UserID, Role: model.RoleAdmin, ChannelID: channel.ChannelID})
+	assert.NoError(t, err)
+
+	denied := fmt.Sprintf(c.Translate.AccessDenied, model.PermWorkspaceManage)
+	testCases := []struct {
+		command string
+		text    string
+	}{
+		{"api_token", "create bot admin"},
+		{"api_token", "list"},
+		{"webhook_set", "https://example.com/hook all"},
+		{"webhook_list", ""},
+		{"delivery_status", ""},
+	}
+	assert.Equal(t, true, rest.can(user.UserID, channel.ChannelID, model.PermWorkspaceManage))
+	for _, tt := range testCases {
+		context, response := getContext(fmt.Sprintf("user_id=CHANADMINID&channel_id=ADMINCHAN&channel_name=adminchan&command=/%s&text=%s", tt.command, tt.text))
+		assert.NoError(t, rest.handleCommands(context))
+		assert.Equal(t, denied, response.Body.String(), tt.command)
+	}
+
+	assert.NoError(t, rest.db.DeleteRoleBinding(user.UserID, model.RoleAdmin, channel.ChannelID))
+	assert.NoError(t, rest.db.DeleteUser(user.ID))
+	assert.NoError(t, rest.db.DeleteChannel(channel.ID))
+}
+
+func TestRunCommand(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	slack, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	rest, err := NewRESTAPI(slack)
+	assert.NoError(t, err)
+	assert.Equal(t, rest, slack.Commands)
+
+	assert.Equal(t, true, rest.IsCommand("/add"))
+	assert.Equal(t, true, rest.IsCommand("/helper"))
+	assert.Equal(t, true, rest.IsCommand("/comedian"))
+	assert.Equal(t, true, rest.IsCommand("/Report_By_Project"))
+	assert.Equal(t, false, rest.IsCommand("/unknown"))
+
+	text := rest.RunCommand(url.Values{
+		"command":      {"/helper"},
+		"channel_id":   {"TestChannelID"},
+		"channel_name": {"TestChannel"},
+		"user_id":      {"UserID"},
+	})
+	assert.Contains(t, text, c.Translate.HelpUsage)
+	assert.Contains(t, text, c.Translate.HelpFooter)
+
+	text = rest.RunCommand(url.Values{
+		"command":      {"/list"},
+		"channel_id":   {"DIRECTID"},
+		"channel_name": {"directmessage"},
+		"user_id":      {"UserID"},
+	})
+	assert.Equal(t, c.Translate.CommandNeedsChannel, text)
+}
+
+func TestReportFormat(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	rest := &REST{conf: c}
+
+	params, format, err := rest.reportFormat([]string{"#chan", "2018-06-01", "format=CSV", "2018-06-05"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#chan", "2018-06-01", "2018-06-05"}, params)
+	assert.Equal(t, "csv", format)
+
+	_, format, err = rest.reportFormat([]string{"#chan", "format=md"})
+	assert.NoError(t, err)
+	assert.Equal(t, "markdown", format)
+
+	params, format, err = rest.reportFormat([]string{"@user", "2018-06-01", "2018-06-05"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(params))
+	assert.Equal(t, "", format)
+
+	_, _, err = rest.reportFormat([]string{"#chan", "format=xls"})
+	assert.Error(t, err)
+}
+
+func TestParseRecipient(t *testing.T) {
+	rest := &REST{}
+	id, err := rest.parseRecipient("<#CHANID|general>")
+	assert.NoError(t, err)
+	assert.Equal(t, "CHANID", id)
+	id, err = rest.parseRecipient("<@USERID|user>")
+	assert.NoError(t, err)
+	assert.Equal(t, "USERID", id)
+	id, err = rest.parseRecipient("<@USERID>")
+	assert.NoError(t, err)
+	assert.Equal(t, "USERID", id)
+	_, err = rest.parseRecipient("somewhere")
+	assert.Error(t, err)
+}
+
+func TestStatsPeriod(t *testing.T) {
+	now := time.Date(2018, 6, 30, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		text     string
+		dateFrom time.Time
+		dateTo   time.Time
+	}{
+		{"", time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC), now},
+		{"week", time.Date(2018, 6, 24, 12, 0, 0, 0, time.UTC), now},
+		{"14d", time.Date(2018, 6, 17, 12, 0, 0, 0, time.UTC), now},
+		{"2018-05-01 2018-05-31", time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 5, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range testCases {
+		dateFrom, dateTo, err := statsPeriod(tt.text, now)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.dateFrom, dateFrom)
+		assert.Equal(t, tt.dateTo, dateTo)
+	}
+	for _, text := range []string{"fortnight", "0", "2018-05-01 wrong", "a b c"} {
+		_, _, err := statsPeriod(text, now)
+		assert.Error(t, err)
+	}
+}
+
+func TestRespondAsync(t *testing.T) {
+	server := fakeslack.NewServer("UBOT", "comedian")
+	defer server.Close()
+	slackAPI := slack.SLACK_API
+	slack.SLACK_API = server.APIURL()
+	defer func() { slack.SLACK_API = slackAPI }()
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.Translate.ReportInProgress = "in progress"
+	rest := &REST{conf: c, slack: &chat.Slack{API: slack.New("xoxb-fake"), Conf: c}}
+
+	context, rec := getContext("user_id=UDEV&response_url=" + url.QueryEscape(server.ResponseURL()))
+	form, err := context.FormParams()
+	assert.NoError(t, err)
+	assert.NoError(t, rest.respondAsync(context, form, func() string { return "report" }))
+	assert.Equal(t, "in progress", rec.Body.String())
+	m, ok := server.WaitForMessage(time.Second, func(m fakeslack.Message) bool { return m.Method == "response_url" })
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "report", m.Text)
+
+	context, rec = getContext("user_id=UDEV")
+	form, err = context.FormParams()
+	assert.NoError(t, err)
+	assert.NoError(t, rest.respondAsync(context, form, func() string { return "report" }))
+	assert.Equal(t, "report", rec.Body.String())
+}
+
+func TestLocalizedCache(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	rest := &REST{conf: c, slack: &chat.Slack{Conf: c}, localizedCache: &localizedCache{}}
+
+	lr := rest.localized("CHANNEL", "USER")
+	assert.NotEmpty(t, lr.commands)
+	assert.True(t, lr == rest.localized("OTHERCHANNEL", "OTHERUSER"))
+
+	// commands are built again with the reloaded config
+	assert.NoError(t, rest.slack.ReloadConfig())
+	reloaded := rest.localized("CHANNEL", "USER")
+	assert.False(t, lr == reloaded)
+	assert.True(t, reloaded == rest.localized("CHANNEL", "USER"))
+}
+
+func TestDashboardLink(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.DashboardURL = "https://comedian.example.com/"
+	c.DashboardSecret = "secret"
+	c.Translate.DashboardLinkExpired = "expired"
+	rest := &REST{conf: c}
+	now := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	link, err := url.Parse(rest.dashboardLink("CHANID", now))
+	assert.NoError(t, err)
+	assert.Equal(t, "comedian.example.com", link.Host)
+	assert.Equal(t, "/dashboard", link.Path)
+	query := link.Query()
+	assert.Equal(t, "CHANID", query.Get("channel"))
+	assert.Equal(t, true, rest.validDashboardLink("CHANID", query.Get("expires"), query.Get("sig"), now))
+	assert.Equal(t, true, rest.validDashboardLink("CHANID", query.Get("expires"), query.Get("sig"), now.Add(59*time.Minute)))
+	assert.Equal(t, false, rest.validDashboardLink("CHANID", query.Get("expires"), query.Get("sig"), now.Add(61*time.Minute)))
+	assert.Equal(t, false, rest.validDashboardLink("", query.Get("expires"), query.Get("sig"), now))
+	assert.Equal(t, false, rest.validDashboardLink("CHANID", "9999999999", query.Get("sig"), now))
+
+	rest.conf.DashboardSecret = "another secret"
+	assert.Equal(t, false, rest.validDashboardLink("CHANID", query.Get("expires"), query.Get("sig"), now))
+
+	rest.conf.DashboardSecret = ""
+	assert.Equal(t, false, rest.dashboardEnabled())
+	assert.Equal(t, false, rest.validDashboardLink("CHANID", query.Get("expires"), rest.dashboardSignature("CHANID", 9999999999), now))
+
+	e := echo.New()
+	req := httptest.NewRequest(echo.GET, "/dashboard?channel=CHANID&expires=1&sig=abc", nil)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, rest.dashboard(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "expired", rec.Body.String())
+}
+
+func TestBuildDashboardChannel(t *testing.T) {
+	now := time.Date(2018, 6, 3, 12, 0, 0, 0, time.UTC)
+	day1 := time.Date(2018, 6, 2, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2018, 6, 3, 0, 0, 0, 0, time.UTC)
+	deadline := day1.Add(10 * time.Hour)
+	entries := []reporting.ReportEntry{
+		{Date: day1, UserID: "U1", UserName: "foo", Status: reporting.StatusSubmitted, Submitted: deadline.Add(-time.Hour), Deadline: deadline},
+		{Date: day1, UserID: "U2", UserName: "bar", Status: reporting.StatusSubmitted, Submitted: deadline.Add(time.Hour), Deadline: deadline},
+		{Date: day2, UserID: "U1", UserName: "foo", Status: reporting.StatusSubmitted},
+		{Date: day2, UserID: "U2", UserName: "bar", Status: reporting.StatusMissed},
+	}
+	blockers := []model.Blocker{{ID: 1, UserID: "U2", Text: "waiting for review"}}
+
+	dc := buildDashboardChannel(model.Channel{ChannelName: "chan"}, entries, []string{"bar", "baz"}, blockers, day1, now)
+	assert.Equal(t, "chan", dc.Name)
+	assert.Equal(t, 2, len(dc.Rows))
+	assert.Equal(t, "foo", dc.Rows[0].Member)
+	assert.Equal(t, []string{colorOnTime, colorOnTime}, []string{dc.Rows[0].Cells[0].Color, dc.Rows[0].Cells[1].Color})
+	assert.Equal(t, []string{colorLate, colorMissed}, []string{dc.Rows[1].Cells[0].Color, dc.Rows[1].Cells[1].Color})
+	assert.Equal(t, []string{"bar", "baz"}, dc.NonReporters)
+	assert.Equal(t, "7,0 23,30", dc.Trend)
+
+	var buf strings.Builder
+	assert.NoError(t, dashboardTemplate.Execute(&buf, dashboardPage{Channels: []dashboardChannel{dc}}))
+	assert.Contains(t, buf.String(), "<svg")
+	assert.Contains(t, buf.String(), "waiting for review")
+}
+
+func TestAPIPagination(t *testing.T) {
+	e := echo.New()
+	context := e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/channels?page=2&per_page=10", nil), httptest.NewRecorder())
+	page, perPage, err := apiPagination(context)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+	assert.Equal(t, 10, perPage)
+
+	context = e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/channels", nil), httptest.NewRecorder())
+	page, perPage, err = apiPagination(context)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page)
+	assert.Equal(t, apiDefaultPerPage, perPage)
+
+	for _, query := range []string{"page=0", "page=x", "per_page=0", "per_page=1000"} {
+		context = e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/channels?"+query, nil), httptest.NewRecorder())
+		_, _, err = apiPagination(context)
+		assert.Error(t, err, query)
+	}
+
+	from, to := pageBounds(25, 1, 10)
+	assert.Equal(t, []int{0, 10}, []int{from, to})
+	from, to = pageBounds(25, 3, 10)
+	assert.Equal(t, []int{20, 25}, []int{from, to})
+	from, to = pageBounds(25, 4, 10)
+	assert.Equal(t, []int{25, 25}, []int{from, to})
+	from, to = pageBounds(25, math.MaxInt64, 100)
+	assert.Equal(t, []int{25, 25}, []int{from, to})
+
+	context = e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/channels?page=9223372036854775807&per_page=100", nil), httptest.NewRecorder())
+	page, perPage, err = apiPagination(context)
+	assert.NoError(t, err)
+	from, to = pageBounds(3, page, perPage)
+	assert.Equal(t, []int{3, 3}, []int{from, to})
+}
+
+func TestAPIPeriod(t *testing.T) {
+	e := echo.New()
+	now := time.Date(2018, 6, 10, 15, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		query string
+		from  string
+		to    string
+		err   bool
+	}{
+		{"", "2018-06-04", "2018-06-10", false},
+		{"to=2018-06-05", "2018-05-30", "2018-06-05", false},
+		{"from=2018-06-01&to=2018-06-05", "2018-06-01", "2018-06-05", false},
+		{"from=2018-06-06&to=2018-06-05", "", "", true},
+		{"from=2016-06-01&to=2018-06-05", "", "", true},
+		{"from=yesterday", "", "", true},
+	}
+	for _, tt := range testCases {
+		context := e.NewContext(httptest.NewRequest(echo.GET, "/api/v1/channels/CHANID/report?"+tt.query, nil), httptest.NewRecorder())
+		from, to, err := apiPeriod(context, now)
+		if tt.err {
+			assert.Error(t, err, tt.query)
+			continue
+		}
+		assert.NoError(t, err, tt.query)
+		assert.Equal(t, tt.from, from.Format("2006-01-02"), tt.query)
+		assert.Equal(t, tt.to, to.Format("2006-01-02"), tt.query)
+	}
+}
+
+func TestAPIRoutes(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	rest := &REST{conf: c, echo: echo.New()}
+	rest.initAPIv1()
+
+	req := httptest.NewRequest(echo.GET, "/api/v1/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var doc struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	for _, route := range rest.echo.Routes() {
+		if route.Method != echo.GET || route.Path == "/api/v1/openapi.json" || strings.HasSuffix(route.Path, "*") || route.Path == "/api/v1" {
+			continue
+		}
+		path := strings.Replace(strings.TrimPrefix(route.Path, "/api/v1"), ":channel", "{channel}", 1)
+		assert.Contains(t, doc.Paths, path)
+	}
+
+	req = httptest.NewRequest(echo.GET, "/api/v1/channels", nil)
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing bearer token")
+}
+
+func TestAPIToken(t *testing.T) {
+	token, err := randomToken()
+	assert.NoError(t, err)
+	assert.Equal(t, 48, len(token))
+	assert.NotEqual(t, token, hashAPIToken(token))
+	assert.Equal(t, hashAPIToken(token), hashAPIToken(token))
+
+	viewer := model.APIToken{Role: model.APIRoleViewer}
+	assert.Equal(t, false, viewer.CanReadStandups())
+	assert.Equal(t, true, viewer.CanAccessChannel("CHANID"))
+	pm := model.APIToken{Role: model.APIRolePM, ChannelID: "CHANID"}
+	assert.Equal(t, true, pm.CanReadStandups())
+	assert.Equal(t, true, pm.CanAccessChannel("CHANID"))
+	assert.Equal(t, false, pm.CanAccessChannel("OTHERID"))
+}
+
+func TestAddStandupSection(t *testing.T) {
+	yesterday := model.StandupSection{Name: "yesterday", Keywords: []string{"yesterday"}}
+	today := model.StandupSection{Name: "today", Keywords: []string{"today"}}
+	defaults := []model.StandupSection{yesterday, today}
+	demo := model.StandupSection{Name: "demo", Keywords: []string{"demo"}}
+
+	// the first custom section does not extend built-in sections
+	sections := addStandupSection(model.StandupRules{ChannelID: "CHANID"}, defaults, demo)
+	assert.Equal(t, []model.StandupSection{demo}, sections)
+
+	rules := model.StandupRules{ID: 1, ChannelID: "CHANID"}
+	sections = addStandupSection(rules, sections, today)
+	assert.Equal(t, []model.StandupSection{demo, today}, sections)
+
+	demo.Pattern = "(?i)demo:"
+	demo.Keywords = nil
+	sections = addStandupSection(rules, sections, demo)
+	assert.Equal(t, []model.StandupSection{demo, today}, sections)
+}
+
+func TestParseLink(t *testing.T) {
+	assert.Equal(t, "https://example.com/hook", parseLink("<https://example.com/hook>"))
+	assert.Equal(t, "https://example.com/hook", parseLink("<https://example.com/hook|example.com/hook>"))
+	assert.Equal(t, "https://example.com/hook", parseLink("https://example.com/hook"))
+}
+
+func TestMetrics(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := storage.NewMySQL(c)
+	assert.NoError(t, err)
+	rest := &REST{conf: c, db: db, echo: echo.New()}
+	rest.echo.GET("/metrics", rest.metrics)
+	commandsTotal.Inc("help", "ok")
+	rest.updateNonReporters()
+
+	req := httptest.NewRequest(echo.GET, "/metrics", nil)
+	rec := httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/plain; version=0.0.4")
+	assert.Contains(t, rec.Body.String(), "# TYPE comedian_commands_total counter")
+	assert.Contains(t, rec.Body.String(), `comedian_commands_total{command="help",result="ok"}`)
+	assert.Contains(t, rec.Body.String(), "# TYPE comedian_non_reporters gauge")
+
+	rest.conf.MetricsToken = "scrape"
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, httptest.NewRequest(echo.GET, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(echo.GET, "/metrics", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer scrape")
+	rec = httptest.NewRecorder()
+	rest.echo.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHealth(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.DatabaseURL = "comedian:comedian@tcp(127.0.0.1:1)/comedian"
+	s, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	rest := &REST{conf: c, db: s.DB, slack: s, echo: echo.New()}
+	rest.echo.GET("/healthz", rest.healthz)
+	rest.echo.GET("/readyz", rest.readyz)
+
+	get := func(path string) (int, healthResponse) {
+		req := httptest.NewRequest(echo.GET, path, nil)
+		rec := httptest.NewRecorder()
+		rest.echo.ServeHTTP(rec, req)
+		var res healthResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return rec.Code, res
+	}
 
+	s.Heartbeat("scheduler")
+	code, res := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, healthOK, res.Status)
+	assert.Equal(t, chat.RTMConnecting, res.Checks["rtm"].Detail)
+
+	code, res = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, healthDegraded, res.Status)
+	assert.Equal(t, healthDegraded, res.Checks["database"].Status)
+	assert.Equal(t, healthDegraded, res.Checks["rtm"].Status)
+	assert.Equal(t, healthOK, res.Checks["scheduler"].Status)
 }
 
 func getContext(command string) (echo.Context, *httptest.ResponseRecorder) {
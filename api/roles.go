@@ -0,0 +1,232 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// can reports whether user has the permission in the channel, empty channel means all channels.
+// Every access check goes through it
+func (r *REST) can(userID, channelID, permission string) bool {
+	for _, role := range r.userRoles(userID, channelID) {
+		for _, p := range r.rolePermissions(role) {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// userRoles returns roles user has in the channel: everyone is a viewer, admins and PMs are given by /add,
+// other roles are bound with /roles
+func (r *REST) userRoles(userID, channelID string) []string {
+	roles := []string{model.RoleViewer}
+	user, err := r.db.SelectUser(userID)
+	if err == nil {
+		// super admin of the config is an admin whatever role is stored
+		if userID == r.conf.ManagerSlackUserID {
+			user.Role = model.RoleAdmin
+		}
+		roleInChannel := ""
+		if member, err := r.db.FindChannelMemberByUserID(userID, channelID); err == nil {
+			roleInChannel = member.RoleInChannel
+		}
+		roles = user.Roles(roleInChannel)
+	}
+	bindings, err := r.db.ListUserRoleBindings(userID)
+	if err != nil {
+		logrus.Errorf("rest: ListUserRoleBindings failed: %v\n", err)
+		return roles
+	}
+	for _, binding := range bindings {
+		if binding.AppliesTo(channelID) {
+			roles = append(roles, binding.Role)
+		}
+	}
+	return roles
+}
+
+// rolePermissions returns permissions of built in or custom role
+func (r *REST) rolePermissions(name string) []string {
+	if permissions, ok := model.BuiltinRoles[name]; ok {
+		return permissions
+	}
+	role, err := r.db.SelectRole(name)
+	if err != nil {
+		logrus.Errorf("rest: SelectRole %v failed: %v\n", name, err)
+		return nil
+	}
+	return role.PermissionsList()
+}
+
+func (r *REST) accessDenied(permission string) string {
+	return fmt.Sprintf(r.conf.Translate.AccessDenied, permission)
+}
+
+func (r *REST) roles(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	args := strings.Fields(ca.Text)
+	if len(args) < 2 {
+		if len(args) == 0 {
+			return c.String(http.StatusOK, r.listRoles())
+		}
+		member, err := r.parseRecipient(args[0])
+		if err != nil || !strings.HasPrefix(args[0], "<@") && !strings.HasPrefix(args[0], "@") {
+			return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+		}
+		return c.String(http.StatusOK, r.showUserRoles(member, ca.ChannelID))
+	}
+
+	switch args[0] {
+	case "bind", "unbind":
+		if len(args) < 3 || len(args) > 4 {
+			return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+		}
+		member, err := r.parseRecipient(args[1])
+		if err != nil || !strings.HasPrefix(args[1], "<@") && !strings.HasPrefix(args[1], "@") {
+			return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+		}
+		binding := model.RoleBinding{UserID: member, Role: args[2]}
+		if len(args) == 4 {
+			binding.ChannelID, err = r.parseRecipient(args[3])
+			if err != nil || !strings.HasPrefix(args[3], "<#") && !strings.HasPrefix(args[3], "#") {
+				return c.String(http.StatusOK, r.conf.Translate.WrongProjectName)
+			}
+		}
+		if args[0] == "unbind" {
+			err = r.db.DeleteRoleBinding(binding.UserID, binding.Role, binding.ChannelID)
+			if err != nil {
+				logrus.Errorf("rest: DeleteRoleBinding failed: %v\n", err)
+				return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+			}
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleUnbound, binding.UserID, binding.Role, r.bindingScope(binding)))
+		}
+		if !r.roleExists(binding.Role) {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleUnknown, binding.Role, strings.Join(r.roleNames(), ", ")))
+		}
+		_, err = r.db.CreateRoleBinding(binding)
+		if err != nil {
+			logrus.Errorf("rest: CreateRoleBinding failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleBound, binding.UserID, binding.Role, r.bindingScope(binding)))
+	case "define":
+		if len(args) < 3 {
+			return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+		}
+		role := model.Role{Name: args[1], Permissions: strings.Join(args[2:], ",")}
+		if err := role.Validate(); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongRole, err, strings.Join(model.Permissions, ", ")))
+		}
+		role.Permissions = strings.Join(role.PermissionsList(), ",")
+		if existing, err := r.db.SelectRole(role.Name); err == nil {
+			existing.Permissions = role.Permissions
+			_, err = r.db.UpdateRole(existing)
+			if err != nil {
+				logrus.Errorf("rest: UpdateRole failed: %v\n", err)
+				return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+			}
+		} else {
+			_, err = r.db.CreateRole(role)
+			if err != nil {
+				logrus.Errorf("rest: CreateRole failed: %v\n", err)
+				return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+			}
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleDefined, role.Name, strings.Join(role.PermissionsList(), ", ")))
+	case "remove":
+		if len(args) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+		}
+		if _, err := r.db.SelectRole(args[1]); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleUnknown, args[1], strings.Join(r.roleNames(), ", ")))
+		}
+		if err := r.db.DeleteRole(args[1]); err != nil {
+			logrus.Errorf("rest: DeleteRole failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.RoleRemoved, args[1]))
+	default:
+		return c.String(http.StatusOK, r.conf.Translate.RolesWrongFormat)
+	}
+}
+
+// listRoles describes roles with their permissions and role bindings
+func (r *REST) listRoles() string {
+	text := r.conf.Translate.RolesHead
+	for _, name := range r.roleNames() {
+		text += fmt.Sprintf(r.conf.Translate.RolesItem, name, strings.Join(r.rolePermissions(name), ", "))
+	}
+	bindings, err := r.db.ListRoleBindings()
+	if err != nil {
+		logrus.Errorf("rest: ListRoleBindings failed: %v\n", err)
+		return r.conf.Translate.SomethingWentWrong
+	}
+	if len(bindings) == 0 {
+		return text + r.conf.Translate.RoleBindingsEmpty
+	}
+	text += r.conf.Translate.RoleBindingsHead
+	for _, binding := range bindings {
+		text += fmt.Sprintf(r.conf.Translate.RoleBindingsItem, binding.UserID, binding.Role, r.bindingScope(binding))
+	}
+	return text
+}
+
+// showUserRoles describes roles user has in the channel and permissions they give
+func (r *REST) showUserRoles(userID, channelID string) string {
+	roles := r.userRoles(userID, channelID)
+	permissions := []string{}
+	for _, permission := range model.Permissions {
+		if r.can(userID, channelID, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return fmt.Sprintf(r.conf.Translate.UserRoles, userID, strings.Join(roles, ", "), strings.Join(permissions, ", "))
+}
+
+// roleNames returns names of built in and custom roles
+func (r *REST) roleNames() []string {
+	names := []string{}
+	for name := range model.BuiltinRoles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	roles, err := r.db.ListRoles()
+	if err != nil {
+		logrus.Errorf("rest: ListRoles failed: %v\n", err)
+		return names
+	}
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+func (r *REST) roleExists(name string) bool {
+	for _, n := range r.roleNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *REST) bindingScope(binding model.RoleBinding) string {
+	if binding.ChannelID == "" {
+		return r.conf.Translate.RoleAllChannels
+	}
+	return "<#" + binding.ChannelID + ">"
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/maddevsio/comedian/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (r *REST) channelSettings(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	args := strings.Fields(ca.Text)
+	if len(args) == 0 || (len(args) == 1 && args[0] == "show") {
+		return c.String(http.StatusOK, r.showChannelSettings(r.slack.ChannelSettings(ca.ChannelID)))
+	}
+	if args[0] != "set" || len(args) < 3 || !isChannelSetting(args[1]) {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ChannelSettingsWrongFormat, strings.Join(channelSettingNames(), ", ")))
+	}
+	name, values := args[1], args[2:]
+	if name == model.SettingLanguage {
+		return r.setChannelSettingsLanguage(c, ca.ChannelID, values)
+	}
+	if len(values) == 1 && values[0] == "default" {
+		err = r.db.DeleteChannelSetting(ca.ChannelID, name)
+		if err != nil {
+			logrus.Errorf("rest: DeleteChannelSetting failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		settings := r.slack.ChannelSettings(ca.ChannelID)
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ChannelSettingsReset, name, r.describeChannelSetting(settings, name)))
+	}
+
+	setting := model.ChannelSetting{ChannelID: ca.ChannelID, Name: name, Value: strings.Join(values, " ")}
+	switch name {
+	case model.SettingReportTime:
+		hour, min, err := utils.FormatTime(setting.Value)
+		if err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongChannelSetting, name, err))
+		}
+		setting.Value = fmt.Sprintf("%02d:%02d", hour, min)
+	case model.SettingReportRecipients:
+		recipients := []string{}
+		for _, value := range values {
+			recipient, err := r.parseRecipient(strings.Trim(value, ","))
+			if err != nil {
+				return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongDigestRecipient, value))
+			}
+			recipients = append(recipients, recipient)
+		}
+		setting.Value = strings.Join(recipients, ",")
+	case model.SettingValidation:
+		setting.Value = strings.ToLower(setting.Value)
+	}
+	if err := setting.Validate(); err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongChannelSetting, name, err))
+	}
+
+	_, err = r.db.SetChannelSetting(setting)
+	if err != nil {
+		logrus.Errorf("rest: SetChannelSetting failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	settings := r.slack.ChannelSettings(ca.ChannelID)
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ChannelSettingsUpdated, name, r.describeChannelSetting(settings, name)))
+}
+
+// setChannelSettingsLanguage sets language of the channel like /language_set does
+func (r *REST) setChannelSettingsLanguage(c echo.Context, channelID string, values []string) error {
+	if len(values) != 1 {
+		return c.String(http.StatusOK, r.conf.Translate.LanguageWrongFormat)
+	}
+	lang := ""
+	if values[0] != "default" {
+		l, ok := config.ParseLanguage(values[0])
+		if !ok {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongLanguage, values[0], strings.Join(config.Languages, ", ")))
+		}
+		lang = l
+	}
+	if err := r.setChannelLanguage(channelID, lang); err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	settings := r.slack.ChannelSettings(channelID)
+	if lang == "" {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ChannelSettingsReset, model.SettingLanguage, r.describeChannelSetting(settings, model.SettingLanguage)))
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.ChannelSettingsUpdated, model.SettingLanguage, r.describeChannelSetting(settings, model.SettingLanguage)))
+}
+
+// showChannelSettings lists settings of the channel, marking inherited ones
+func (r *REST) showChannelSettings(settings chat.ChannelSettings) string {
+	text := r.conf.Translate.ChannelSettingsHead
+	for _, name := range channelSettingNames() {
+		value := r.describeChannelSetting(settings, name)
+		if !settings.Set[name] {
+			value = fmt.Sprintf(r.conf.Translate.ChannelSettingsInherited, value)
+		}
+		text += fmt.Sprintf(r.conf.Translate.ChannelSettingsItem, name, value)
+	}
+	return text
+}
+
+// describeChannelSetting returns human readable value of the setting
+func (r *REST) describeChannelSetting(settings chat.ChannelSettings, name string) string {
+	switch name {
+	case model.SettingMaxReminders:
+		return fmt.Sprint(settings.ReminderRepeatsMax)
+	case model.SettingReminderInterval:
+		return r.conf.Translate.Plural(config.PluralMinutes, settings.NotifierInterval)
+	case model.SettingWarningTime:
+		return r.conf.Translate.Plural(config.PluralMinutes, settings.ReminderTime)
+	case model.SettingReportTime:
+		return settings.ReportTime
+	case model.SettingReportRecipients:
+		return mentionRecipients(settings.ReportRecipients)
+	case model.SettingLanguage:
+		if settings.Language == "" {
+			return fmt.Sprintf(r.conf.Translate.LanguageDefault, r.defaultLanguage())
+		}
+		return settings.Language
+	case model.SettingValidation:
+		return settings.Validation
+	}
+	return ""
+}
+
+// channelSettingNames lists settings shown and set by /channel_settings, language is stored with the channel
+func channelSettingNames() []string {
+	return append(append([]string{}, model.ChannelSettings...), model.SettingLanguage)
+}
+
+func isChannelSetting(name string) bool {
+	for _, setting := range channelSettingNames() {
+		if setting == name {
+			return true
+		}
+	}
+	return false
+}
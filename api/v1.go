@@ -0,0 +1,407 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// apiDefaultPerPage is the page size used when per_page is not given
+	apiDefaultPerPage = 50
+	// apiMaxPerPage is the largest page size clients may request
+	apiMaxPerPage = 200
+	// apiMaxDays is the longest period standups and reports can be requested for
+	apiMaxDays = 366
+)
+
+// apiPage is the envelope of paginated JSON API responses
+type apiPage struct {
+	Data    interface{} `json:"data"`
+	Page    int         `json:"page"`
+	PerPage int         `json:"per_page"`
+	Total   int         `json:"total"`
+}
+
+// apiError is the body of failed JSON API responses
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// apiMember is channel member as returned by JSON API
+type apiMember struct {
+	ID            int64     `json:"id"`
+	UserID        string    `json:"user_id"`
+	UserName      string    `json:"user_name"`
+	RoleInChannel string    `json:"role_in_channel"`
+	StandupTime   int64     `json:"standup_time"`
+	Created       time.Time `json:"created"`
+}
+
+// apiTimeTable is timetable of channel member as returned by JSON API
+type apiTimeTable struct {
+	UserID string `json:"user_id"`
+	model.TimeTable
+}
+
+// initAPIv1 registers versioned JSON API for external integrations
+func (r *REST) initAPIv1() {
+	r.echo.GET("/api/v1/openapi.json", r.apiOpenAPI)
+	g := r.echo.Group("/api/v1", r.apiAuth)
+	g.GET("/channels", r.apiChannels)
+	g.GET("/channels/:channel", r.apiChannel)
+	g.GET("/channels/:channel/members", r.apiMembers)
+	g.GET("/channels/:channel/timetables", r.apiTimeTables)
+	g.GET("/channels/:channel/standups", r.apiStandups)
+	g.GET("/channels/:channel/report", r.apiReport)
+}
+
+// apiAuth authenticates requests by the bearer token and stores the token in context
+func (r *REST) apiAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		if !strings.HasPrefix(header, "Bearer ") {
+			return apiFail(c, http.StatusUnauthorized, "missing bearer token")
+		}
+		token, err := r.db.SelectAPITokenByHash(hashAPIToken(strings.TrimPrefix(header, "Bearer ")))
+		if err != nil {
+			return apiFail(c, http.StatusUnauthorized, "invalid token")
+		}
+		c.Set("token", token)
+		return next(c)
+	}
+}
+
+func (r *REST) apiOpenAPI(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, []byte(openAPIDocument))
+}
+
+func (r *REST) apiChannels(c echo.Context) error {
+	token := c.Get("token").(model.APIToken)
+	all, err := r.db.GetAllChannels()
+	if err != nil {
+		logrus.Errorf("rest: GetAllChannels failed: %v\n", err)
+		return apiFail(c, http.StatusInternalServerError, "failed to list channels")
+	}
+	channels := []model.Channel{}
+	for _, channel := range all {
+		if token.CanAccessChannel(channel.ChannelID) {
+			channels = append(channels, channel)
+		}
+	}
+	page, perPage, err := apiPagination(c)
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	from, to := pageBounds(len(channels), page, perPage)
+	return c.JSON(http.StatusOK, apiPage{Data: channels[from:to], Page: page, PerPage: perPage, Total: len(channels)})
+}
+
+func (r *REST) apiChannel(c echo.Context) error {
+	channel, status, err := r.apiSelectChannel(c)
+	if err != nil {
+		return apiFail(c, status, err.Error())
+	}
+	return c.JSON(http.StatusOK, channel)
+}
+
+func (r *REST) apiMembers(c echo.Context) error {
+	channel, status, err := r.apiSelectChannel(c)
+	if err != nil {
+		return apiFail(c, status, err.Error())
+	}
+	members, err := r.db.ListChannelMembers(channel.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ListChannelMembers failed: %v\n", err)
+		return apiFail(c, http.StatusInternalServerError, "failed to list members")
+	}
+	page, perPage, err := apiPagination(c)
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	from, to := pageBounds(len(members), page, perPage)
+	items := []apiMember{}
+	for _, member := range members[from:to] {
+		item := apiMember{
+			ID:            member.ID,
+			UserID:        member.UserID,
+			RoleInChannel: member.RoleInChannel,
+			StandupTime:   member.StandupTime,
+			Created:       member.Created,
+		}
+		user, err := r.db.SelectUser(member.UserID)
+		if err == nil {
+			item.UserName = user.UserName
+		}
+		items = append(items, item)
+	}
+	return c.JSON(http.StatusOK, apiPage{Data: items, Page: page, PerPage: perPage, Total: len(members)})
+}
+
+func (r *REST) apiTimeTables(c echo.Context) error {
+	channel, status, err := r.apiSelectChannel(c)
+	if err != nil {
+		return apiFail(c, status, err.Error())
+	}
+	members, err := r.db.ListChannelMembers(channel.ChannelID)
+	if err != nil {
+		logrus.Errorf("rest: ListChannelMembers failed: %v\n", err)
+		return apiFail(c, http.StatusInternalServerError, "failed to list members")
+	}
+	timetables := []apiTimeTable{}
+	for _, member := range members {
+		if !r.db.MemberHasTimeTable(member.ID) {
+			continue
+		}
+		tt, err := r.db.SelectTimeTable(member.ID)
+		if err != nil {
+			logrus.Errorf("rest: SelectTimeTable failed: %v\n", err)
+			continue
+		}
+		timetables = append(timetables, apiTimeTable{UserID: member.UserID, TimeTable: tt})
+	}
+	page, perPage, err := apiPagination(c)
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	from, to := pageBounds(len(timetables), page, perPage)
+	return c.JSON(http.StatusOK, apiPage{Data: timetables[from:to], Page: page, PerPage: perPage, Total: len(timetables)})
+}
+
+func (r *REST) apiStandups(c echo.Context) error {
+	if !c.Get("token").(model.APIToken).CanReadStandups() {
+		return apiFail(c, http.StatusForbidden, "token cannot read standups")
+	}
+	channel, status, err := r.apiSelectChannel(c)
+	if err != nil {
+		return apiFail(c, status, err.Error())
+	}
+	dateFrom, dateTo, err := apiPeriod(c, time.Now())
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	standups, err := r.db.SelectStandupsByChannelIDForPeriod(channel.ChannelID, dateFrom, dateTo.Add(24*time.Hour))
+	if err != nil {
+		logrus.Errorf("rest: SelectStandupsByChannelIDForPeriod failed: %v\n", err)
+		return apiFail(c, http.StatusInternalServerError, "failed to list standups")
+	}
+	page, perPage, err := apiPagination(c)
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	from, to := pageBounds(len(standups), page, perPage)
+	return c.JSON(http.StatusOK, apiPage{Data: standups[from:to], Page: page, PerPage: perPage, Total: len(standups)})
+}
+
+func (r *REST) apiReport(c echo.Context) error {
+	token := c.Get("token").(model.APIToken)
+	channel, status, err := r.apiSelectChannel(c)
+	if err != nil {
+		return apiFail(c, status, err.Error())
+	}
+	dateFrom, dateTo, err := apiPeriod(c, time.Now())
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	report, err := r.report.StandupReportByProject(channel, dateFrom, dateTo)
+	if err != nil {
+		logrus.Errorf("rest: StandupReportByProject failed: %v\n", err)
+		return apiFail(c, http.StatusInternalServerError, "failed to build report")
+	}
+	entries := report.ExportEntries()
+	if !token.CanReadStandups() {
+		for i := range entries {
+			entries[i].Standup = ""
+		}
+	}
+	page, perPage, err := apiPagination(c)
+	if err != nil {
+		return apiFail(c, http.StatusBadRequest, err.Error())
+	}
+	from, to := pageBounds(len(entries), page, perPage)
+	return c.JSON(http.StatusOK, apiPage{Data: entries[from:to], Page: page, PerPage: perPage, Total: len(entries)})
+}
+
+// apiSelectChannel returns channel from the request path if token gives access to it,
+// otherwise it returns HTTP status and error to respond with
+func (r *REST) apiSelectChannel(c echo.Context) (model.Channel, int, error) {
+	token := c.Get("token").(model.APIToken)
+	channelID := c.Param("channel")
+	if !token.CanAccessChannel(channelID) {
+		return model.Channel{}, http.StatusForbidden, errors.New("token cannot access the channel")
+	}
+	channel, err := r.db.SelectChannel(channelID)
+	if err != nil {
+		return channel, http.StatusNotFound, errors.New("channel not found")
+	}
+	return channel, http.StatusOK, nil
+}
+
+func apiFail(c echo.Context, status int, message string) error {
+	return c.JSON(status, apiError{Error: message})
+}
+
+// apiPagination parses page and per_page query parameters
+func apiPagination(c echo.Context) (int, int, error) {
+	page, perPage := 1, apiDefaultPerPage
+	var err error
+	if v := c.QueryParam("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return 0, 0, errors.New("page should be a positive number")
+		}
+	}
+	if v := c.QueryParam("per_page"); v != "" {
+		perPage, err = strconv.Atoi(v)
+		if err != nil || perPage < 1 || perPage > apiMaxPerPage {
+			return 0, 0, errors.New("per_page should be a number from 1 to " + strconv.Itoa(apiMaxPerPage))
+		}
+	}
+	return page, perPage, nil
+}
+
+// pageBounds returns bounds of the page in a list of total items, a page past the end is empty.
+// Pages are compared before multiplying, so a huge page number does not overflow
+func pageBounds(total, page, perPage int) (int, int) {
+	if page-1 > total/perPage {
+		return total, total
+	}
+	from := (page - 1) * perPage
+	if from > total {
+		from = total
+	}
+	to := from + perPage
+	if to > total {
+		to = total
+	}
+	return from, to
+}
+
+// apiPeriod parses from and to query parameters, the last 7 days by default
+func apiPeriod(c echo.Context, now time.Time) (time.Time, time.Time, error) {
+	dateTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	var err error
+	if v := c.QueryParam("to"); v != "" {
+		dateTo, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return dateTo, dateTo, errors.New("to should be a date like 2006-01-02")
+		}
+	}
+	dateFrom := dateTo.AddDate(0, 0, -6)
+	if v := c.QueryParam("from"); v != "" {
+		dateFrom, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			return dateFrom, dateTo, errors.New("from should be a date like 2006-01-02")
+		}
+	}
+	if dateFrom.After(dateTo) {
+		return dateFrom, dateTo, errors.New("from should not be after to")
+	}
+	if dateTo.Sub(dateFrom) >= apiMaxDays*24*time.Hour {
+		return dateFrom, dateTo, errors.New("period should not be longer than " + strconv.Itoa(apiMaxDays) + " days")
+	}
+	return dateFrom, dateTo, nil
+}
+
+func (r *REST) apiToken(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	args := strings.Fields(ca.Text)
+	if len(args) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	switch args[0] {
+	case "list":
+		tokens, err := r.db.ListAPITokens()
+		if err != nil {
+			logrus.Errorf("rest: ListAPITokens failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		if len(tokens) == 0 {
+			return c.String(http.StatusOK, r.conf.Translate.APITokenListEmpty)
+		}
+		text := r.conf.Translate.APITokenListHead
+		for _, token := range tokens {
+			channel := r.conf.Translate.DigestAllChannels
+			if token.ChannelID != "" {
+				channel = "<#" + token.ChannelID + ">"
+			}
+			text += fmt.Sprintf(r.conf.Translate.APITokenItem, token.ID, token.Name, token.Role, channel, token.Created.Format("2006-01-02"))
+		}
+		return c.String(http.StatusOK, text)
+	case "revoke":
+		if len(args) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+		}
+		err = r.db.DeleteAPIToken(id)
+		if err != nil {
+			logrus.Errorf("rest: DeleteAPIToken failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.APITokenRevoked, id))
+	case "create":
+	default:
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+
+	if len(args) < 3 || len(args) > 4 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	token := model.APIToken{Name: args[1], Role: args[2]}
+	if len(args) == 4 {
+		token.ChannelID, err = r.parseRecipient(args[3])
+		if err != nil || !strings.HasPrefix(args[3], "<#") && !strings.HasPrefix(args[3], "#") {
+			return c.String(http.StatusOK, r.conf.Translate.WrongProjectName)
+		}
+	}
+	secret, err := randomToken()
+	if err != nil {
+		logrus.Errorf("rest: randomToken failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	token.TokenHash = hashAPIToken(secret)
+	if err := token.Validate(); err != nil {
+		return c.String(http.StatusOK, r.conf.Translate.WrongAPITokenRole)
+	}
+	token, err = r.db.CreateAPIToken(token)
+	if err != nil {
+		logrus.Errorf("rest: CreateAPIToken failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.APITokenCreated, token.ID, token.Name, secret))
+}
+
+// randomToken generates random hex token used as API token or webhook secret
+func randomToken() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashAPIToken returns hash API token is stored by, tokens themselves are never stored
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
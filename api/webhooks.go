@@ -0,0 +1,132 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+func (r *REST) webhookSet(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	args := strings.Fields(ca.Text)
+	if len(args) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	if args[0] == "remove" {
+		if len(args) != 2 {
+			return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WebhookNotFound, args[1]))
+		}
+		if _, err := r.db.SelectWebhook(id); err != nil {
+			return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WebhookNotFound, args[1]))
+		}
+		err = r.db.DeleteWebhook(id)
+		if err != nil {
+			logrus.Errorf("rest: DeleteWebhook failed: %v\n", err)
+			return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+		}
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WebhookRemoved, id))
+	}
+
+	if len(args) < 2 || len(args) > 3 {
+		return c.String(http.StatusOK, r.conf.Translate.WrongNArgs)
+	}
+	webhook := model.Webhook{URL: parseLink(args[0]), Events: args[1]}
+	if args[1] == "all" {
+		webhook.Events = strings.Join(model.WebhookEvents, ",")
+	}
+	if len(args) == 3 {
+		webhook.ChannelID, err = r.parseRecipient(args[2])
+		if err != nil || !strings.HasPrefix(args[2], "<#") && !strings.HasPrefix(args[2], "#") {
+			return c.String(http.StatusOK, r.conf.Translate.WrongProjectName)
+		}
+	}
+	webhook.Secret, err = randomToken()
+	if err != nil {
+		logrus.Errorf("rest: randomToken failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if err := webhook.Validate(); err != nil {
+		return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WrongWebhook, err, strings.Join(model.WebhookEvents, ", ")))
+	}
+	webhook, err = r.db.CreateWebhook(webhook)
+	if err != nil {
+		logrus.Errorf("rest: CreateWebhook failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	return c.String(http.StatusOK, fmt.Sprintf(r.conf.Translate.WebhookCreated, webhook.ID, webhook.URL, webhook.Secret))
+}
+
+func (r *REST) webhookList(c echo.Context, f url.Values) error {
+	ca, err := r.validateRequest(c, f)
+	if err != nil {
+		logrus.Errorf("Validate Request Failed: %v", err)
+		return c.String(http.StatusOK, err.Error())
+	}
+
+	if text := strings.TrimSpace(ca.Text); text != "" {
+		return c.String(http.StatusOK, r.webhookLog(text))
+	}
+	webhooks, err := r.db.ListWebhooks()
+	if err != nil {
+		logrus.Errorf("rest: ListWebhooks failed: %v\n", err)
+		return c.String(http.StatusOK, r.conf.Translate.SomethingWentWrong)
+	}
+	if len(webhooks) == 0 {
+		return c.String(http.StatusOK, r.conf.Translate.WebhookListEmpty)
+	}
+	text := r.conf.Translate.WebhookListHead
+	for _, webhook := range webhooks {
+		channel := r.conf.Translate.DigestAllChannels
+		if webhook.ChannelID != "" {
+			channel = "<#" + webhook.ChannelID + ">"
+		}
+		text += fmt.Sprintf(r.conf.Translate.WebhookItem, webhook.ID, webhook.URL, strings.Join(webhook.EventsList(), ", "), channel)
+	}
+	return c.String(http.StatusOK, text)
+}
+
+// webhookLog lists latest deliveries of the webhook
+func (r *REST) webhookLog(id string) string {
+	webhookID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return fmt.Sprintf(r.conf.Translate.WebhookNotFound, id)
+	}
+	webhook, err := r.db.SelectWebhook(webhookID)
+	if err != nil {
+		return fmt.Sprintf(r.conf.Translate.WebhookNotFound, id)
+	}
+	deliveries, err := r.db.ListWebhookDeliveries(webhook.ID, 10)
+	if err != nil {
+		logrus.Errorf("rest: ListWebhookDeliveries failed: %v\n", err)
+		return r.conf.Translate.SomethingWentWrong
+	}
+	if len(deliveries) == 0 {
+		return fmt.Sprintf(r.conf.Translate.WebhookLogEmpty, webhook.ID)
+	}
+	text := fmt.Sprintf(r.conf.Translate.WebhookLogHead, webhook.ID, webhook.URL)
+	for _, d := range deliveries {
+		text += fmt.Sprintf(r.conf.Translate.WebhookLogItem, d.ID, d.Created.Format("2006-01-02 15:04:05"), d.Event, d.Status, d.Attempts, d.ResponseCode, d.LastError)
+	}
+	return text
+}
+
+// parseLink returns URL from Slack formatted link like <https://example.com|example.com>
+func parseLink(link string) string {
+	link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+	return strings.Split(link, "|")[0]
+}
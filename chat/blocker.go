@@ -0,0 +1,247 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// permalinkClient requests links to standup messages
+var permalinkClient = &http.Client{Timeout: 10 * time.Second}
+
+// blockerSectionNames are names of standup sections which describe blockers
+var blockerSectionNames = []string{"problems", "blockers"}
+
+// noBlockerAnswers are answers that mean there is nothing blocking the work
+var noBlockerAnswers = []string{
+	"no", "none", "nope", "nothing", "n/a", "na", "not yet", "all good", "everything is fine",
+	"no problems", "no problem", "no blockers", "no issues", "no questions", "no difficulties",
+	"нет", "нету", "ничего", "нет проблем", "проблем нет", "без проблем", "пока нет", "все хорошо", "всё хорошо",
+	"нет вопросов", "вопросов нет", "трудностей нет", "нет трудностей",
+}
+
+// handleBlocker extracts blocker from the standup and escalates it to channel PMs
+func (s *Slack) handleBlocker(standup model.Standup) {
+	text := extractBlocker(s.StandupRules(standup.ChannelID), standup.Comment)
+	permalink := ""
+	if text != "" {
+		var err error
+		permalink, err = s.getPermalink(standup.ChannelID, standup.MessageTS)
+		if err != nil {
+			logrus.Errorf("getPermalink failed for standup %v: %v", standup.ID, err)
+		}
+	}
+	s.escalateBlocker(standup, text, permalink)
+}
+
+// getPermalink returns link to the message from chat.getPermalink, it knows domain of the workspace
+// and links replies in threads properly. The vendored Slack client does not wrap this method
+func (s *Slack) getPermalink(channelID, messageTS string) (string, error) {
+	form := url.Values{"token": {s.Config().SlackToken}, "channel": {channelID}, "message_ts": {messageTS}}
+	req, err := http.NewRequest(http.MethodPost, slack.SLACK_API+"chat.getPermalink", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := instrumentedClient{permalinkClient}.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		OK        bool   `json:"ok"`
+		Error     string `json:"error"`
+		Permalink string `json:"permalink"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if !result.OK {
+		return "", errors.New(result.Error)
+	}
+	return result.Permalink, nil
+}
+
+// escalateBlocker saves blocker reported in the standup and DMs channel PMs about it.
+// If blocker disappears after standup is edited, it is marked as resolved
+func (s *Slack) escalateBlocker(standup model.Standup, text, permalink string) {
+	blocker, err := s.DB.SelectBlockerByStandup(standup.ID)
+	if err == nil {
+		if text == "" {
+			if blocker.Resolved {
+				return
+			}
+			blocker.Resolved = true
+			blocker.ResolvedBy = standup.UserID
+		} else {
+			blocker.Text = text
+		}
+		_, err := s.DB.UpdateBlocker(blocker)
+		if err != nil {
+			logrus.Errorf("UpdateBlocker failed: %v", err)
+		}
+		return
+	}
+	if text == "" {
+		return
+	}
+
+	blocker, err = s.DB.CreateBlocker(model.Blocker{
+		ChannelID: standup.ChannelID,
+		UserID:    standup.UserID,
+		StandupID: standup.ID,
+		MessageTS: standup.MessageTS,
+		Text:      text,
+	})
+	if err != nil {
+		logrus.Errorf("CreateBlocker failed: %v", err)
+		return
+	}
+	logrus.Infof("Blocker created #id:%v\n", blocker.ID)
+
+	pms, err := s.DB.ListChannelMembersByRole(standup.ChannelID, "pm")
+	if err != nil {
+		logrus.Errorf("ListChannelMembersByRole failed: %v", err)
+		return
+	}
+	for _, pm := range pms {
+		t := s.Translation(standup.ChannelID, pm.UserID)
+		message := fmt.Sprintf(t.BlockerEscalation, standup.UserID, standup.ChannelID, text)
+		if permalink != "" {
+			message += fmt.Sprintf(t.BlockerEscalationLink, permalink)
+		}
+		message += fmt.Sprintf(t.BlockerEscalationResolve, blocker.ID)
+		s.SendUserMessage(pm.UserID, message)
+	}
+}
+
+// extractBlocker returns text of the blockers section of the standup.
+// Empty string is returned if the section is missing or says there are no blockers
+func extractBlocker(rules model.StandupRules, message string) string {
+	sections, err := rules.ListSections()
+	if err != nil {
+		logrus.Errorf("ListSections failed for channel %v: %v", rules.ChannelID, err)
+		sections = defaultStandupSections
+	}
+	start, end := -1, -1
+	for _, section := range sections {
+		if !isBlockerSection(section) {
+			continue
+		}
+		start, end = sectionPosition(section, message)
+		break
+	}
+	if start < 0 {
+		return ""
+	}
+	// blockers section lasts till the beginning of the closest following section
+	stop := len(message)
+	for _, section := range sections {
+		if isBlockerSection(section) {
+			continue
+		}
+		position, _ := sectionPosition(section, message)
+		if position > start && position < stop {
+			stop = position
+		}
+	}
+	if end > stop {
+		return ""
+	}
+	return normalizeBlocker(message[end:stop])
+}
+
+// normalizeBlocker trims the answer about blockers and returns empty string if it means there are none
+func normalizeBlocker(text string) string {
+	text = strings.Trim(text, " \t\r\n.,;:-*_")
+	normalized := strings.Trim(strings.ToLower(text), " .,!?():;")
+	for _, answer := range noBlockerAnswers {
+		if normalized == answer {
+			return ""
+		}
+	}
+	return text
+}
+
+func isBlockerSection(section model.StandupSection) bool {
+	for _, name := range blockerSectionNames {
+		if strings.ToLower(section.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionPosition returns bounds of the heading of the section in the message.
+// Headings followed by a colon or a dash are preferred over keywords mentioned in passing
+func sectionPosition(section model.StandupSection, message string) (int, int) {
+	matches := [][]int{}
+	if section.Pattern != "" {
+		rg, err := regexp.Compile("(?i)" + section.Pattern)
+		if err != nil {
+			return -1, -1
+		}
+		matches = rg.FindAllStringIndex(message, -1)
+	} else {
+		// keywords are matched case-insensitively on the message itself, lowering the message
+		// could change byte lengths of its characters and shift the positions
+		for _, keyword := range section.Keywords {
+			if keyword == "" {
+				continue
+			}
+			rg := regexp.MustCompile("(?i)" + regexp.QuoteMeta(keyword))
+			for _, match := range rg.FindAllStringIndex(message, -1) {
+				matches = append(matches, []int{match[0], wordEnd(message, match[1])})
+			}
+		}
+	}
+	start, end, heading := -1, -1, false
+	for _, match := range matches {
+		e, isHeading := headingEnd(message, match[1])
+		if !isHeading {
+			e = match[1]
+		}
+		if start < 0 || (isHeading && !heading) || (isHeading == heading && match[0] < start) {
+			start, end, heading = match[0], e, isHeading
+		}
+	}
+	return start, end
+}
+
+func wordEnd(message string, i int) int {
+	for i < len(message) {
+		r, size := utf8.DecodeRuneInString(message[i:])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// headingEnd skips formatting and separator after the section heading
+func headingEnd(message string, i int) (int, bool) {
+	isHeading := false
+	for i < len(message) {
+		switch message[i] {
+		case ':', '-':
+			isHeading = true
+		case ' ', '*', '_', '\t':
+		default:
+			return i, isHeading
+		}
+		i++
+	}
+	return i, isHeading
+}
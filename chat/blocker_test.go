@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestExtractBlocker(t *testing.T) {
+	rules := model.StandupRules{}
+	assert.NoError(t, rules.SetSections(defaultStandupSections))
+
+	testCases := []struct {
+		message string
+		blocker string
+	}{
+		{"Yesterday: fixed bugs\nToday: write tests\nProblems: none", ""},
+		{"Yesterday: fixed bugs\nToday: write tests\nProblems: no problems!", ""},
+		{"yesterday fixed bugs, today write tests, no problems", ""},
+		{"Вчера: исправлял баги\nСегодня: пишу тесты\nПроблемы: нет", ""},
+		{"Yesterday: fixed bugs\nToday: write tests\nProblems: staging DB is down", "staging DB is down"},
+		{"*Problems*: need access to CI\n*Yesterday*: fixed issue #12\n*Today*: write tests", "need access to CI"},
+		{"Yesterday: fixed issue #12. Today: write tests. Problems: waiting for review.", "waiting for review"},
+		{"Yesterday: fixed bugs\nToday: write tests", ""},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.blocker, extractBlocker(rules, tt.message), tt.message)
+	}
+
+	custom := model.StandupRules{}
+	assert.NoError(t, custom.SetSections([]model.StandupSection{
+		{Name: "done", Keywords: []string{"done"}},
+		{Name: "blockers", Pattern: `blocker[s]?`},
+	}))
+	assert.Equal(t, "API keys expired", extractBlocker(custom, "Blockers - API keys expired\nDone: release"))
+}
+
+func TestSectionPosition(t *testing.T) {
+	section := model.StandupSection{Name: "problems", Keywords: []string{"problem"}}
+	start, end := sectionPosition(section, "Problems: VPN is down")
+	assert.Equal(t, []int{0, 10}, []int{start, end})
+
+	// İ changes its byte length when lowered, the heading is still found in the message
+	message := "Yesterday: İstanbul office\nProblems: VPN is down"
+	start, end = sectionPosition(section, message)
+	if assert.NotEqual(t, -1, start) {
+		assert.Equal(t, "Problems: ", message[start:end])
+	}
+}
+
+func TestNormalizeBlocker(t *testing.T) {
+	assert.Equal(t, "", normalizeBlocker(" none. "))
+	assert.Equal(t, "", normalizeBlocker("Нет проблем :)"))
+	assert.Equal(t, "", normalizeBlocker(""))
+	assert.Equal(t, "tests are flaky?", normalizeBlocker(" - tests are flaky?\n"))
+}
+
+func TestGetPermalink(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.getPermalink", func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "CBAPFA2J2", req.FormValue("channel"))
+		assert.Equal(t, "1530000000.000100", req.FormValue("message_ts"))
+		return httpmock.NewStringResponse(200, `{"ok": true, "channel": "CBAPFA2J2", "permalink": "https://team.slack.com/archives/CBAPFA2J2/p1530000000000100?thread_ts=1529999999.000200"}`), nil
+	})
+
+	s := &Slack{Conf: config.Config{SlackToken: "xoxb-test"}}
+	permalink, err := s.getPermalink("CBAPFA2J2", "1530000000.000100")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://team.slack.com/archives/CBAPFA2J2/p1530000000000100?thread_ts=1529999999.000200", permalink)
+
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.getPermalink", httpmock.NewStringResponder(200, `{"ok": false, "error": "message_not_found"}`))
+	_, err = s.getPermalink("CBAPFA2J2", "1530000000.000100")
+	assert.EqualError(t, err, "message_not_found")
+}
@@ -0,0 +1,139 @@
+package chat
+
+import (
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Slack) handleLeave(userID, channelID string) {
+	if userID == s.botUserID() {
+		// bot can not see channel it was kicked from, so it is treated as archived
+		s.setChannelArchived(channelID, true)
+		return
+	}
+	member, err := s.DB.FindChannelMemberByUserID(userID, channelID)
+	if err != nil {
+		return
+	}
+	s.removeChannelMember(member)
+	logrus.Infof("Member %v left channel %v and was removed from standupers", userID, channelID)
+}
+
+func (s *Slack) handleRename(channelID, name string) {
+	channel, err := s.DB.SelectChannel(channelID)
+	if err != nil {
+		logrus.Errorf("SelectChannel failed: %v", err)
+		return
+	}
+	channel.ChannelName = name
+	_, err = s.DB.UpdateChannel(channel)
+	if err != nil {
+		logrus.Errorf("UpdateChannel failed: %v", err)
+		return
+	}
+	logrus.Infof("Channel %v renamed to %v", channelID, name)
+}
+
+func (s *Slack) setChannelArchived(channelID string, archived bool) {
+	channel, err := s.DB.SelectChannel(channelID)
+	if err != nil {
+		logrus.Errorf("SelectChannel failed: %v", err)
+		return
+	}
+	if channel.Archived == archived {
+		return
+	}
+	channel.Archived = archived
+	_, err = s.DB.UpdateChannel(channel)
+	if err != nil {
+		logrus.Errorf("UpdateChannel failed: %v", err)
+		return
+	}
+	logrus.Infof("Channel %v archived: %v", channelID, archived)
+}
+
+// removeChannelMember deletes member from channel standupers together with its timetable
+func (s *Slack) removeChannelMember(member model.ChannelMember) {
+	s.DB.DeleteChannelMember(member.UserID, member.ChannelID)
+	tt, err := s.DB.SelectTimeTable(member.ID)
+	if err != nil {
+		return
+	}
+	s.DB.DeleteTimeTable(tt.ID)
+}
+
+// UpdateChannelsList reconciles stored channels and their members with Slack.
+// Renamed channels get their new names, archived channels or channels bot was removed from
+// are marked as archived and members who left channels are removed from standupers
+func (s *Slack) UpdateChannelsList() {
+	channels, err := s.DB.GetAllChannels()
+	if err != nil {
+		logrus.Errorf("GetAllChannels failed: %v", err)
+		return
+	}
+	for _, channel := range channels {
+		info, err := s.API.GetConversationInfo(channel.ChannelID, false)
+		if err != nil {
+			logrus.Errorf("GetConversationInfo failed for channel %v: %v", channel.ChannelID, err)
+			continue
+		}
+		archived := info.IsArchived || (info.IsChannel && !info.IsMember)
+		if info.Name != channel.ChannelName || archived != channel.Archived {
+			channel.ChannelName = info.Name
+			channel.Archived = archived
+			_, err := s.DB.UpdateChannel(channel)
+			if err != nil {
+				logrus.Errorf("UpdateChannel failed: %v", err)
+				continue
+			}
+		}
+		if archived {
+			continue
+		}
+
+		users, err := s.listConversationMembers(channel.ChannelID)
+		if err != nil {
+			logrus.Errorf("listConversationMembers failed for channel %v: %v", channel.ChannelID, err)
+			continue
+		}
+		members, err := s.DB.ListChannelMembers(channel.ChannelID)
+		if err != nil {
+			logrus.Errorf("ListChannelMembers failed for channel %v: %v", channel.ChannelID, err)
+			continue
+		}
+		for _, member := range members {
+			if !users[member.UserID] {
+				s.removeChannelMember(member)
+				logrus.Infof("Member %v is not in channel %v anymore and was removed from standupers", member.UserID, channel.ChannelID)
+			}
+		}
+	}
+	logrus.Info("Channels list updated successfully")
+}
+
+func (s *Slack) listConversationMembers(channelID string) (map[string]bool, error) {
+	users := map[string]bool{}
+	params := &slack.GetUsersInConversationParameters{ChannelID: channelID}
+	for {
+		page, cursor, err := s.API.GetUsersInConversation(params)
+		if err != nil {
+			return users, err
+		}
+		for _, user := range page {
+			users[user] = true
+		}
+		if cursor == "" {
+			return users, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+func (s *Slack) botUserID() string {
+	info := s.RTM.GetInfo()
+	if info == nil || info.User == nil {
+		return ""
+	}
+	return info.User.ID
+}
@@ -0,0 +1,85 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestChannelEvents(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	channel, err := s.DB.CreateChannel(model.Channel{
+		ChannelName: "events",
+		ChannelID:   "EVENTSCHAN",
+	})
+	assert.NoError(t, err)
+	member, err := s.DB.CreateChannelMember(model.ChannelMember{
+		UserID:    "userID1",
+		ChannelID: channel.ChannelID,
+	})
+	assert.NoError(t, err)
+
+	s.handleRename(channel.ChannelID, "renamed")
+	channel, err = s.DB.SelectChannel(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", channel.ChannelName)
+
+	s.setChannelArchived(channel.ChannelID, true)
+	channel, err = s.DB.SelectChannel(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, true, channel.Archived)
+
+	s.setChannelArchived(channel.ChannelID, false)
+	channel, err = s.DB.SelectChannel(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, false, channel.Archived)
+
+	s.handleLeave(member.UserID, channel.ChannelID)
+	_, err = s.DB.FindChannelMemberByUserID(member.UserID, channel.ChannelID)
+	assert.Error(t, err)
+
+	assert.NoError(t, s.DB.DeleteChannel(channel.ID))
+}
+
+func TestUpdateChannelsList(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/conversations.info", httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "SYNCCHAN", "name": "new-name", "is_channel": true, "is_member": true}}`))
+	httpmock.RegisterResponder("POST", "https://slack.com/api/conversations.members", httpmock.NewStringResponder(200, `{"ok": true, "members": ["userID1"], "response_metadata": {"next_cursor": ""}}`))
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	channel, err := s.DB.CreateChannel(model.Channel{
+		ChannelName: "old-name",
+		ChannelID:   "SYNCCHAN",
+	})
+	assert.NoError(t, err)
+	stayed, err := s.DB.CreateChannelMember(model.ChannelMember{UserID: "userID1", ChannelID: channel.ChannelID})
+	assert.NoError(t, err)
+	_, err = s.DB.CreateChannelMember(model.ChannelMember{UserID: "userID2", ChannelID: channel.ChannelID})
+	assert.NoError(t, err)
+
+	s.UpdateChannelsList()
+
+	channel, err = s.DB.SelectChannel(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new-name", channel.ChannelName)
+	assert.Equal(t, false, channel.Archived)
+
+	members, err := s.DB.ListChannelMembers(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(members))
+
+	assert.NoError(t, s.DB.DeleteChannelMember(stayed.UserID, stayed.ChannelID))
+	assert.NoError(t, s.DB.DeleteChannel(channel.ID))
+}
@@ -0,0 +1,111 @@
+package chat
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// CommandRunner executes management commands. It is implemented by REST API,
+// so commands sent to the bot in messages are handled the same way as slash commands
+type CommandRunner interface {
+	// IsCommand reports whether command is known
+	IsCommand(command string) bool
+	// RunCommand executes command described by slash command form values and returns response text
+	RunCommand(form url.Values) string
+}
+
+var (
+	userMentionRegexp    = regexp.MustCompile(`<@([A-Z0-9]+)>`)
+	channelMentionRegexp = regexp.MustCompile(`^<#([A-Z0-9]+)(\|[^>]*)?>$`)
+)
+
+// messageCommand is a command found in a message sent to the bot
+type messageCommand struct {
+	Command   string
+	Text      string
+	ChannelID string
+}
+
+// findMessageCommand finds command sent as a bot mention in a channel or as a direct message.
+// It returns false if the message is not a command
+func (s *Slack) findMessageCommand(msg *slack.MessageEvent, botUserID string, direct bool) (messageCommand, bool) {
+	if s.Commands == nil {
+		return messageCommand{}, false
+	}
+	return s.parseMessageCommand(msg.Msg.Text, botUserID, msg.Channel, direct)
+}
+
+// runMessageCommand executes command found in the message and sends response to its author
+func (s *Slack) runMessageCommand(msg *slack.MessageEvent, mc messageCommand, direct bool) {
+	channelName := mc.ChannelID
+	channel, err := s.DB.SelectChannel(mc.ChannelID)
+	if err == nil {
+		channelName = channel.ChannelName
+	}
+	logrus.Infof("Command %v %v from %v in %v", mc.Command, mc.Text, msg.User, mc.ChannelID)
+	// commands like reports may take a while, they should not block incoming events
+	go func() {
+		response := s.Commands.RunCommand(url.Values{
+			"command":      {mc.Command},
+			"text":         {mc.Text},
+			"channel_id":   {mc.ChannelID},
+			"channel_name": {channelName},
+			"user_id":      {msg.User},
+		})
+		if direct {
+			s.SendResponse("", msg.User, response)
+			return
+		}
+		s.sendEphemeralResponse(msg.Channel, msg.User, response)
+	}()
+}
+
+// parseMessageCommand finds command in text like "@comedian add @user / pm".
+// In direct messages bot mention is not needed and channel may be specified before the command
+func (s *Slack) parseMessageCommand(text, botUserID, channelID string, direct bool) (messageCommand, bool) {
+	mc := messageCommand{ChannelID: channelID}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, botUserID) {
+		text = strings.TrimSpace(strings.TrimPrefix(text, botUserID))
+	} else if !direct {
+		return mc, false
+	}
+	fields := strings.Fields(text)
+	if direct && len(fields) != 0 {
+		if match := channelMentionRegexp.FindStringSubmatch(fields[0]); match != nil {
+			mc.ChannelID = match[1]
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return mc, false
+	}
+	mc.Command = "/" + strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+	if !s.Commands.IsCommand(mc.Command) {
+		return mc, false
+	}
+	mc.Text = s.expandUserMentions(strings.Join(fields[1:], " "))
+	return mc, true
+}
+
+// expandUserMentions turns message mentions like <@U123> into slash command ones like <@U123|name>
+func (s *Slack) expandUserMentions(text string) string {
+	return userMentionRegexp.ReplaceAllStringFunc(text, func(mention string) string {
+		userID := userMentionRegexp.FindStringSubmatch(mention)[1]
+		user, err := s.DB.SelectUser(userID)
+		if err != nil {
+			return mention
+		}
+		return fmt.Sprintf("<@%v|%v>", userID, user.UserName)
+	})
+}
+
+func (s *Slack) hasInterview(userID string) bool {
+	interviews, err := s.DB.ListUserInterviews(userID)
+	return err == nil && len(interviews) != 0
+}
@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCommandRunner struct {
+	forms []url.Values
+}
+
+func (f *fakeCommandRunner) IsCommand(command string) bool {
+	return command == "/add" || command == "/list"
+}
+
+func (f *fakeCommandRunner) RunCommand(form url.Values) string {
+	f.forms = append(f.forms, form)
+	return "done"
+}
+
+func TestParseMessageCommand(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+	s.Commands = &fakeCommandRunner{}
+
+	testCases := []struct {
+		text      string
+		direct    bool
+		ok        bool
+		command   string
+		args      string
+		channelID string
+	}{
+		{"<@BOT> add <@U1|user> / pm", false, true, "/add", "<@U1|user> / pm", "CHAN"},
+		{"<@BOT> /list admin", false, true, "/list", "admin", "CHAN"},
+		{"add <@U1|user> / pm", false, false, "", "", ""},
+		{"<@BOT> yesterday: fixed bugs", false, false, "", "", ""},
+		{"list pm", true, true, "/list", "pm", "CHAN"},
+		{"<#CPROJECT|project> add <@U1|user>", true, true, "/add", "<@U1|user>", "CPROJECT"},
+		{"<#CPROJECT> list", true, true, "/list", "", "CPROJECT"},
+		{"hello there", true, false, "", "", ""},
+	}
+	for _, tt := range testCases {
+		mc, ok := s.parseMessageCommand(tt.text, "<@BOT>", "CHAN", tt.direct)
+		assert.Equal(t, tt.ok, ok, tt.text)
+		if !ok {
+			continue
+		}
+		assert.Equal(t, tt.command, mc.Command, tt.text)
+		assert.Equal(t, tt.args, mc.Text, tt.text)
+		assert.Equal(t, tt.channelID, mc.ChannelID, tt.text)
+	}
+}
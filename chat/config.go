@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/sirupsen/logrus"
+)
+
+// configPollInterval is how often the config file is checked for changes
+var configPollInterval = 10 * time.Second
+
+// Config returns current configuration, it changes when the config file is reloaded
+func (s *Slack) Config() config.Config {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+	return s.Conf
+}
+
+// VersionedConfig returns current configuration together with the number of reloads
+// applied so far, so that anything derived from the configuration can be rebuilt on reload
+func (s *Slack) VersionedConfig() (config.Config, int) {
+	s.confMutex.RLock()
+	defer s.confMutex.RUnlock()
+	return s.Conf, s.confVersion
+}
+
+// ReloadConfig reads env variables and the config file again and applies them
+// without reconnecting to Slack. Invalid configuration is logged and ignored
+func (s *Slack) ReloadConfig() error {
+	conf, err := config.Get()
+	if err != nil {
+		logrus.Errorf("slack: config reload failed, keeping current config: %v\n", err)
+		return err
+	}
+	s.confMutex.Lock()
+	s.Conf = conf
+	s.confVersion++
+	s.confMutex.Unlock()
+	logrus.Infof("slack: config reloaded from %v", conf.ConfigFile)
+	return nil
+}
+
+// WatchConfig reloads configuration on SIGHUP and when the config file is modified
+func (s *Slack) WatchConfig() {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	modified := configModTime(s.Config().ConfigFile)
+	for {
+		select {
+		case <-hangup:
+			s.ReloadConfig()
+			modified = configModTime(s.Config().ConfigFile)
+		case <-ticker.C:
+			m := configModTime(s.Config().ConfigFile)
+			if m.Equal(modified) {
+				continue
+			}
+			modified = m
+			s.ReloadConfig()
+		}
+	}
+}
+
+// configModTime returns modification time of the config file, zero time if there is none
+func configModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
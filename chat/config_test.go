@@ -0,0 +1,43 @@
+package chat
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "comedian")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "comedian.toml")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("max_reminders = 7\n[channels.CBAPFA2J2]\nwarning_time = 20\n"), 0644))
+	os.Setenv("COMEDIAN_CONFIG_FILE", file)
+	defer os.Unsetenv("COMEDIAN_CONFIG_FILE")
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s := &Slack{Conf: c}
+	assert.Equal(t, 7, s.Config().ReminderRepeatsMax)
+	assert.Equal(t, int64(20), s.ChannelSettings("CBAPFA2J2").ReminderTime)
+	modified := configModTime(file)
+	assert.False(t, modified.IsZero())
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("max_reminders = 2\n"), 0644))
+	assert.NoError(t, s.ReloadConfig())
+	assert.Equal(t, 2, s.Config().ReminderRepeatsMax)
+	assert.Equal(t, s.Config().ReminderTime, s.ChannelSettings("CBAPFA2J2").ReminderTime)
+
+	// invalid config keeps the current one
+	assert.NoError(t, ioutil.WriteFile(file, []byte("max_reminders = -2\n"), 0644))
+	assert.Error(t, s.ReloadConfig())
+	assert.Equal(t, 2, s.Config().ReminderRepeatsMax)
+
+	assert.True(t, configModTime(filepath.Join(dir, "missing.toml")).IsZero())
+	assert.Equal(t, time.Time{}, configModTime(""))
+}
@@ -0,0 +1,58 @@
+package chat
+
+import (
+	"sort"
+	"time"
+)
+
+// States of RTM connection
+const (
+	RTMConnecting   = "connecting"
+	RTMConnected    = "connected"
+	RTMDisconnected = "disconnected"
+	RTMInvalidAuth  = "invalid_auth"
+)
+
+// heartbeatTimeout is how long scheduled loops may stay silent before they are considered stalled,
+// they beat every minute
+const heartbeatTimeout = 3 * time.Minute
+
+// RTMState returns state of RTM connection
+func (s *Slack) RTMState() string {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	if s.rtmState == "" {
+		return RTMConnecting
+	}
+	return s.rtmState
+}
+
+func (s *Slack) setRTMState(state string) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	s.rtmState = state
+}
+
+// Heartbeat records that scheduled loop with the name is running
+func (s *Slack) Heartbeat(name string) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	if s.heartbeats == nil {
+		s.heartbeats = map[string]time.Time{}
+	}
+	s.heartbeats[name] = time.Now()
+}
+
+// StalledLoops returns names of scheduled loops which have not beaten for too long
+func (s *Slack) StalledLoops(now time.Time) []string {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	stalled := []string{}
+	for name, beat := range s.heartbeats {
+		if now.Sub(beat) > heartbeatTimeout {
+			stalled = append(stalled, name)
+		}
+	}
+	sort.Strings(stalled)
+	return stalled
+}
@@ -0,0 +1,27 @@
+package chat
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTMState(t *testing.T) {
+	s := &Slack{}
+	assert.Equal(t, RTMConnecting, s.RTMState())
+	s.setRTMState(RTMConnected)
+	assert.Equal(t, RTMConnected, s.RTMState())
+	s.setRTMState(RTMInvalidAuth)
+	assert.Equal(t, RTMInvalidAuth, s.RTMState())
+}
+
+func TestStalledLoops(t *testing.T) {
+	s := &Slack{}
+	assert.Equal(t, []string{}, s.StalledLoops(time.Now()))
+
+	s.Heartbeat("scheduler")
+	s.Heartbeat("notifier")
+	assert.Equal(t, []string{}, s.StalledLoops(time.Now()))
+	assert.Equal(t, []string{"notifier", "scheduler"}, s.StalledLoops(time.Now().Add(5*time.Minute)))
+}
@@ -0,0 +1,176 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// StartInterview begins to collect standup from channel member in direct messages.
+// Questions are asked one by one and only one interview is held with a member at a time,
+// the rest wait in storage until the current one is finished
+func (s *Slack) StartInterview(channelID, userID string) {
+	if s.DB.SubmittedStandupToday(userID, channelID) {
+		return
+	}
+	interviews, err := s.DB.ListUserInterviews(userID)
+	if err != nil {
+		logrus.Errorf("ListUserInterviews failed: %v", err)
+		return
+	}
+	for _, interview := range interviews {
+		if interview.ChannelID == channelID {
+			return
+		}
+	}
+	interview, err := s.DB.CreateInterview(model.Interview{
+		ChannelID: channelID,
+		UserID:    userID,
+	})
+	if err != nil {
+		logrus.Errorf("CreateInterview failed: %v", err)
+		return
+	}
+	logrus.Infof("Interview created #id:%v\n", interview.ID)
+	if len(interviews) == 0 {
+		s.askQuestion(interview)
+	}
+}
+
+// CloseInterviews drops interviews which were not finished during the day
+func (s *Slack) CloseInterviews() {
+	interviews, err := s.DB.ListInterviews()
+	if err != nil {
+		logrus.Errorf("ListInterviews failed: %v", err)
+		return
+	}
+	for _, interview := range interviews {
+		err := s.DB.DeleteInterview(interview.ID)
+		if err != nil {
+			logrus.Errorf("DeleteInterview failed: %v", err)
+		}
+	}
+}
+
+func (s *Slack) handleInterviewAnswer(msg *slack.MessageEvent) {
+	interviews, err := s.DB.ListUserInterviews(msg.User)
+	if err != nil || len(interviews) == 0 {
+		return
+	}
+	interview := interviews[0]
+	err = interview.AddAnswer(msg.Msg.Text)
+	if err != nil {
+		logrus.Errorf("AddAnswer failed: %v", err)
+		return
+	}
+	if interview.Step < len(s.interviewQuestions(s.Config().Translate)) {
+		interview, err = s.DB.UpdateInterview(interview)
+		if err != nil {
+			logrus.Errorf("UpdateInterview failed: %v", err)
+			return
+		}
+		s.askQuestion(interview)
+		return
+	}
+	s.finishInterview(interview, msg.Msg.Timestamp)
+	if len(interviews) > 1 {
+		s.askQuestion(interviews[1])
+	}
+}
+
+func (s *Slack) askQuestion(interview model.Interview) {
+	t := s.Translation(interview.ChannelID, interview.UserID)
+	questions := s.interviewQuestions(t)
+	if interview.Step >= len(questions) {
+		return
+	}
+	text := questions[interview.Step]
+	if interview.Step == 0 {
+		channelName, err := s.DB.GetChannelName(interview.ChannelID)
+		if err != nil {
+			logrus.Errorf("GetChannelName failed: %v", err)
+		}
+		text = fmt.Sprintf(t.InterviewIntro, interview.UserID, interview.ChannelID, channelName) + text
+	}
+	err := s.SendUserMessage(interview.UserID, text)
+	if err != nil {
+		logrus.Errorf("SendUserMessage failed: %v", err)
+	}
+}
+
+func (s *Slack) finishInterview(interview model.Interview, messageTS string) {
+	defer func() {
+		err := s.DB.DeleteInterview(interview.ID)
+		if err != nil {
+			logrus.Errorf("DeleteInterview failed: %v", err)
+		}
+	}()
+
+	t := s.Translation(interview.ChannelID, interview.UserID)
+	if s.DB.SubmittedStandupToday(interview.UserID, interview.ChannelID) {
+		s.SendUserMessage(interview.UserID, t.StandupHandleOneDayOneStandup)
+		return
+	}
+	answers, err := interview.ListAnswers()
+	if err != nil {
+		logrus.Errorf("ListAnswers failed: %v", err)
+		return
+	}
+	// standup is posted to the channel, so it is compiled in language of the channel
+	channelTranslate := s.Translation(interview.ChannelID, "")
+	comment := s.compileStandup(channelTranslate, answers)
+	standup, err := s.DB.CreateStandup(model.Standup{
+		ChannelID: interview.ChannelID,
+		UserID:    interview.UserID,
+		Comment:   comment,
+		MessageTS: messageTS,
+	})
+	if err != nil {
+		logrus.Errorf("CreateStandup from interview failed: %v", err)
+		errorReportToManager := fmt.Sprintf("I could not save standup collected in direct messages for user %s in channel %s because of the following reasons: %v", interview.UserID, interview.ChannelID, err)
+		s.SendUserMessage(s.Config().ManagerSlackUserID, errorReportToManager)
+		s.SendUserMessage(interview.UserID, t.StandupHandleCouldNotSaveStandup)
+		return
+	}
+	logrus.Infof("Standup created from interview #id:%v\n", standup.ID)
+	standupsSaved.Inc("interview")
+	s.EmitEvent(model.EventStandupCreated, standup.ChannelID, standup)
+	if len(answers) > 2 {
+		s.escalateBlocker(standup, normalizeBlocker(answers[2]), "")
+	}
+
+	s.SendStandupSummary(interview.ChannelID, fmt.Sprintf(channelTranslate.InterviewSummary, interview.UserID, comment))
+	channelName, err := s.DB.GetChannelName(interview.ChannelID)
+	if err != nil {
+		logrus.Errorf("GetChannelName failed: %v", err)
+	}
+	s.SendUserMessage(interview.UserID, fmt.Sprintf(t.InterviewFinished, interview.ChannelID, channelName))
+}
+
+func (s *Slack) compileStandup(t config.Translate, answers []string) string {
+	headings := []string{
+		t.InterviewHeadingYesterday,
+		t.InterviewHeadingToday,
+		t.InterviewHeadingProblems,
+	}
+	text := ""
+	for i, answer := range answers {
+		if i >= len(headings) {
+			break
+		}
+		text += fmt.Sprintf("*%v*: %v\n", headings[i], strings.TrimSpace(answer))
+	}
+	return strings.TrimSpace(text)
+}
+
+func (s *Slack) interviewQuestions(t config.Translate) []string {
+	return []string{
+		t.InterviewQuestionYesterday,
+		t.InterviewQuestionToday,
+		t.InterviewQuestionProblems,
+	}
+}
@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestCompileStandup(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", s.compileStandup(s.Conf.Translate, []string{}))
+	assert.Equal(t, "*Yesterday*: fixed bugs\n*Today*: write tests\n*Problems*: none", s.compileStandup(s.Conf.Translate, []string{"fixed bugs", " write tests ", "none", "extra"}))
+
+	rules := model.StandupRules{}
+	assert.NoError(t, rules.SetSections(defaultStandupSections))
+	ok, _ := s.validateStandup(rules, s.compileStandup(s.Conf.Translate, []string{"fixed bugs", "write tests", "none"}))
+	assert.Equal(t, true, ok)
+}
+
+func TestInterview(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/im.open", httpmock.NewStringResponder(200, `{"ok": true, "channel": {"id": "DIRECT"}}`))
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.postMessage", httpmock.NewStringResponder(200, `{"ok": true}`))
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	channel, err := s.DB.CreateChannel(model.Channel{
+		ChannelName: "interviews",
+		ChannelID:   "INTERVIEWCHAN",
+	})
+	assert.NoError(t, err)
+
+	s.StartInterview(channel.ChannelID, "userID1")
+	s.StartInterview(channel.ChannelID, "userID1")
+	interviews, err := s.DB.ListUserInterviews("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(interviews))
+
+	answers := []struct {
+		text      string
+		timestamp string
+	}{
+		{"fixed bugs", "1000"},
+		{"write tests", "1001"},
+		{"none", "1002"},
+	}
+	for _, answer := range answers {
+		msg := &slack.MessageEvent{}
+		msg.Channel = "DIRECT"
+		msg.User = "userID1"
+		msg.Text = answer.text
+		msg.Timestamp = answer.timestamp
+		s.handleMessage(msg, "<@BOTID>")
+	}
+
+	interviews, err = s.DB.ListUserInterviews("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(interviews))
+
+	standup, err := s.DB.SelectStandupByMessageTS("1002")
+	assert.NoError(t, err)
+	assert.Equal(t, channel.ChannelID, standup.ChannelID)
+	assert.Equal(t, "*Yesterday*: fixed bugs\n*Today*: write tests\n*Problems*: none", standup.Comment)
+
+	assert.NoError(t, s.DB.DeleteStandup(standup.ID))
+	assert.NoError(t, s.DB.DeleteChannel(channel.ID))
+}
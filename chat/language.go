@@ -0,0 +1,36 @@
+package chat
+
+import (
+	"github.com/maddevsio/comedian/config"
+)
+
+// Language returns language messages for the user in the channel are written in:
+// language of the user if set, otherwise language of the channel set by command or in the config file.
+// Empty string means default language
+func (s *Slack) Language(channelID, userID string) string {
+	if s.DB == nil {
+		return ""
+	}
+	if userID != "" {
+		user, err := s.DB.SelectUser(userID)
+		if err == nil && user.Language != "" {
+			return user.Language
+		}
+	}
+	if channelID != "" {
+		channel, err := s.DB.SelectChannel(channelID)
+		if err == nil && channel.Language != "" {
+			return channel.Language
+		}
+		if cc, ok := s.Config().ChannelOverride(channelID, channel.ChannelName); ok {
+			return cc.Language
+		}
+	}
+	return ""
+}
+
+// Translation returns translation for messages addressed to the user in the channel.
+// Either of them can be empty, e.g. for messages posted to the whole channel
+func (s *Slack) Translation(channelID, userID string) config.Translate {
+	return s.Config().Translation(s.Language(channelID, userID))
+}
@@ -0,0 +1,214 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// MemberDiff describes changes which bring members of a channel in line with a user group
+type MemberDiff struct {
+	Add    []string
+	Remove []string
+	// NotInChannel are members of the group who have not joined the channel yet,
+	// they are added once they join
+	NotInChannel []string
+
+	groupSize  int
+	developers int
+}
+
+// maxReconcileRemovedShare is the share of developers the hourly reconcile may remove at once,
+// more likely means the user group or the users list is broken than that the team has changed
+const maxReconcileRemovedShare = 0.5
+
+// Empty reports whether diff changes nothing
+func (d MemberDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Remove) == 0
+}
+
+// checkReconcile tells why diff should not be applied without a human looking at it:
+// user group resolved to nobody or most of the developers would be removed
+func (d MemberDiff) checkReconcile() error {
+	if d.groupSize == 0 {
+		return errors.New("user group has no members")
+	}
+	if len(d.Remove) > 1 && float64(len(d.Remove)) > maxReconcileRemovedShare*float64(d.developers) {
+		return fmt.Errorf("%v of %v developers would be removed", len(d.Remove), d.developers)
+	}
+	return nil
+}
+
+// IsUserGroupMention reports whether text mentions user group, like <!subteam^S123|@team>
+func IsUserGroupMention(text string) bool {
+	return strings.HasPrefix(text, "<!subteam^")
+}
+
+// UserGroup finds user group by mention like <!subteam^S123|@team>, or by its handle like @team
+func (s *Slack) UserGroup(mention string) (slack.UserGroup, error) {
+	id := ""
+	handle := strings.TrimPrefix(mention, "@")
+	if IsUserGroupMention(mention) {
+		parts := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(mention, "<!subteam^"), ">"), "|", 2)
+		id = parts[0]
+		handle = ""
+	}
+	groups, err := s.API.GetUserGroups()
+	if err != nil {
+		return slack.UserGroup{}, err
+	}
+	for _, group := range groups {
+		if group.ID == id || strings.EqualFold(group.Handle, handle) {
+			return group, nil
+		}
+	}
+	return slack.UserGroup{}, fmt.Errorf("user group %v not found", mention)
+}
+
+// UserGroupMembers returns human members of the user group
+func (s *Slack) UserGroupMembers(groupID string) ([]string, error) {
+	users, err := s.API.GetUserGroupMembers(groupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.humans(users), nil
+}
+
+// ChannelHumans returns human members of the channel
+func (s *Slack) ChannelHumans(channelID string) ([]string, error) {
+	users, err := s.listConversationMembers(channelID)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for user := range users {
+		ids = append(ids, user)
+	}
+	sort.Strings(ids)
+	return s.humans(ids), nil
+}
+
+// humans filters out bots. UpdateUsersList stores only humans, so users not stored are bots
+func (s *Slack) humans(userIDs []string) []string {
+	humans := []string{}
+	for _, userID := range userIDs {
+		if userID == s.botUserID() {
+			continue
+		}
+		if _, err := s.DB.SelectUser(userID); err != nil {
+			continue
+		}
+		humans = append(humans, userID)
+	}
+	return humans
+}
+
+// MemberSyncDiff compares members of the channel with members of the user group
+func (s *Slack) MemberSyncDiff(channelID, groupID string) (MemberDiff, error) {
+	group, err := s.UserGroupMembers(groupID)
+	if err != nil {
+		return MemberDiff{}, err
+	}
+	inChannel, err := s.listConversationMembers(channelID)
+	if err != nil {
+		return MemberDiff{}, err
+	}
+	members, err := s.DB.ListChannelMembers(channelID)
+	if err != nil {
+		return MemberDiff{}, err
+	}
+	return memberDiff(members, group, inChannel), nil
+}
+
+// memberDiff returns group members who are not channel members yet and developers who are not in the group.
+// PMs are never removed, they do not have to be in the group
+func memberDiff(members []model.ChannelMember, group []string, inChannel map[string]bool) MemberDiff {
+	diff := MemberDiff{Add: []string{}, Remove: []string{}, NotInChannel: []string{}, groupSize: len(group)}
+	isMember := map[string]bool{}
+	for _, member := range members {
+		isMember[member.UserID] = true
+		if member.RoleInChannel != "pm" {
+			diff.developers++
+		}
+	}
+	inGroup := map[string]bool{}
+	for _, userID := range group {
+		inGroup[userID] = true
+		switch {
+		case isMember[userID]:
+		case inChannel[userID]:
+			diff.Add = append(diff.Add, userID)
+		default:
+			diff.NotInChannel = append(diff.NotInChannel, userID)
+		}
+	}
+	for _, member := range members {
+		if !inGroup[member.UserID] && member.RoleInChannel != "pm" {
+			diff.Remove = append(diff.Remove, member.UserID)
+		}
+	}
+	sort.Strings(diff.Add)
+	sort.Strings(diff.Remove)
+	sort.Strings(diff.NotInChannel)
+	return diff
+}
+
+// ApplyMemberDiff adds and removes members of the channel
+func (s *Slack) ApplyMemberDiff(channelID string, diff MemberDiff) {
+	for _, userID := range diff.Add {
+		_, err := s.DB.CreateChannelMember(model.ChannelMember{
+			UserID:        userID,
+			ChannelID:     channelID,
+			RoleInChannel: "developer",
+		})
+		if err != nil {
+			logrus.Errorf("CreateChannelMember failed: %v", err)
+		}
+	}
+	for _, userID := range diff.Remove {
+		member, err := s.DB.FindChannelMemberByUserID(userID, channelID)
+		if err != nil {
+			continue
+		}
+		s.removeChannelMember(member)
+	}
+	if !diff.Empty() {
+		logrus.Infof("Members of channel %v synced: added %v, removed %v", channelID, diff.Add, diff.Remove)
+	}
+}
+
+// ReconcileMemberSyncs brings members of synced channels in line with their user groups
+func (s *Slack) ReconcileMemberSyncs() {
+	syncs, err := s.DB.ListMemberSyncs()
+	if err != nil {
+		logrus.Errorf("ListMemberSyncs failed: %v", err)
+		return
+	}
+	for _, sync := range syncs {
+		channel, err := s.DB.SelectChannel(sync.ChannelID)
+		if err != nil || channel.Archived {
+			continue
+		}
+		diff, err := s.MemberSyncDiff(sync.ChannelID, sync.UserGroupID)
+		if err != nil {
+			logrus.Errorf("MemberSyncDiff failed for channel %v: %v", sync.ChannelID, err)
+			continue
+		}
+		if err := diff.checkReconcile(); err != nil {
+			logrus.Errorf("Members of channel %v are not synced with @%v, run /sync_members to review: %v", sync.ChannelID, sync.UserGroupHandle, err)
+			continue
+		}
+		s.ApplyMemberDiff(sync.ChannelID, diff)
+		sync.LastSynced = time.Now()
+		_, err = s.DB.UpdateMemberSync(sync)
+		if err != nil {
+			logrus.Errorf("UpdateMemberSync failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestMemberDiff(t *testing.T) {
+	members := []model.ChannelMember{
+		{UserID: "U1", RoleInChannel: "developer"},
+		{UserID: "U2", RoleInChannel: "developer"},
+		{UserID: "U3", RoleInChannel: "pm"},
+	}
+	inChannel := map[string]bool{"U1": true, "U2": true, "U3": true, "U4": true}
+
+	diff := memberDiff(members, []string{"U1", "U4", "U5"}, inChannel)
+	assert.Equal(t, []string{"U4"}, diff.Add)
+	assert.Equal(t, []string{"U2"}, diff.Remove)
+	assert.Equal(t, []string{"U5"}, diff.NotInChannel)
+	assert.Equal(t, false, diff.Empty())
+
+	diff = memberDiff(members, []string{"U1", "U2"}, inChannel)
+	assert.Equal(t, true, diff.Empty())
+}
+
+func TestCheckReconcile(t *testing.T) {
+	members := []model.ChannelMember{
+		{UserID: "U1", RoleInChannel: "developer"},
+		{UserID: "U2", RoleInChannel: "developer"},
+		{UserID: "U3", RoleInChannel: "developer"},
+		{UserID: "U4", RoleInChannel: "developer"},
+		{UserID: "U5", RoleInChannel: "pm"},
+	}
+	inChannel := map[string]bool{"U1": true, "U2": true, "U3": true, "U4": true, "U5": true, "U6": true}
+
+	// one developer left the team
+	assert.NoError(t, memberDiff(members, []string{"U1", "U2", "U3"}, inChannel).checkReconcile())
+	// half of the team left
+	assert.NoError(t, memberDiff(members, []string{"U1", "U2"}, inChannel).checkReconcile())
+
+	// disabled group or stale users list resolves to nobody
+	diff := memberDiff(members, []string{}, inChannel)
+	assert.Equal(t, []string{"U1", "U2", "U3", "U4"}, diff.Remove)
+	assert.EqualError(t, diff.checkReconcile(), "user group has no members")
+
+	diff = memberDiff(members, []string{"U1", "U6"}, inChannel)
+	assert.EqualError(t, diff.checkReconcile(), "3 of 4 developers would be removed")
+}
+
+func TestUserGroup(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/usergroups.list", httpmock.NewStringResponder(200, `{"ok": true, "usergroups": [{"id": "S123", "handle": "frontend-team"}, {"id": "S456", "handle": "backend-team"}]}`))
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	group, err := s.UserGroup("<!subteam^S456|@backend-team>")
+	assert.NoError(t, err)
+	assert.Equal(t, "backend-team", group.Handle)
+
+	group, err = s.UserGroup("@Frontend-Team")
+	assert.NoError(t, err)
+	assert.Equal(t, "S123", group.ID)
+
+	_, err = s.UserGroup("@design-team")
+	assert.Error(t, err)
+
+	assert.Equal(t, true, IsUserGroupMention("<!subteam^S456|@backend-team>"))
+	assert.Equal(t, false, IsUserGroupMention("<@U123|user>"))
+}
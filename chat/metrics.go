@@ -0,0 +1,67 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/maddevsio/comedian/metrics"
+)
+
+// Reasons standups are rejected for
+const (
+	rejectMissingSection = "missing_section"
+	rejectTooShort       = "too_short"
+	rejectNoTicket       = "no_ticket"
+	rejectDuplicate      = "duplicate"
+)
+
+var (
+	standupsSaved    = metrics.NewCounter("comedian_standups_saved_total", "Standups saved, by how they were submitted.", "source")
+	standupsRejected = metrics.NewCounter("comedian_standups_rejected_total", "Standups rejected, by reason.", "reason")
+	messagesSent     = metrics.NewCounter("comedian_messages_sent_total", "Messages delivered to Slack, by kind: message, ephemeral or direct.", "kind")
+	slackRequests    = metrics.NewHistogram("comedian_slack_api_request_duration_seconds", "Duration of Slack Web API requests in seconds, by API method.", metrics.DefaultBuckets, "method")
+	slackErrors      = metrics.NewCounter("comedian_slack_api_errors_total", "Failed Slack Web API requests, by API method and kind of error: transport, ratelimited, http or api.", "method", "error")
+)
+
+// instrumentedClient measures latency and errors of Slack Web API requests made by slack package
+type instrumentedClient struct {
+	client *http.Client
+}
+
+func (c instrumentedClient) Do(req *http.Request) (*http.Response, error) {
+	method := path.Base(req.URL.Path)
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	slackRequests.ObserveSince(start, method)
+	if err != nil {
+		slackErrors.Inc(method, "transport")
+		return resp, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		slackErrors.Inc(method, "ratelimited")
+		return resp, nil
+	case resp.StatusCode >= 400:
+		slackErrors.Inc(method, "http")
+		return resp, nil
+	}
+	// Slack reports API errors with 200 status and "ok": false in the body
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		slackErrors.Inc(method, "transport")
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	var result struct {
+		Ok bool `json:"ok"`
+	}
+	if json.Unmarshal(body, &result) == nil && !result.Ok {
+		slackErrors.Inc(method, "api")
+	}
+	return resp, nil
+}
@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/chat.postMessage":
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+		case "/api/users.list":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+	client := instrumentedClient{&http.Client{}}
+
+	for _, method := range []string{"chat.postMessage", "users.list", "auth.test"} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/"+method, nil)
+		assert.NoError(t, err)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		if method == "chat.postMessage" {
+			assert.Equal(t, `{"ok":false,"error":"channel_not_found"}`, string(body))
+		}
+	}
+
+	var b strings.Builder
+	_, err := metrics.DefaultRegistry.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `comedian_slack_api_errors_total{method="chat.postMessage",error="api"} 1`)
+	assert.Contains(t, b.String(), `comedian_slack_api_errors_total{method="users.list",error="ratelimited"} 1`)
+	assert.NotContains(t, b.String(), `comedian_slack_api_errors_total{method="auth.test"`)
+	assert.Contains(t, b.String(), `comedian_slack_api_request_duration_seconds_count{method="auth.test"} 1`)
+}
+
+func TestCheckStandup(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	rules := model.StandupRules{ID: 1, ChannelID: "QWERTY123", MinLength: 40, TicketPattern: "[A-Z]+-[0-9]+"}
+	assert.NoError(t, rules.SetSections([]model.StandupSection{
+		{Name: "yesterday", Keywords: []string{"yesterday"}},
+		{Name: "today", Keywords: []string{"today"}},
+	}))
+	testCases := []struct {
+		input  string
+		reason string
+	}{
+		{"Gestern habe ich COM-12 gemacht", rejectMissingSection},
+		{"yesterday COM-1, today, problems", rejectTooShort},
+		{"yesterday I did a lot, today I do even more, no problems", rejectNoTicket},
+		{"yesterday I did COM-12, today I do COM-13, no problems", ""},
+	}
+	for _, tt := range testCases {
+		reason, _ := s.checkStandup(s.Conf.Translate, rules, tt.input)
+		assert.Equal(t, tt.reason, reason, tt.input)
+	}
+}
@@ -0,0 +1,248 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds and statuses of outbound messages
+const (
+	outboundMessage   = "message"
+	outboundEphemeral = "ephemeral"
+	outboundDirect    = "direct"
+
+	outboundPending = "pending"
+	outboundSent    = "sent"
+	outboundFailed  = "failed"
+)
+
+// maxDeliveryAttempts is the number of attempts after which queued message is marked as failed
+const maxDeliveryAttempts = 8
+
+// maxErrorLength is the number of characters last_error columns keep
+const maxErrorLength = 255
+
+// transientSlackErrors are Slack API errors which are worth retrying
+var transientSlackErrors = map[string]bool{
+	"internal_error":      true,
+	"fatal_error":         true,
+	"service_unavailable": true,
+	"request_timeout":     true,
+	"ratelimited":         true,
+}
+
+// send delivers message to Slack right away. If Slack rate limits the bot or fails temporarily,
+// message is persisted and delivered later by DeliverQueuedMessages
+func (s *Slack) send(m model.OutboundMessage) error {
+	if until := s.rateLimitedUntil(); time.Now().Before(until) {
+		return s.enqueue(m, until, "rate limited")
+	}
+	err := s.deliver(m)
+	if err == nil {
+		return nil
+	}
+	if rle, ok := err.(*slack.RateLimitedError); ok {
+		until := time.Now().Add(rle.RetryAfter)
+		s.setRateLimitedUntil(until)
+		return s.enqueue(m, until, err.Error())
+	}
+	if isTransient(err) {
+		return s.enqueue(m, time.Now().Add(retryDelay(0)), err.Error())
+	}
+	s.saveFailed(m, err.Error())
+	return err
+}
+
+// enqueue persists message to be delivered later. Ephemeral messages answer what user has just done,
+// delivered late they only confuse, so they are not queued
+func (s *Slack) enqueue(m model.OutboundMessage, nextAttempt time.Time, reason string) error {
+	if m.Kind == outboundEphemeral {
+		s.saveFailed(m, reason)
+		return errors.New(reason)
+	}
+	m.Status = outboundPending
+	m.Attempts = 1
+	m.LastError = reason
+	m.NextAttempt = nextAttempt
+	m, err := s.createOutboundMessage(m)
+	if err != nil {
+		logrus.Errorf("CreateOutboundMessage failed: %v", err)
+		return err
+	}
+	logrus.Infof("Message #id:%v queued till %v: %v", m.ID, nextAttempt, reason)
+	return nil
+}
+
+// saveFailed keeps message which could not be delivered, so that delivery status shows it
+func (s *Slack) saveFailed(m model.OutboundMessage, reason string) {
+	m.Status = outboundFailed
+	m.Attempts = 1
+	m.LastError = reason
+	m.NextAttempt = time.Now()
+	if _, err := s.createOutboundMessage(m); err != nil {
+		logrus.Errorf("CreateOutboundMessage failed: %v", err)
+	}
+}
+
+func (s *Slack) createOutboundMessage(m model.OutboundMessage) (model.OutboundMessage, error) {
+	m.LastError = truncate(m.LastError, maxErrorLength)
+	return s.DB.CreateOutboundMessage(m)
+}
+
+func (s *Slack) updateOutboundMessage(m model.OutboundMessage) {
+	m.LastError = truncate(m.LastError, maxErrorLength)
+	_, err := s.DB.UpdateOutboundMessage(m)
+	if err != nil {
+		logrus.Errorf("UpdateOutboundMessage failed: %v", err)
+	}
+}
+
+func (s *Slack) deliver(m model.OutboundMessage) error {
+	err := s.post(m)
+	if err == nil {
+		messagesSent.Inc(m.Kind)
+	}
+	return err
+}
+
+func (s *Slack) post(m model.OutboundMessage) error {
+	switch m.Kind {
+	case outboundEphemeral:
+		_, err := s.API.PostEphemeral(m.ChannelID, m.UserID, slack.MsgOptionText(m.Text, true))
+		return err
+	case outboundDirect:
+		_, _, channelID, err := s.API.OpenIMChannel(m.UserID)
+		if err != nil {
+			return err
+		}
+		m.ChannelID = channelID
+	}
+	params := slack.PostMessageParameters{ThreadTimestamp: m.ThreadTS}
+	if m.Attachments != "" {
+		err := json.Unmarshal([]byte(m.Attachments), &params.Attachments)
+		if err != nil {
+			return err
+		}
+	}
+	_, _, err := s.API.PostMessage(m.ChannelID, m.Text, params)
+	return err
+}
+
+// DeliverQueuedMessages retries delivery of queued messages which are due
+func (s *Slack) DeliverQueuedMessages() {
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+
+	messages, err := s.DB.ListDueOutboundMessages(time.Now(), 50)
+	if err != nil {
+		logrus.Errorf("ListDueOutboundMessages failed: %v", err)
+		return
+	}
+	for _, m := range messages {
+		if time.Now().Before(s.rateLimitedUntil()) {
+			return
+		}
+		if m.Kind == outboundEphemeral {
+			// queued before ephemeral messages stopped being retried
+			m.Status = outboundFailed
+			m.LastError = "ephemeral message expired"
+			s.updateOutboundMessage(m)
+			continue
+		}
+		err := s.deliver(m)
+		m.Attempts++
+		switch {
+		case err == nil:
+			m.Status = outboundSent
+			m.LastError = ""
+		case isRateLimited(err):
+			rle := err.(*slack.RateLimitedError)
+			s.setRateLimitedUntil(time.Now().Add(rle.RetryAfter))
+			m.NextAttempt = time.Now().Add(rle.RetryAfter)
+			m.LastError = err.Error()
+		case isTransient(err) && m.Attempts < maxDeliveryAttempts:
+			m.NextAttempt = time.Now().Add(retryDelay(m.Attempts))
+			m.LastError = err.Error()
+		default:
+			m.Status = outboundFailed
+			m.LastError = err.Error()
+		}
+		if m.Status == outboundFailed {
+			logrus.Errorf("Message #id:%v delivery failed after %v attempts: %v", m.ID, m.Attempts, err)
+		}
+		s.updateOutboundMessage(m)
+	}
+}
+
+// CleanOutboundMessages removes delivered and failed messages older than a week
+func (s *Slack) CleanOutboundMessages() {
+	err := s.DB.DeleteOutboundMessages(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		logrus.Errorf("DeleteOutboundMessages failed: %v", err)
+	}
+}
+
+func (s *Slack) runQueue() {
+	for range time.Tick(10 * time.Second) {
+		s.DeliverQueuedMessages()
+	}
+}
+
+func (s *Slack) rateLimitedUntil() time.Time {
+	s.rateMutex.Lock()
+	defer s.rateMutex.Unlock()
+	return s.pausedUntil
+}
+
+func (s *Slack) setRateLimitedUntil(t time.Time) {
+	s.rateMutex.Lock()
+	defer s.rateMutex.Unlock()
+	if t.After(s.pausedUntil) {
+		s.pausedUntil = t
+	}
+}
+
+func isRateLimited(err error) bool {
+	_, ok := err.(*slack.RateLimitedError)
+	return ok
+}
+
+func isTransient(err error) bool {
+	if isRateLimited(err) {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if sce, ok := err.(interface{ HTTPStatusCode() int }); ok {
+		return sce.HTTPStatusCode() >= 500
+	}
+	return transientSlackErrors[err.Error()]
+}
+
+// truncate cuts text to max characters, multi-byte characters are never split
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
+
+// retryDelay returns exponential delay before the next delivery attempt, capped at one hour
+func retryDelay(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 0; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
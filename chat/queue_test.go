@@ -0,0 +1,79 @@
+package chat
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(0))
+	assert.Equal(t, 2*time.Minute, retryDelay(2))
+	assert.Equal(t, time.Hour, retryDelay(20))
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.Equal(t, true, isTransient(&slack.RateLimitedError{RetryAfter: time.Second}))
+	assert.Equal(t, true, isTransient(errors.New("internal_error")))
+	assert.Equal(t, false, isTransient(errors.New("channel_not_found")))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "ошиб", truncate("ошибка", 4))
+	long := strings.Repeat("я", 300)
+	assert.Equal(t, 255, utf8.RuneCountInString(truncate(long, maxErrorLength)))
+	assert.Equal(t, true, utf8.ValidString(truncate("a"+long, maxErrorLength)))
+}
+
+func TestDeliveryQueue(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.postMessage", func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusTooManyRequests, `{"ok": false, "error": "ratelimited"}`)
+		resp.Header.Set("Retry-After", "1")
+		return resp, nil
+	})
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SendMessage("QUEUECHAN", "Hey!", nil))
+	assert.NoError(t, s.SendMessage("QUEUECHAN", "Hey again!", nil))
+	// ephemeral messages are not retried
+	assert.Error(t, s.SendEphemeralMessage("QUEUECHAN", "QUEUEUSER", "Standup accepted"))
+
+	pending, err := s.DB.ListOutboundMessagesByStatus(outboundPending, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pending))
+
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.postMessage", httpmock.NewStringResponder(200, `{"ok": true}`))
+	time.Sleep(1100 * time.Millisecond)
+	s.DeliverQueuedMessages()
+
+	pending, err = s.DB.ListOutboundMessagesByStatus(outboundPending, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(pending))
+	sent, err := s.DB.ListOutboundMessagesByStatus(outboundSent, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, 2, sent[0].Attempts)
+	}
+	failed, err := s.DB.ListOutboundMessagesByStatus(outboundFailed, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, outboundEphemeral, failed[0].Kind)
+	}
+
+	assert.NoError(t, s.DB.DeleteOutboundMessages(time.Now().Add(time.Minute)))
+}
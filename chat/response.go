@@ -0,0 +1,150 @@
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxMessageLength is the longest message text Slack shows without truncating it
+	maxMessageLength = 3900
+	// maxResponseParts is the number of messages a slash command may post to its response_url
+	maxResponseParts = 5
+)
+
+// responseClient posts command results to response_url, a stalled response_url falls back to direct messages
+var responseClient = &http.Client{Timeout: 10 * time.Second}
+
+// SendResponse posts result of a slash command to its response_url. If response_url is not known
+// or does not accept the result, it is sent to the user in direct messages. Long text is split
+// into several messages and text which needs too many messages is uploaded as a snippet
+func (s *Slack) SendResponse(responseURL, userID, text string) error {
+	parts := splitMessage(text, maxMessageLength)
+	if len(parts) > maxResponseParts {
+		err := s.UploadSnippet(userID, "report.txt", "text", text)
+		if err == nil {
+			return s.sendResponseParts(responseURL, userID, []string{s.Translation("", userID).ResponseUploaded})
+		}
+		logrus.Errorf("slack: UploadSnippet failed: %v\n", err)
+	}
+	return s.sendResponseParts(responseURL, userID, parts)
+}
+
+// sendEphemeralResponse posts command result in the channel visible only for the user
+func (s *Slack) sendEphemeralResponse(channelID, userID, text string) error {
+	parts := splitMessage(text, maxMessageLength)
+	if len(parts) > maxResponseParts {
+		err := s.UploadSnippet(userID, "report.txt", "text", text)
+		if err == nil {
+			return s.SendEphemeralMessage(channelID, userID, s.Translation(channelID, userID).ResponseUploaded)
+		}
+		logrus.Errorf("slack: UploadSnippet failed: %v\n", err)
+	}
+	for _, part := range parts {
+		err := s.SendEphemeralMessage(channelID, userID, part)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Slack) sendResponseParts(responseURL, userID string, parts []string) error {
+	for i, part := range parts {
+		if responseURL == "" {
+			return s.sendUserMessages(userID, parts[i:])
+		}
+		err := postResponse(responseURL, part)
+		if err != nil {
+			logrus.Errorf("slack: postResponse failed: %v\n", err)
+			return s.sendUserMessages(userID, parts[i:])
+		}
+	}
+	return nil
+}
+
+func (s *Slack) sendUserMessages(userID string, parts []string) error {
+	for _, part := range parts {
+		err := s.SendUserMessage(userID, part)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UploadSnippet uploads content as a file to direct messages with the user
+func (s *Slack) UploadSnippet(userID, filename, filetype, content string) error {
+	_, _, channelID, err := s.API.OpenIMChannel(userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.API.UploadFile(slack.FileUploadParameters{
+		Content:  content,
+		Filetype: filetype,
+		Filename: filename,
+		Title:    filename,
+		Channels: []string{channelID},
+	})
+	return err
+}
+
+// postResponse posts ephemeral message to response_url of a slash command
+func postResponse(responseURL, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"response_type": "ephemeral",
+		"text":          text,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := responseClient.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response_url responded with %v", resp.Status)
+	}
+	return nil
+}
+
+// splitMessage splits text into parts not longer than limit, preferably at line breaks
+func splitMessage(text string, limit int) []string {
+	if len(text) <= limit {
+		return []string{text}
+	}
+	parts := []string{}
+	part := ""
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if len(part)+len(line) <= limit {
+			part += line
+			continue
+		}
+		if part != "" {
+			parts = append(parts, part)
+			part = ""
+		}
+		for len(line) > limit {
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			parts = append(parts, line[:cut])
+			line = line[cut:]
+		}
+		part = line
+	}
+	if part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/fakeslack"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitMessage(t *testing.T) {
+	assert.Equal(t, []string{"short"}, splitMessage("short", 10))
+	assert.Equal(t, []string{"line one\n", "line two\n", "three"}, splitMessage("line one\nline two\nthree", 10))
+	assert.Equal(t, []string{"a\nb\n", "c"}, splitMessage("a\nb\nc", 4))
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, splitMessage("abcdefghij", 4))
+	assert.Equal(t, []string{"пр", "ив", "ет"}, splitMessage("привет", 5))
+}
+
+func TestSendResponse(t *testing.T) {
+	server := fakeslack.NewServer("UBOT", "comedian")
+	defer server.Close()
+	slack.SLACK_API = server.APIURL()
+	defer func() { slack.SLACK_API = "https://slack.com/api/" }()
+
+	s := &Slack{API: slack.New("xoxb-fake"), Conf: config.Config{}}
+	s.Conf.Translate.ResponseUploaded = "uploaded"
+
+	assert.NoError(t, s.SendResponse(server.ResponseURL(), "UDEV", "report"))
+	messages := server.Messages()
+	assert.Equal(t, 1, len(messages))
+	assert.Equal(t, "response_url", messages[0].Method)
+	assert.Equal(t, "report", messages[0].Text)
+
+	long := strings.Repeat(strings.Repeat("x", 100)+"\n", 50)
+	assert.NoError(t, s.SendResponse(server.ResponseURL(), "UDEV", long))
+	messages = server.Messages()
+	assert.Equal(t, 3, len(messages))
+	assert.Equal(t, long, messages[1].Text+messages[2].Text)
+
+	huge := strings.Repeat(long, 5)
+	assert.NoError(t, s.SendResponse(server.ResponseURL(), "UDEV", huge))
+	files := server.Files()
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, huge, files[0].Content)
+	assert.Equal(t, []string{"DUDEV"}, files[0].Channels)
+	messages = server.Messages()
+	assert.Equal(t, "uploaded", messages[len(messages)-1].Text)
+
+	assert.NoError(t, s.SendResponse("", "UDEV", "direct"))
+	messages = server.Messages()
+	assert.Equal(t, "chat.postMessage", messages[len(messages)-1].Method)
+	assert.Equal(t, "DUDEV", messages[len(messages)-1].Channel)
+}
@@ -0,0 +1,93 @@
+package chat
+
+import (
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// ChannelSettings are settings of a channel. Settings set with /channel_settings come first,
+// then overrides of the config file, then global config
+type ChannelSettings struct {
+	ChannelID          string
+	ReminderRepeatsMax int
+	NotifierInterval   int
+	ReminderTime       int64
+	ReportTime         string
+	ReportRecipients   []string
+	// Language is empty when channel speaks the default language
+	Language   string
+	Validation string
+	// Set lists settings the channel has, the rest are inherited
+	Set map[string]bool
+}
+
+// ChannelReport reports whether the channel gets its own daily report. Only channels which set
+// report time or recipients do, others are covered by the team report
+func (settings ChannelSettings) ChannelReport() bool {
+	return settings.Set[model.SettingReportTime] || settings.Set[model.SettingReportRecipients]
+}
+
+// ChannelSettings returns current settings of the channel
+func (s *Slack) ChannelSettings(channelID string) ChannelSettings {
+	channel := model.Channel{ChannelID: channelID}
+	stored := []model.ChannelSetting{}
+	if s.DB != nil {
+		if ch, err := s.DB.SelectChannel(channelID); err == nil {
+			channel = ch
+		}
+		var err error
+		stored, err = s.DB.ListChannelSettings(channelID)
+		if err != nil {
+			logrus.Errorf("slack: ListChannelSettings failed for %v: %v\n", channelID, err)
+		}
+	}
+	return s.channelSettings(channel, stored)
+}
+
+// channelSettings applies stored settings of the channel on top of the config
+func (s *Slack) channelSettings(channel model.Channel, stored []model.ChannelSetting) ChannelSettings {
+	conf := s.Config()
+	channelConf := conf.ForChannel(channel.ChannelID, channel.ChannelName)
+	settings := ChannelSettings{
+		ChannelID:          channel.ChannelID,
+		ReminderRepeatsMax: channelConf.ReminderRepeatsMax,
+		NotifierInterval:   channelConf.NotifierInterval,
+		ReminderTime:       channelConf.ReminderTime,
+		ReportTime:         conf.ReportTime,
+		ReportRecipients:   []string{channel.ChannelID},
+		Language:           channel.Language,
+		Validation:         model.ValidationStrict,
+		Set:                map[string]bool{},
+	}
+	settings.Set[model.SettingLanguage] = channel.Language != ""
+	if settings.Language == "" {
+		if cc, ok := conf.ChannelOverride(channel.ChannelID, channel.ChannelName); ok {
+			settings.Language = cc.Language
+		}
+	}
+
+	for _, setting := range stored {
+		// settings are validated when they are set, this only protects from broken rows
+		if err := setting.Validate(); err != nil {
+			logrus.Errorf("slack: setting of channel %v is broken: %v\n", channel.ChannelID, err)
+			continue
+		}
+		n, _ := setting.Int()
+		switch setting.Name {
+		case model.SettingMaxReminders:
+			settings.ReminderRepeatsMax = n
+		case model.SettingReminderInterval:
+			settings.NotifierInterval = n
+		case model.SettingWarningTime:
+			settings.ReminderTime = int64(n)
+		case model.SettingReportTime:
+			settings.ReportTime = setting.Value
+		case model.SettingReportRecipients:
+			settings.ReportRecipients = setting.List()
+		case model.SettingValidation:
+			settings.Validation = setting.Value
+		}
+		settings.Set[setting.Name] = true
+	}
+	return settings
+}
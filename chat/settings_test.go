@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelSettings(t *testing.T) {
+	warning := int64(20)
+	s := &Slack{Conf: config.Config{
+		ReminderRepeatsMax: 3,
+		NotifierInterval:   30,
+		ReminderTime:       10,
+		ReportTime:         "10:00",
+		Channels: map[string]config.ChannelConfig{
+			"general": {ReminderTime: &warning, Language: "ru"},
+		},
+	}}
+	channel := model.Channel{ChannelID: "CBAPFA2J2", ChannelName: "general"}
+
+	settings := s.channelSettings(channel, nil)
+	assert.Equal(t, 3, settings.ReminderRepeatsMax)
+	assert.Equal(t, 30, settings.NotifierInterval)
+	assert.Equal(t, int64(20), settings.ReminderTime)
+	assert.Equal(t, "10:00", settings.ReportTime)
+	assert.Equal(t, []string{"CBAPFA2J2"}, settings.ReportRecipients)
+	assert.Equal(t, "ru", settings.Language)
+	assert.Equal(t, model.ValidationStrict, settings.Validation)
+	assert.Empty(t, settings.Set[model.SettingWarningTime])
+	assert.Empty(t, settings.Set[model.SettingLanguage])
+	assert.False(t, settings.ChannelReport())
+
+	channel.Language = "en"
+	settings = s.channelSettings(channel, []model.ChannelSetting{
+		{ChannelID: "CBAPFA2J2", Name: model.SettingWarningTime, Value: "5"},
+		{ChannelID: "CBAPFA2J2", Name: model.SettingMaxReminders, Value: "-1"},
+		{ChannelID: "CBAPFA2J2", Name: model.SettingReportTime, Value: "18:30"},
+		{ChannelID: "CBAPFA2J2", Name: model.SettingReportRecipients, Value: "CBAPFA2J2,UB9AE7CL9"},
+		{ChannelID: "CBAPFA2J2", Name: model.SettingValidation, Value: model.ValidationWarn},
+	})
+	assert.Equal(t, int64(5), settings.ReminderTime)
+	// broken rows are ignored
+	assert.Equal(t, 3, settings.ReminderRepeatsMax)
+	assert.False(t, settings.Set[model.SettingMaxReminders])
+	assert.Equal(t, "18:30", settings.ReportTime)
+	assert.Equal(t, []string{"CBAPFA2J2", "UB9AE7CL9"}, settings.ReportRecipients)
+	assert.Equal(t, "en", settings.Language)
+	assert.Equal(t, model.ValidationWarn, settings.Validation)
+	assert.True(t, settings.Set[model.SettingWarningTime])
+	assert.True(t, settings.Set[model.SettingLanguage])
+	assert.True(t, settings.ChannelReport())
+
+	settings = s.channelSettings(channel, []model.ChannelSetting{
+		{ChannelID: "CBAPFA2J2", Name: model.SettingReportRecipients, Value: "UB9AE7CL9"},
+	})
+	assert.Equal(t, "10:00", settings.ReportTime)
+	assert.True(t, settings.ChannelReport())
+}
@@ -1,13 +1,18 @@
 package chat
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"regexp"
 	"strconv"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jasonlvhit/gocron"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
 	"github.com/maddevsio/comedian/model"
 	"github.com/maddevsio/comedian/storage"
 	"github.com/nlopes/slack"
@@ -22,6 +27,13 @@ var (
 	typeDeleteMessage = "message_deleted"
 )
 
+// defaultStandupSections are used to validate standups in channels without their own rules
+var defaultStandupSections = []model.StandupSection{
+	{Name: "problems", Keywords: []string{"problem", "difficult", "stuck", "question", "issue", "block", "проблем", "трудност", "затрдуднени", "вопрос"}},
+	{Name: "yesterday", Keywords: []string{"yesterday", "friday", "completed", "вчера", "пятниц", "делал", "сделано"}},
+	{Name: "today", Keywords: []string{"today", "going", "plan", "сегодня", "собираюсь", "план"}},
+}
+
 // Slack struct used for storing and communicating with slack api
 type Slack struct {
 	API  *slack.Client
@@ -29,6 +41,22 @@ type Slack struct {
 	WG   sync.WaitGroup
 	DB   *storage.MySQL
 	Conf config.Config
+
+	confMutex   sync.RWMutex
+	confVersion int
+
+	// Commands runs management commands sent to the bot in messages
+	Commands CommandRunner
+
+	queueMutex   sync.Mutex
+	rateMutex    sync.Mutex
+	pausedUntil  time.Time
+	webhookMutex sync.Mutex
+	webhookWake  chan struct{}
+
+	healthMutex sync.Mutex
+	rtmState    string
+	heartbeats  map[string]time.Time
 }
 
 // NewSlack creates a new copy of slack handler
@@ -41,9 +69,11 @@ func NewSlack(conf config.Config) (*Slack, error) {
 
 	s := &Slack{}
 	s.Conf = conf
+	slack.SetHTTPClient(instrumentedClient{&http.Client{}})
 	s.API = slack.New(conf.SlackToken)
 	s.RTM = s.API.NewRTM()
 	s.DB = db
+	s.webhookWake = make(chan struct{}, 1)
 	return s, nil
 }
 
@@ -51,11 +81,21 @@ func NewSlack(conf config.Config) (*Slack, error) {
 func (s *Slack) Run() {
 
 	s.UpdateUsersList()
-	s.SendUserMessage(s.Conf.ManagerSlackUserID, s.Conf.Translate.HelloManager)
+	s.UpdateChannelsList()
+	s.SendUserMessage(s.Config().ManagerSlackUserID, s.Translation("", s.Config().ManagerSlackUserID).HelloManager)
 
-	gocron.Every(1).Day().At("23:50").Do(s.FillStandupsForNonReporters)
-	gocron.Every(1).Day().At("23:55").Do(s.UpdateUsersList)
+	gocron.Every(1).Day().At("23:45").Do(metrics.Job("close_interviews", s.CloseInterviews))
+	gocron.Every(1).Day().At("23:50").Do(metrics.Job("fill_standups", s.FillStandupsForNonReporters))
+	gocron.Every(1).Day().At("23:55").Do(metrics.Job("update_users", s.UpdateUsersList))
+	gocron.Every(1).Day().At("23:57").Do(metrics.Job("update_channels", s.UpdateChannelsList))
+	gocron.Every(1).Day().At("04:00").Do(metrics.Job("clean_outbound_messages", s.CleanOutboundMessages))
+	gocron.Every(1).Day().At("04:05").Do(metrics.Job("clean_webhook_deliveries", s.CleanWebhookDeliveries))
+	gocron.Every(1).Hour().Do(metrics.Job("reconcile_member_syncs", s.ReconcileMemberSyncs))
+	s.Heartbeat("scheduler")
+	gocron.Every(1).Minute().Do(s.Heartbeat, "scheduler")
 	gocron.Start()
+	go s.runQueue()
+	go s.runWebhooks()
 
 	s.WG.Add(1)
 	go s.RTM.ManageConnection()
@@ -68,9 +108,28 @@ func (s *Slack) Run() {
 			s.handleMessage(ev, botUserID)
 		case *slack.MemberJoinedChannelEvent:
 			s.handleJoin(ev.Channel)
+			if ev.User == s.botUserID() {
+				s.setChannelArchived(ev.Channel, false)
+			}
+		case *slack.MemberLeftChannelEvent:
+			s.handleLeave(ev.User, ev.Channel)
+		case *slack.ChannelLeftEvent:
+			s.setChannelArchived(ev.Channel, true)
+		case *slack.ChannelArchiveEvent:
+			s.setChannelArchived(ev.Channel, true)
+		case *slack.ChannelUnarchiveEvent:
+			s.setChannelArchived(ev.Channel, false)
+		case *slack.ChannelRenameEvent:
+			s.handleRename(ev.Channel.ID, ev.Channel.Name)
 		case *slack.InvalidAuthEvent:
+			s.setRTMState(RTMInvalidAuth)
 			return
+		case *slack.ConnectingEvent:
+			s.setRTMState(RTMConnecting)
+		case *slack.DisconnectedEvent:
+			s.setRTMState(RTMDisconnected)
 		case *slack.ConnectedEvent:
+			s.setRTMState(RTMConnected)
 			logrus.Info("Reconnected!")
 		}
 	}
@@ -100,17 +159,42 @@ func (s *Slack) handleJoin(channelID string) {
 func (s *Slack) handleMessage(msg *slack.MessageEvent, botUserID string) {
 	switch msg.SubType {
 	case typeMessage:
-		if !strings.Contains(msg.Msg.Text, botUserID) && !strings.Contains(msg.Msg.Text, "#standup") {
+		if strings.HasPrefix(msg.Channel, "D") {
+			if msg.BotID != "" {
+				return
+			}
+			if !s.hasInterview(msg.User) {
+				if mc, ok := s.findMessageCommand(msg, botUserID, true); ok {
+					s.runMessageCommand(msg, mc, true)
+					return
+				}
+			}
+			s.handleInterviewAnswer(msg)
 			return
 		}
-		messageIsStandup, problem := s.analizeStandup(msg.Msg.Text)
-		if problem != "" {
+		// the cheap mention checks go first, standups are analyzed only in messages addressed to the bot
+		if mc, ok := s.findMessageCommand(msg, botUserID, false); ok {
+			if isStandup, _ := s.analizeStandup(msg.Channel, msg.Msg.Text); !isStandup {
+				s.runMessageCommand(msg, mc, false)
+				return
+			}
+		}
+		mentioned := strings.Contains(msg.Msg.Text, botUserID) || strings.Contains(msg.Msg.Text, "#standup")
+		if !mentioned && (msg.Msg.ThreadTimestamp == msg.Msg.Timestamp || !s.isStandupThreadReply(msg.Channel, msg.Msg.ThreadTimestamp)) {
+			return
+		}
+		t := s.Translation(msg.Channel, msg.User)
+		reason, problem := s.standupProblem(t, msg.Channel, msg.Msg.Text)
+		messageIsStandup := reason == ""
+		if reason != "" {
+			standupsRejected.Inc(reason)
 			s.SendEphemeralMessage(msg.Channel, msg.User, problem)
 			return
 		}
 		if messageIsStandup {
 			if s.DB.SubmittedStandupToday(msg.User, msg.Channel) {
-				s.SendEphemeralMessage(msg.Channel, msg.User, s.Conf.Translate.StandupHandleOneDayOneStandup)
+				standupsRejected.Inc(rejectDuplicate)
+				s.SendEphemeralMessage(msg.Channel, msg.User, t.StandupHandleOneDayOneStandup)
 				return
 			}
 			standup, err := s.DB.CreateStandup(model.Standup{
@@ -122,31 +206,42 @@ func (s *Slack) handleMessage(msg *slack.MessageEvent, botUserID string) {
 			if err != nil {
 				logrus.Errorf("CreateStandup failed: %v", err)
 				errorReportToManager := fmt.Sprintf("I could not save standup for user %s in channel %s because of the following reasons: %v", msg.User, msg.Channel, err)
-				s.SendUserMessage(s.Conf.ManagerSlackUserID, errorReportToManager)
-				s.SendEphemeralMessage(msg.Channel, msg.User, s.Conf.Translate.StandupHandleCouldNotSaveStandup)
+				s.SendUserMessage(s.Config().ManagerSlackUserID, errorReportToManager)
+				s.SendEphemeralMessage(msg.Channel, msg.User, t.StandupHandleCouldNotSaveStandup)
 				return
 			}
 			logrus.Infof("Standup created #id:%v\n", standup.ID)
+			standupsSaved.Inc("created")
+			s.handleBlocker(standup)
+			s.EmitEvent(model.EventStandupCreated, standup.ChannelID, standup)
 			item := slack.ItemRef{msg.Channel, msg.Msg.Timestamp, "", ""}
 			time.Sleep(2 * time.Second)
 			s.API.AddReaction("heavy_check_mark", item)
-			s.SendEphemeralMessage(msg.Channel, msg.User, s.Conf.Translate.StandupHandleCreatedStandup)
+			s.SendEphemeralMessage(msg.Channel, msg.User, t.StandupHandleCreatedStandup)
+			if problem != "" {
+				s.SendEphemeralMessage(msg.Channel, msg.User, problem)
+			}
 			return
 		}
 	case typeEditMessage:
-		if !strings.Contains(msg.SubMessage.Text, botUserID) && !strings.Contains(msg.SubMessage.Text, "#standup") {
+		mentioned := strings.Contains(msg.SubMessage.Text, botUserID) || strings.Contains(msg.SubMessage.Text, "#standup")
+		if !mentioned && (msg.SubMessage.ThreadTimestamp == msg.SubMessage.Timestamp || !s.isStandupThreadReply(msg.Channel, msg.SubMessage.ThreadTimestamp)) {
 			return
 		}
+		t := s.Translation(msg.Channel, msg.SubMessage.User)
 		standup, err := s.DB.SelectStandupByMessageTS(msg.SubMessage.Timestamp)
 		if err != nil {
-			messageIsStandup, problem := s.analizeStandup(msg.SubMessage.Text)
-			if problem != "" {
+			reason, problem := s.standupProblem(t, msg.Channel, msg.SubMessage.Text)
+			messageIsStandup := reason == ""
+			if reason != "" {
+				standupsRejected.Inc(reason)
 				s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, problem)
 				return
 			}
 			if messageIsStandup {
 				if s.DB.SubmittedStandupToday(msg.SubMessage.User, msg.Channel) {
-					s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, s.Conf.Translate.StandupHandleOneDayOneStandup)
+					standupsRejected.Inc(rejectDuplicate)
+					s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, t.StandupHandleOneDayOneStandup)
 					return
 				}
 				logrus.Infof("CreateStandup while updating text ChannelID (%v), UserID (%v), Comment (%v), TimeStamp (%v)", msg.Channel, msg.SubMessage.User, msg.SubMessage.Text, msg.SubMessage.Timestamp)
@@ -159,21 +254,29 @@ func (s *Slack) handleMessage(msg *slack.MessageEvent, botUserID string) {
 				if err != nil {
 					logrus.Errorf("CreateStandup while updating text failed: %v", err)
 					errorReportToManager := fmt.Sprintf("I could not create standup while updating msg for user %s in channel %s because of the following reasons: %v", msg.SubMessage.User, msg.Channel, err)
-					s.SendUserMessage(s.Conf.ManagerSlackUserID, errorReportToManager)
-					s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, s.Conf.Translate.StandupHandleCouldNotSaveStandup)
+					s.SendUserMessage(s.Config().ManagerSlackUserID, errorReportToManager)
+					s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, t.StandupHandleCouldNotSaveStandup)
 					return
 				}
 				logrus.Infof("Standup created #id:%v\n", standup.ID)
+				standupsSaved.Inc("created")
+				s.handleBlocker(standup)
+				s.EmitEvent(model.EventStandupCreated, standup.ChannelID, standup)
 				item := slack.ItemRef{msg.Channel, msg.SubMessage.Timestamp, "", ""}
 				time.Sleep(2 * time.Second)
 				s.API.AddReaction("heavy_check_mark", item)
-				s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, s.Conf.Translate.StandupHandleCreatedStandup)
+				s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, t.StandupHandleCreatedStandup)
+				if problem != "" {
+					s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, problem)
+				}
 				return
 			}
 		}
 
-		messageIsStandup, problem := s.analizeStandup(msg.SubMessage.Text)
-		if problem != "" {
+		reason, problem := s.standupProblem(t, msg.Channel, msg.SubMessage.Text)
+		messageIsStandup := reason == ""
+		if reason != "" {
+			standupsRejected.Inc(reason)
 			s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, problem)
 			return
 		}
@@ -181,8 +284,14 @@ func (s *Slack) handleMessage(msg *slack.MessageEvent, botUserID string) {
 			standup.Comment = msg.SubMessage.Text
 			st, _ := s.DB.UpdateStandup(standup)
 			logrus.Infof("Standup updated #id:%v\n", st.ID)
+			standupsSaved.Inc("edited")
+			s.handleBlocker(standup)
+			s.EmitEvent(model.EventStandupEdited, standup.ChannelID, standup)
 			time.Sleep(2 * time.Second)
-			s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, s.Conf.Translate.StandupHandleUpdatedStandup)
+			s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, t.StandupHandleUpdatedStandup)
+			if problem != "" {
+				s.SendEphemeralMessage(msg.Channel, msg.SubMessage.User, problem)
+			}
 			return
 		}
 
@@ -193,51 +302,140 @@ func (s *Slack) handleMessage(msg *slack.MessageEvent, botUserID string) {
 		}
 		s.DB.DeleteStandup(standup.ID)
 		logrus.Infof("Standup deleted #id:%v\n", standup.ID)
+		if standup.ID != 0 {
+			s.EmitEvent(model.EventStandupDeleted, standup.ChannelID, standup)
+		}
+		blocker, err := s.DB.SelectBlockerByStandup(standup.ID)
+		if err == nil {
+			s.DB.DeleteBlocker(blocker.ID)
+		}
 	}
 }
 
-func (s *Slack) analizeStandup(message string) (bool, string) {
-	message = strings.ToLower(message)
-	mentionsProblem := false
-	problemKeys := []string{"problem", "difficult", "stuck", "question", "issue", "block", "проблем", "трудност", "затрдуднени", "вопрос"}
-	for _, problem := range problemKeys {
-		if strings.Contains(message, problem) {
-			mentionsProblem = true
+// StandupRules returns standup validation rules of the channel.
+// If channel does not have its own rules, default ones are returned
+func (s *Slack) StandupRules(channelID string) model.StandupRules {
+	rules, err := s.DB.SelectStandupRules(channelID)
+	if err != nil {
+		rules = model.StandupRules{ChannelID: channelID}
+		rules.SetSections(defaultStandupSections)
+	}
+	return rules
+}
+
+func (s *Slack) analizeStandup(channelID, message string) (bool, string) {
+	return s.validateStandup(s.StandupRules(channelID), message)
+}
+
+func (s *Slack) validateStandup(rules model.StandupRules, message string) (bool, string) {
+	reason, problem := s.checkStandup(s.Config().Translate, rules, message)
+	return reason == "", problem
+}
+
+// standupProblem validates standup like analizeStandup, but returns reason of rejection along with the problem.
+// Problems reject standups only in channels with strict validation, others accept them with empty reason
+func (s *Slack) standupProblem(t config.Translate, channelID, message string) (string, string) {
+	reason, problem := s.checkStandup(t, s.StandupRules(channelID), message)
+	if reason == "" {
+		return "", ""
+	}
+	switch s.ChannelSettings(channelID).Validation {
+	case model.ValidationOff:
+		return "", ""
+	case model.ValidationWarn:
+		return "", problem
+	}
+	return reason, problem
+}
+
+// checkStandup returns reason of rejection and the problem to tell user about in their language, reason is empty for valid standups
+func (s *Slack) checkStandup(t config.Translate, rules model.StandupRules, message string) (string, string) {
+	sections, err := rules.ListSections()
+	if err != nil {
+		logrus.Errorf("ListSections failed for channel %v: %v", rules.ChannelID, err)
+		sections = defaultStandupSections
+	}
+	for _, section := range sections {
+		if !sectionMentioned(section, message) {
+			return rejectMissingSection, s.sectionWarning(t, rules, section)
 		}
 	}
-	if !mentionsProblem {
-		return false, s.Conf.Translate.StandupHandleNoProblemsMentioned
+	if utf8.RuneCountInString(strings.TrimSpace(message)) < rules.MinLength {
+		return rejectTooShort, fmt.Sprintf(t.StandupHandleTooShort, t.Plural(config.PluralCharacters, rules.MinLength))
 	}
+	if rules.TicketPattern != "" {
+		rg, err := regexp.Compile(rules.TicketPattern)
+		if err != nil {
+			logrus.Errorf("Ticket pattern for channel %v is broken: %v", rules.ChannelID, err)
+			return "", ""
+		}
+		if !rg.MatchString(message) {
+			return rejectNoTicket, fmt.Sprintf(t.StandupHandleNoTicketMentioned, rules.TicketPattern)
+		}
+	}
+	return "", ""
+}
 
-	mentionsYesterdayWork := false
-	yesterdayWorkKeys := []string{"yesterday", "friday", "completed", "вчера", "пятниц", "делал", "сделано"}
-	for _, work := range yesterdayWorkKeys {
-		if strings.Contains(message, work) {
-			mentionsYesterdayWork = true
+func (s *Slack) sectionWarning(t config.Translate, rules model.StandupRules, section model.StandupSection) string {
+	// default rules keep their own detailed warnings
+	if rules.ID == 0 {
+		switch section.Name {
+		case "problems":
+			return t.StandupHandleNoProblemsMentioned
+		case "yesterday":
+			return t.StandupHandleNoYesterdayWorkMentioned
+		case "today":
+			return t.StandupHandleNoTodayPlansMentioned
 		}
 	}
-	if !mentionsYesterdayWork {
-		return false, s.Conf.Translate.StandupHandleNoYesterdayWorkMentioned
+	return fmt.Sprintf(t.StandupHandleNoSectionMentioned, section.Name, DescribeSection(section))
+}
+
+// DescribeSection returns human readable description of what section expects to see in standup
+func DescribeSection(section model.StandupSection) string {
+	if section.Pattern != "" {
+		return fmt.Sprintf("`%v`", section.Pattern)
+	}
+	keywords := []string{}
+	for _, keyword := range section.Keywords {
+		keywords = append(keywords, fmt.Sprintf("'%v'", keyword))
 	}
+	return strings.Join(keywords, ", ")
+}
 
-	mentionsTodayPlans := false
-	todayPlansKeys := []string{"today", "going", "plan", "сегодня", "собираюсь", "план"}
-	for _, plan := range todayPlansKeys {
-		if strings.Contains(message, plan) {
-			mentionsTodayPlans = true
+func sectionMentioned(section model.StandupSection, message string) bool {
+	if section.Pattern != "" {
+		rg, err := regexp.Compile("(?i)" + section.Pattern)
+		if err != nil {
+			logrus.Errorf("Pattern of section %v is broken: %v", section.Name, err)
+			return true
 		}
+		return rg.MatchString(message)
 	}
-	if !mentionsTodayPlans {
-		return false, s.Conf.Translate.StandupHandleNoTodayPlansMentioned
+	message = strings.ToLower(message)
+	for _, keyword := range section.Keywords {
+		if strings.Contains(message, strings.ToLower(keyword)) {
+			return true
+		}
 	}
-	return true, ""
+	return false
 }
 
 // SendMessage posts a message in a specified channel visible for everyone
 func (s *Slack) SendMessage(channel, message string, attachments []slack.Attachment) error {
-	_, _, err := s.API.PostMessage(channel, message, slack.PostMessageParameters{
-		Attachments: attachments,
-	})
+	m := model.OutboundMessage{
+		Kind:      outboundMessage,
+		ChannelID: channel,
+		Text:      message,
+	}
+	if len(attachments) != 0 {
+		data, err := json.Marshal(attachments)
+		if err != nil {
+			return err
+		}
+		m.Attachments = string(data)
+	}
+	err := s.send(m)
 	if err != nil {
 		logrus.Errorf("slack: PostMessage failed: %v\n", err)
 		return err
@@ -247,11 +445,12 @@ func (s *Slack) SendMessage(channel, message string, attachments []slack.Attachm
 
 // SendEphemeralMessage posts a message in a specified channel which is visible only for selected user
 func (s *Slack) SendEphemeralMessage(channel, user, message string) error {
-	_, err := s.API.PostEphemeral(
-		channel,
-		user,
-		slack.MsgOptionText(message, true),
-	)
+	err := s.send(model.OutboundMessage{
+		Kind:      outboundEphemeral,
+		ChannelID: channel,
+		UserID:    user,
+		Text:      message,
+	})
 	if err != nil {
 		logrus.Errorf("slack: PostEphemeral failed: %v\n", err)
 		return err
@@ -261,11 +460,11 @@ func (s *Slack) SendEphemeralMessage(channel, user, message string) error {
 
 // SendUserMessage Direct Message specific user
 func (s *Slack) SendUserMessage(userID, message string) error {
-	_, _, channelID, err := s.API.OpenIMChannel(userID)
-	if err != nil {
-		return err
-	}
-	err = s.SendMessage(channelID, message, nil)
+	err := s.send(model.OutboundMessage{
+		Kind:   outboundDirect,
+		UserID: userID,
+		Text:   message,
+	})
 	if err != nil {
 		return err
 	}
@@ -307,12 +506,7 @@ func (s *Slack) UpdateUsersList() {
 				continue
 			}
 			for _, member := range cm {
-				s.DB.DeleteChannelMember(member.UserID, member.ChannelID)
-				tt, err := s.DB.SelectTimeTable(member.ID)
-				if err != nil {
-					continue
-				}
-				s.DB.DeleteTimeTable(tt.ID)
+				s.removeChannelMember(member)
 			}
 		}
 	}
@@ -329,8 +523,16 @@ func (s *Slack) FillStandupsForNonReporters() {
 	if err != nil {
 		return
 	}
+	channels, err := s.DB.GetAllChannels()
+	if err != nil {
+		return
+	}
+	archived := map[string]bool{}
+	for _, channel := range channels {
+		archived[channel.ChannelID] = channel.Archived
+	}
 	for _, user := range allUsers {
-		if user.Created.Day() == time.Now().Day() {
+		if user.Created.Day() == time.Now().Day() || archived[user.ChannelID] {
 			continue
 		}
 		hasStandup := s.DB.SubmittedStandupToday(user.UserID, user.ChannelID)
@@ -343,7 +545,7 @@ func (s *Slack) FillStandupsForNonReporters() {
 			})
 			if err != nil {
 				errorReportToManager := fmt.Sprintf("I could not create empty standup for user %s in channel %s because of the following reasons: %v", user.UserID, user.ChannelID, err)
-				s.SendUserMessage(s.Conf.ManagerSlackUserID, errorReportToManager)
+				s.SendUserMessage(s.Config().ManagerSlackUserID, errorReportToManager)
 			}
 		}
 	}
@@ -46,13 +46,44 @@ func TestIsStandup(t *testing.T) {
 	s, err := NewSlack(c)
 	assert.NoError(t, err)
 	for _, tt := range testCases {
-		ok, _ := s.analizeStandup(tt.input)
+		ok, _ := s.analizeStandup("", tt.input)
 		if ok != tt.confirm {
 			t.Errorf("Test %s: \n input: %s,\n expected confirm: %v\n actual confirm: %v \n", tt.title, tt.input, tt.confirm, ok)
 		}
 	}
 }
 
+func TestValidateStandup(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	rules := model.StandupRules{ID: 1, ChannelID: "QWERTY123", MinLength: 40, TicketPattern: "[A-Z]+-[0-9]+"}
+	assert.NoError(t, rules.SetSections([]model.StandupSection{
+		{Name: "gestern", Keywords: []string{"gestern"}},
+		{Name: "heute", Pattern: "heute|jetzt"},
+	}))
+
+	testCases := []struct {
+		title   string
+		input   string
+		confirm bool
+		problem string
+	}{
+		{"no sections", "Yesterday, today, problems", false, "No 'gestern' section detected! Please, mention it in your standup using: 'gestern'"},
+		{"no regex section", "Gestern habe ich COM-12 gemacht", false, "No 'heute' section detected! Please, mention it in your standup using: `heute|jetzt`"},
+		{"too short", "Gestern COM-1, heute", false, "Your standup is too short! Please, write at least 40 characters"},
+		{"no ticket", "Gestern habe ich viel gemacht, HEUTE mache ich noch mehr", false, "Please, mention at least one ticket in your standup (text matching `[A-Z]+-[0-9]+`)"},
+		{"valid", "Gestern habe ich COM-12 gemacht, heute mache ich COM-13", true, ""},
+	}
+	for _, tt := range testCases {
+		ok, problem := s.validateStandup(rules, tt.input)
+		assert.Equal(t, tt.confirm, ok, tt.title)
+		assert.Equal(t, tt.problem, problem, tt.title)
+	}
+}
+
 func TestSendMessage(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -0,0 +1,80 @@
+package chat
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/sirupsen/logrus"
+)
+
+// StartStandupThread posts today's parent message in the channel. Replies in its thread are treated as standups.
+// If the thread was already started today, it is returned as is. The parent message can not be queued,
+// since its timestamp is needed right away, so while Slack rate limits the bot an error is returned
+// and the caller tries again later
+func (s *Slack) StartStandupThread(channelID string) (model.StandupThread, error) {
+	thread, err := s.DB.SelectStandupThread(channelID, time.Now())
+	if err == nil {
+		return thread, nil
+	}
+	if until := s.rateLimitedUntil(); time.Now().Before(until) {
+		return thread, fmt.Errorf("rate limited till %v", until)
+	}
+	text := fmt.Sprintf(s.Translation(channelID, "").StandupThreadHeader, time.Now().Format("2006-01-02"))
+	_, threadTS, err := s.API.PostMessage(channelID, text, slack.PostMessageParameters{})
+	if rle, ok := err.(*slack.RateLimitedError); ok {
+		s.setRateLimitedUntil(time.Now().Add(rle.RetryAfter))
+	}
+	if err != nil {
+		logrus.Errorf("slack: PostMessage failed: %v\n", err)
+		return thread, err
+	}
+	messagesSent.Inc(outboundMessage)
+	thread, err = s.DB.CreateStandupThread(model.StandupThread{
+		ChannelID: channelID,
+		ThreadTS:  threadTS,
+	})
+	if err != nil {
+		logrus.Errorf("CreateStandupThread failed: %v", err)
+		return thread, err
+	}
+	logrus.Infof("Standup thread started #id:%v\n", thread.ID)
+	return thread, nil
+}
+
+// SendThreadMessage posts a message as a reply in the thread of a specified channel
+func (s *Slack) SendThreadMessage(channel, threadTS, message string) error {
+	err := s.send(model.OutboundMessage{
+		Kind:      outboundMessage,
+		ChannelID: channel,
+		ThreadTS:  threadTS,
+		Text:      message,
+	})
+	if err != nil {
+		logrus.Errorf("slack: PostMessage in thread failed: %v\n", err)
+		return err
+	}
+	return err
+}
+
+// SendStandupSummary posts a message to today's standup thread of the channel if there is one,
+// otherwise the message is posted to the channel itself
+func (s *Slack) SendStandupSummary(channelID, message string) error {
+	thread, err := s.DB.SelectStandupThread(channelID, time.Now())
+	if err != nil {
+		return s.SendMessage(channelID, message, nil)
+	}
+	return s.SendThreadMessage(channelID, thread.ThreadTS, message)
+}
+
+func (s *Slack) isStandupThreadReply(channelID, threadTS string) bool {
+	if threadTS == "" {
+		return false
+	}
+	thread, err := s.DB.SelectStandupThreadByTS(threadTS)
+	if err != nil {
+		return false
+	}
+	return thread.ChannelID == channelID
+}
@@ -0,0 +1,57 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	httpmock "gopkg.in/jarcoal/httpmock.v1"
+)
+
+func TestStandupThread(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://slack.com/api/chat.postMessage", httpmock.NewStringResponder(200, `{"ok": true, "channel": "THREADCHAN", "ts": "1530000000.000100"}`))
+	httpmock.RegisterResponder("POST", "https://slack.com/api/reactions.add", httpmock.NewStringResponder(200, `{"ok": true}`))
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	s, err := NewSlack(c)
+	assert.NoError(t, err)
+
+	channel, err := s.DB.CreateChannel(model.Channel{
+		ChannelName: "threads",
+		ChannelID:   "THREADCHAN",
+	})
+	assert.NoError(t, err)
+
+	thread, err := s.StartStandupThread(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, "1530000000.000100", thread.ThreadTS)
+
+	again, err := s.StartStandupThread(channel.ChannelID)
+	assert.NoError(t, err)
+	assert.Equal(t, thread.ID, again.ID)
+
+	assert.Equal(t, true, s.isStandupThreadReply(channel.ChannelID, thread.ThreadTS))
+	assert.Equal(t, false, s.isStandupThreadReply("OTHERCHAN", thread.ThreadTS))
+	assert.Equal(t, false, s.isStandupThreadReply(channel.ChannelID, ""))
+
+	msg := &slack.MessageEvent{}
+	msg.Channel = channel.ChannelID
+	msg.User = "userID1"
+	msg.Text = "yesterday: fixed bugs, today: write tests, problems: none"
+	msg.Timestamp = "1530000100.000100"
+	msg.ThreadTimestamp = thread.ThreadTS
+	s.handleMessage(msg, "<@BOTID>")
+
+	standup, err := s.DB.SelectStandupByMessageTS("1530000100.000100")
+	assert.NoError(t, err)
+	assert.Equal(t, channel.ChannelID, standup.ChannelID)
+
+	assert.NoError(t, s.DB.DeleteStandup(standup.ID))
+	assert.NoError(t, s.DB.DeleteStandupThread(thread.ID))
+	assert.NoError(t, s.DB.DeleteChannel(channel.ID))
+}
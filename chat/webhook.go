@@ -0,0 +1,216 @@
+package chat
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuses of webhook deliveries
+const (
+	webhookPending   = "pending"
+	webhookDelivered = "delivered"
+	webhookFailed    = "failed"
+)
+
+// maxWebhookAttempts is the number of attempts after which webhook delivery is marked as failed
+const maxWebhookAttempts = 8
+
+// webhookClient posts events to webhooks, slow receivers must not block delivery of others for long
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// webhookBatchTime caps how long deliveries to one webhook take in a run, the rest wait for the next run
+var webhookBatchTime = 30 * time.Second
+
+// WebhookEvent is JSON payload posted to webhooks
+type WebhookEvent struct {
+	Event     string      `json:"event"`
+	ChannelID string      `json:"channel_id"`
+	Created   time.Time   `json:"created"`
+	Data      interface{} `json:"data"`
+}
+
+// EmitEvent queues delivery of the event to every webhook subscribed to it and attempts delivery right away
+func (s *Slack) EmitEvent(event, channelID string, data interface{}) {
+	webhooks, err := s.DB.ListWebhooks()
+	if err != nil {
+		logrus.Errorf("ListWebhooks failed: %v", err)
+		return
+	}
+	payload := []byte{}
+	queued := false
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event, channelID) {
+			continue
+		}
+		if len(payload) == 0 {
+			payload, err = json.Marshal(WebhookEvent{Event: event, ChannelID: channelID, Created: time.Now(), Data: data})
+			if err != nil {
+				logrus.Errorf("Marshal of %v event failed: %v", event, err)
+				return
+			}
+		}
+		_, err := s.DB.CreateWebhookDelivery(model.WebhookDelivery{
+			WebhookID:   webhook.ID,
+			Event:       event,
+			Payload:     string(payload),
+			Status:      webhookPending,
+			NextAttempt: time.Now(),
+		})
+		if err != nil {
+			logrus.Errorf("CreateWebhookDelivery failed: %v", err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		s.wakeWebhooks()
+	}
+}
+
+// wakeWebhooks makes the webhook worker deliver at once instead of waiting for the next tick.
+// Wakeups coming while the worker is busy collapse into one, so events do not pile up goroutines
+func (s *Slack) wakeWebhooks() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// DeliverWebhooks attempts webhook deliveries which are due. Webhooks are delivered to concurrently,
+// deliveries to one webhook go one after another in the order events happened. A failed delivery
+// stops deliveries to its webhook, the later ones wait until it is retried
+func (s *Slack) DeliverWebhooks() {
+	s.webhookMutex.Lock()
+	defer s.webhookMutex.Unlock()
+
+	deliveries, err := s.DB.ListDueWebhookDeliveries(time.Now(), 50)
+	if err != nil {
+		logrus.Errorf("ListDueWebhookDeliveries failed: %v", err)
+		return
+	}
+	byWebhook := map[int64][]model.WebhookDelivery{}
+	for _, d := range deliveries {
+		byWebhook[d.WebhookID] = append(byWebhook[d.WebhookID], d)
+	}
+	deadline := time.Now().Add(webhookBatchTime)
+	var wg sync.WaitGroup
+	for webhookID, deliveries := range byWebhook {
+		webhook, err := s.DB.SelectWebhook(webhookID)
+		if err != nil {
+			logrus.Errorf("SelectWebhook failed: %v", err)
+			for _, d := range deliveries {
+				d.Status = webhookFailed
+				d.LastError = "webhook is removed"
+				s.updateWebhookDelivery(d)
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(webhook model.Webhook, deliveries []model.WebhookDelivery) {
+			defer wg.Done()
+			for _, d := range deliveries {
+				if time.Now().After(deadline) {
+					return
+				}
+				if !s.deliverWebhook(webhook, d) {
+					return
+				}
+			}
+		}(webhook, deliveries)
+	}
+	wg.Wait()
+}
+
+// deliverWebhook attempts the delivery and reports whether the webhook is done with it,
+// either delivered or failed for good
+func (s *Slack) deliverWebhook(webhook model.Webhook, d model.WebhookDelivery) bool {
+	code, err := postWebhook(webhook, d, time.Now())
+	d.Attempts++
+	d.ResponseCode = code
+	switch {
+	case err == nil:
+		d.Status = webhookDelivered
+		d.LastError = ""
+	case d.Attempts < maxWebhookAttempts:
+		d.NextAttempt = time.Now().Add(retryDelay(d.Attempts))
+		d.LastError = err.Error()
+	default:
+		d.Status = webhookFailed
+		d.LastError = err.Error()
+		logrus.Errorf("Webhook delivery #id:%v failed after %v attempts: %v", d.ID, d.Attempts, err)
+	}
+	s.updateWebhookDelivery(d)
+	return d.Status != webhookPending
+}
+
+func (s *Slack) updateWebhookDelivery(d model.WebhookDelivery) {
+	d.LastError = truncate(d.LastError, maxErrorLength)
+	_, err := s.DB.UpdateWebhookDelivery(d)
+	if err != nil {
+		logrus.Errorf("UpdateWebhookDelivery failed: %v", err)
+	}
+}
+
+// CleanWebhookDeliveries removes delivered and failed webhook deliveries older than a week
+func (s *Slack) CleanWebhookDeliveries() {
+	err := s.DB.DeleteWebhookDeliveries(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		logrus.Errorf("DeleteWebhookDeliveries failed: %v", err)
+	}
+}
+
+// runWebhooks is the only worker delivering webhooks, it runs every 10 seconds and when events are emitted
+func (s *Slack) runWebhooks() {
+	tick := time.Tick(10 * time.Second)
+	for {
+		select {
+		case <-tick:
+		case <-s.webhookWake:
+		}
+		s.DeliverWebhooks()
+	}
+}
+
+// postWebhook posts delivery payload to the webhook and returns response status code.
+// Receivers verify payload by X-Comedian-Signature header, HMAC-SHA256 of the timestamp and the body
+// keyed by webhook secret, and reject requests with an old X-Comedian-Timestamp to prevent replays
+func postWebhook(webhook model.Webhook, d model.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader([]byte(d.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Comedian-Webhook")
+	req.Header.Set("X-Comedian-Event", d.Event)
+	req.Header.Set("X-Comedian-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Comedian-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Comedian-Signature", "sha256="+SignWebhookPayload(webhook.Secret, now.Unix(), []byte(d.Payload)))
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns hex encoded HMAC-SHA256 of "timestamp.payload" keyed by the secret
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%v.", timestamp)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package chat
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	assert.Equal(t, "8fe48956985226b5e6814beb43aaab532e451475ae2f27f5b05db9e0e96309e5", SignWebhookPayload("secret", 1530000000, []byte("{}")))
+	assert.NotEqual(t, SignWebhookPayload("secret", 1530000000, []byte("{}")), SignWebhookPayload("another secret", 1530000000, []byte("{}")))
+	// replayed payload with a new timestamp does not match the signature
+	assert.NotEqual(t, SignWebhookPayload("secret", 1530000000, []byte("{}")), SignWebhookPayload("secret", 1530000060, []byte("{}")))
+}
+
+func TestPostWebhook(t *testing.T) {
+	var header http.Header
+	var body string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := model.Webhook{ID: 1, URL: server.URL, Secret: "secret", Events: model.EventStandupCreated}
+	delivery := model.WebhookDelivery{ID: 7, WebhookID: 1, Event: model.EventStandupCreated, Payload: "{}"}
+	now := time.Unix(1530000000, 0)
+	code, err := postWebhook(webhook, delivery, now)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "{}", body)
+	assert.Equal(t, model.EventStandupCreated, header.Get("X-Comedian-Event"))
+	assert.Equal(t, "7", header.Get("X-Comedian-Delivery"))
+	assert.Equal(t, "1530000000", header.Get("X-Comedian-Timestamp"))
+	assert.Equal(t, "sha256="+SignWebhookPayload("secret", now.Unix(), []byte("{}")), header.Get("X-Comedian-Signature"))
+
+	status = http.StatusBadGateway
+	code, err = postWebhook(webhook, delivery, now)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, code)
+}
+
+func TestWebhookSubscribed(t *testing.T) {
+	webhook := model.Webhook{URL: "https://example.com", Secret: "secret", Events: "standup.created, report.generated"}
+	assert.NoError(t, webhook.Validate())
+	assert.Equal(t, true, webhook.Subscribed(model.EventStandupCreated, "CHANID"))
+	assert.Equal(t, false, webhook.Subscribed(model.EventStandupDeleted, "CHANID"))
+
+	webhook.ChannelID = "CHANID"
+	assert.Equal(t, true, webhook.Subscribed(model.EventReportGenerated, "CHANID"))
+	assert.Equal(t, false, webhook.Subscribed(model.EventReportGenerated, "OTHERID"))
+
+	webhook.Events = "standup.created,standup.exploded"
+	assert.Error(t, webhook.Validate())
+}
+
+func TestWakeWebhooks(t *testing.T) {
+	s := &Slack{webhookWake: make(chan struct{}, 1)}
+	// wakeups while the worker is busy do not block and collapse into one
+	s.wakeWebhooks()
+	s.wakeWebhooks()
+	assert.Equal(t, 1, len(s.webhookWake))
+	<-s.webhookWake
+	assert.Equal(t, 0, len(s.webhookWake))
+
+	// without the worker waking is a no-op
+	(&Slack{}).wakeWebhooks()
+}
@@ -13,19 +13,38 @@ type Config struct {
 	ManagerSlackUserID string `envconfig:"SUPER_ADMIN_ID" required:"true"`
 	ReportingChannel   string `envconfig:"REPORT_CHANNEL" required:"true"`
 	ReportTime         string `envconfig:"REPORT_TIME" required:"true" default:"13:05"`
+	ThreadTime         string `envconfig:"THREAD_TIME" required:"true" default:"09:00"`
 	Language           string `envconfig:"LANGUAGE" required:"true" default:"en_US"`
 	ReminderRepeatsMax int    `envconfig:"MAX_REMINDERS" required:"true" default:"5"`
 	ReminderTime       int64  `envconfig:"WARNING_TIME" required:"true" default:"5"`
-	Translate          Translate
+	DashboardURL       string `envconfig:"DASHBOARD_URL"`
+	DashboardSecret    string `envconfig:"DASHBOARD_SECRET"`
+	MetricsToken       string `envconfig:"METRICS_TOKEN"`
+	MigrationsDir      string `envconfig:"MIGRATIONS_DIR" default:"migrations"`
+	ConfigFile         string `envconfig:"CONFIG_FILE"`
+	// Channels hold per-channel overrides from the config file keyed by channel ID or name
+	Channels  map[string]ChannelConfig `ignored:"true"`
+	Translate Translate
 }
 
-// Get method processes env variables and fills Config struct
+// Get method processes env variables and the optional config file and fills Config struct.
+// Settings of the config file take precedence over env variables, so that they can be reloaded
 func Get() (Config, error) {
 	var c Config
 	err := envconfig.Process("comedian", &c)
 	if err != nil {
 		return c, err
 	}
+	if c.ConfigFile != "" {
+		err = c.loadFile()
+		if err != nil {
+			return c, err
+		}
+	}
+	err = c.validate()
+	if err != nil {
+		return c, err
+	}
 	t, err := GetTranslation(c.Language)
 	if err != nil {
 		return c, err
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,3 +39,118 @@ func TestConfig(t *testing.T) {
 	assert.Equal(t, conf.ReminderRepeatsMax, int(5))
 	assert.Equal(t, conf.ReminderTime, int64(10))
 }
+
+func TestParseLanguage(t *testing.T) {
+	testCases := []struct {
+		input    string
+		language string
+		ok       bool
+	}{
+		{"en", "en", true},
+		{"RU", "ru", true},
+		{"ru_RU", "ru", true},
+		{"en-US", "en", true},
+		{"", "", false},
+		{"fr", "", false},
+	}
+	for _, tt := range testCases {
+		language, ok := ParseLanguage(tt.input)
+		assert.Equal(t, tt.language, language, tt.input)
+		assert.Equal(t, tt.ok, ok, tt.input)
+	}
+}
+
+func TestTranslation(t *testing.T) {
+	en, err := GetTranslation("en_US")
+	assert.NoError(t, err)
+	c := Config{Language: "en_US", Translate: en}
+
+	assert.Equal(t, en, c.Translation(""))
+	assert.Equal(t, en, c.Translation("en"))
+	assert.Equal(t, en, c.Translation("fr"))
+
+	ru := c.Translation("ru")
+	assert.Equal(t, "Язык этого канала: %v\nВаш язык: %v", ru.LanguageShow)
+	assert.Equal(t, ru, c.Translation("ru_RU"))
+}
+
+func TestConfigFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("COMEDIAN_SLACK_TOKEN", "token")
+	os.Setenv("COMEDIAN_SUPER_ADMIN_ID", "FAKEUSERID")
+	os.Setenv("COMEDIAN_REPORT_CHANNEL", "REPORTINGCHANNEL")
+	os.Setenv("COMEDIAN_MAX_REMINDERS", "5")
+
+	dir, err := ioutil.TempDir("", "comedian")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "comedian.toml")
+	os.Setenv("COMEDIAN_CONFIG_FILE", file)
+
+	_, err = Get()
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte(`
+report_time = "9:30"
+thread_time = "8:45"
+max_reminders = 3
+
+[channels.C123]
+reminder_interval = 10
+max_reminders = 0
+
+[channels.backend]
+warning_time = 15
+language = "ru"
+`), 0644))
+	conf, err := Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "09:30", conf.ReportTime)
+	assert.Equal(t, "08:45", conf.ThreadTime)
+	assert.Equal(t, 3, conf.ReminderRepeatsMax)
+	assert.Equal(t, 2, conf.NotifierInterval)
+	assert.Equal(t, "REPORTINGCHANNEL", conf.ReportingChannel)
+
+	c123 := conf.ForChannel("C123", "general")
+	assert.Equal(t, 10, c123.NotifierInterval)
+	assert.Equal(t, 0, c123.ReminderRepeatsMax)
+	assert.Equal(t, int64(5), c123.ReminderTime)
+
+	backend := conf.ForChannel("C456", "backend")
+	assert.Equal(t, 2, backend.NotifierInterval)
+	assert.Equal(t, 3, backend.ReminderRepeatsMax)
+	assert.Equal(t, int64(15), backend.ReminderTime)
+	cc, ok := conf.ChannelOverride("C456", "#backend")
+	assert.True(t, ok)
+	assert.Equal(t, "ru", cc.Language)
+
+	_, ok = conf.ChannelOverride("C789", "")
+	assert.False(t, ok)
+	assert.Equal(t, conf, conf.ForChannel("C789", "frontend"))
+
+	testCases := []struct {
+		file string
+		err  string
+	}{
+		{`report_time = "25:00"`, "report time 25:00 is not hh:mm"},
+		{`thread_time = "9am"`, "thread time 9am is not hh:mm"},
+		{`max_reminders = -1`, "max reminders must not be negative, got -1"},
+		{`reminder_time = 5`, "unknown keys reminder_time"},
+		{`language = "fr"`, "unknown language fr"},
+		{"[channels.C123]\nreminder_interval = -5", "channel C123: reminder interval must not be negative, got -5"},
+		{"reminder_interval = 0", "reminder interval must be positive when max reminders is 5, got 0"},
+		{"[channels.C123]\nreminder_interval = 0", "channel C123: reminder interval must be positive when max reminders is 5, got 0"},
+		{"[channels.C123]\nreminder_interval = 0\nmax_reminders = 2", "channel C123: reminder interval must be positive when max reminders is 2, got 0"},
+		{"[channels.C123]\nwarning_time = 1440", "channel C123: warning time must be between 0 and 1439 minutes, got 1440"},
+		{"[channels.C123]\nlanguage = \"fr\"", "channel C123: unknown language fr"},
+		{"[channels.C123]\nreport_time = \"10:00\"", "unknown keys channels.C123.report_time"},
+		{`max_reminders = "five"`, "config file"},
+	}
+	for _, tt := range testCases {
+		assert.NoError(t, ioutil.WriteFile(file, []byte(tt.file), 0644))
+		_, err := Get()
+		if assert.Error(t, err, tt.file) {
+			assert.Contains(t, err.Error(), tt.err, tt.file)
+		}
+	}
+}
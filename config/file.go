@@ -0,0 +1,176 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ChannelConfig overrides global settings in a channel, unset fields are inherited
+type ChannelConfig struct {
+	NotifierInterval   *int   `toml:"reminder_interval"`
+	ReminderRepeatsMax *int   `toml:"max_reminders"`
+	ReminderTime       *int64 `toml:"warning_time"`
+	Language           string `toml:"language"`
+}
+
+// fileConfig is the layout of the config file, unset fields keep values of env variables
+type fileConfig struct {
+	ReportingChannel   *string                  `toml:"report_channel"`
+	ReportTime         *string                  `toml:"report_time"`
+	ThreadTime         *string                  `toml:"thread_time"`
+	Language           *string                  `toml:"language"`
+	NotifierInterval   *int                     `toml:"reminder_interval"`
+	ReminderRepeatsMax *int                     `toml:"max_reminders"`
+	ReminderTime       *int64                   `toml:"warning_time"`
+	Channels           map[string]ChannelConfig `toml:"channels"`
+}
+
+// loadFile applies settings of the config file on top of env variables
+func (c *Config) loadFile() error {
+	var f fileConfig
+	md, err := toml.DecodeFile(c.ConfigFile, &f)
+	if err != nil {
+		return fmt.Errorf("config file %v: %v", c.ConfigFile, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := []string{}
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return fmt.Errorf("config file %v: unknown keys %v", c.ConfigFile, strings.Join(keys, ", "))
+	}
+
+	if f.ReportingChannel != nil {
+		c.ReportingChannel = *f.ReportingChannel
+	}
+	if f.ReportTime != nil {
+		c.ReportTime = *f.ReportTime
+	}
+	if f.ThreadTime != nil {
+		c.ThreadTime = *f.ThreadTime
+	}
+	if f.Language != nil {
+		if _, ok := ParseLanguage(*f.Language); !ok {
+			return fmt.Errorf("config file %v: unknown language %v", c.ConfigFile, *f.Language)
+		}
+		c.Language = *f.Language
+	}
+	if f.NotifierInterval != nil {
+		c.NotifierInterval = *f.NotifierInterval
+	}
+	if f.ReminderRepeatsMax != nil {
+		c.ReminderRepeatsMax = *f.ReminderRepeatsMax
+	}
+	if f.ReminderTime != nil {
+		c.ReminderTime = *f.ReminderTime
+	}
+
+	names := []string{}
+	for name := range f.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := f.Channels[name].validate(*c); err != nil {
+			return fmt.Errorf("config file %v: channel %v: %v", c.ConfigFile, name, err)
+		}
+	}
+	c.Channels = f.Channels
+	return nil
+}
+
+// validate checks that settings are in their ranges and normalizes report and thread time
+func (c *Config) validate() error {
+	reportTime, err := time.Parse("15:04", c.ReportTime)
+	if err != nil {
+		return fmt.Errorf("report time %v is not hh:mm", c.ReportTime)
+	}
+	c.ReportTime = reportTime.Format("15:04")
+	threadTime, err := time.Parse("15:04", c.ThreadTime)
+	if err != nil {
+		return fmt.Errorf("thread time %v is not hh:mm", c.ThreadTime)
+	}
+	c.ThreadTime = threadTime.Format("15:04")
+	if err := validateReminders(&c.NotifierInterval, &c.ReminderRepeatsMax, &c.ReminderTime); err != nil {
+		return err
+	}
+	return validateRepeats(c.NotifierInterval, c.ReminderRepeatsMax)
+}
+
+// validate checks overrides of the channel, reminders are checked together with the inherited ones
+func (cc ChannelConfig) validate(c Config) error {
+	if cc.Language != "" {
+		if _, ok := ParseLanguage(cc.Language); !ok {
+			return fmt.Errorf("unknown language %v", cc.Language)
+		}
+	}
+	if err := validateReminders(cc.NotifierInterval, cc.ReminderRepeatsMax, cc.ReminderTime); err != nil {
+		return err
+	}
+	interval, repeats := c.NotifierInterval, c.ReminderRepeatsMax
+	if cc.NotifierInterval != nil {
+		interval = *cc.NotifierInterval
+	}
+	if cc.ReminderRepeatsMax != nil {
+		repeats = *cc.ReminderRepeatsMax
+	}
+	return validateRepeats(interval, repeats)
+}
+
+// validateReminders checks reminder settings, nil ones are not set
+func validateReminders(interval, repeats *int, warning *int64) error {
+	if interval != nil && *interval < 0 {
+		return fmt.Errorf("reminder interval must not be negative, got %v", *interval)
+	}
+	if repeats != nil && *repeats < 0 {
+		return fmt.Errorf("max reminders must not be negative, got %v", *repeats)
+	}
+	if warning != nil && (*warning < 0 || *warning >= 24*60) {
+		return fmt.Errorf("warning time must be between 0 and 1439 minutes, got %v", *warning)
+	}
+	return nil
+}
+
+// validateRepeats checks that repeated reminders have an interval, otherwise they are all sent at once
+func validateRepeats(interval, repeats int) error {
+	if repeats > 0 && interval <= 0 {
+		return fmt.Errorf("reminder interval must be positive when max reminders is %v, got %v", repeats, interval)
+	}
+	return nil
+}
+
+// ChannelOverride returns overrides of the channel from the config file, looked up by channel ID or name
+func (c Config) ChannelOverride(channelID, channelName string) (ChannelConfig, bool) {
+	if cc, ok := c.Channels[channelID]; ok && channelID != "" {
+		return cc, true
+	}
+	channelName = strings.TrimPrefix(channelName, "#")
+	if cc, ok := c.Channels[channelName]; ok && channelName != "" {
+		return cc, true
+	}
+	cc, ok := c.Channels["#"+channelName]
+	return cc, ok && channelName != ""
+}
+
+// ForChannel returns the config with reminder overrides of the channel applied.
+// Language of the channel is resolved by chat together with languages set by commands
+func (c Config) ForChannel(channelID, channelName string) Config {
+	cc, ok := c.ChannelOverride(channelID, channelName)
+	if !ok {
+		return c
+	}
+	if cc.NotifierInterval != nil {
+		c.NotifierInterval = *cc.NotifierInterval
+	}
+	if cc.ReminderRepeatsMax != nil {
+		c.ReminderRepeatsMax = *cc.ReminderRepeatsMax
+	}
+	if cc.ReminderTime != nil {
+		c.ReminderTime = *cc.ReminderTime
+	}
+	return c
+}
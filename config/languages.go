@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Languages lists languages Comedian has translations for
+var Languages = Locales()
+
+var (
+	translationsMutex sync.Mutex
+	translations      = map[string]Translate{}
+)
+
+// ParseLanguage turns language setting like "ru", "RU" or "ru_RU" into one of Languages
+func ParseLanguage(lang string) (string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-"); i >= 0 {
+		lang = lang[:i]
+	}
+	for _, l := range Languages {
+		if l == lang {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Translation returns translation to the language, each language is loaded once.
+// Empty or unknown language falls back to the default language of the config
+func (c Config) Translation(lang string) Translate {
+	l, ok := ParseLanguage(lang)
+	if !ok {
+		return c.Translate
+	}
+	if def, _ := ParseLanguage(c.Language); def == l {
+		return c.Translate
+	}
+
+	translationsMutex.Lock()
+	defer translationsMutex.Unlock()
+	if t, ok := translations[l]; ok {
+		return t
+	}
+	t, err := GetTranslation(l)
+	if err != nil {
+		logrus.Errorf("config: GetTranslation failed for %v: %v\n", l, err)
+		return c.Translate
+	}
+	translations[l] = t
+	return t
+}
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"embed"
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/BurntSushi/toml"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -12,7 +15,27 @@ import (
 	"golang.org/x/text/language"
 )
 
-//Translate struct makes translation data
+// translationFiles are message files of all locales, one <language>.toml per locale.
+// A new locale is added by putting its file into the translations directory
+//
+//go:embed translations/*.toml
+var translationFiles embed.FS
+
+// Plural messages have plural forms and are rendered with Translate.Plural
+const (
+	PluralDays       = "Days"
+	PluralMinutes    = "Minutes"
+	PluralCharacters = "Characters"
+)
+
+var (
+	bundleOnce sync.Once
+	bundle     *i18n.Bundle
+	bundleErr  error
+)
+
+// Translate struct makes translation data. Every message needs a field named after its message id,
+// TestMessageFiles checks that message files and fields match
 type Translate struct {
 	ListNoStandupers string
 	ListNoAdmins     string
@@ -115,11 +138,7 @@ type Translate struct {
 	ReportHeader            string
 	ReportHeaderWeekly      string
 
-	AccessAtLeastPM           string
-	AccessAtLeastAdmin        string
-	AccessAtLeastSuperAdmin   string
-	AccessAtLeastAdminOrOwner string
-	AccessAtLeastPMOrOwner    string
+	AccessDenied string
 
 	NeedCorrectUserRole string
 	AddMembersFailed    string
@@ -133,257 +152,331 @@ type Translate struct {
 	AddAdminsAdded      string
 
 	SomethingWentWrong   string
-	HelpCommand          string
 	EmptyReportForSunday string
+
+	StandupHandleNoSectionMentioned string
+	StandupHandleTooShort           string
+	StandupHandleNoTicketMentioned  string
+	StandupRulesWrongFormat         string
+	StandupRulesWrongPattern        string
+	StandupRulesWrongMinLength      string
+	StandupRulesNoSuchSection       string
+	StandupRulesUpdated             string
+	StandupRulesReset               string
+	StandupRulesShow                string
+	StandupRulesShowDefault         string
+	StandupRulesSection             string
+	StandupRulesNoSections          string
+	StandupRulesMinLength           string
+	StandupRulesTicket              string
+
+	InterviewIntro             string
+	InterviewQuestionYesterday string
+	InterviewQuestionToday     string
+	InterviewQuestionProblems  string
+	InterviewHeadingYesterday  string
+	InterviewHeadingToday      string
+	InterviewHeadingProblems   string
+	InterviewSummary           string
+	InterviewFinished          string
+	InterviewModeOn            string
+	InterviewModeOff           string
+	InterviewModeShowOn        string
+	InterviewModeShowOff       string
+	InterviewModeWrongFormat   string
+
+	StandupThreadHeader    string
+	ThreadSummaryHeader    string
+	ThreadSummarySubmitted string
+	ThreadSummaryMissed    string
+	ThreadModeOn           string
+	ThreadModeOff          string
+	ThreadModeShowOn       string
+	ThreadModeShowOff      string
+	ThreadModeWrongFormat  string
+
+	BlockerEscalation        string
+	BlockerEscalationLink    string
+	BlockerEscalationResolve string
+	BlockersHeader           string
+	BlockersItem             string
+	BlockersNoOpen           string
+	BlockersNoSuchChannel    string
+	BlockersNotFound         string
+	BlockersResolved         string
+	BlockersResolvedNotify   string
+	BlockersWrongFormat      string
+
+	DeliveryStatus        string
+	DeliveryStatusMessage string
+
+	HelpUsage                 string
+	HelpItem                  string
+	HelpFooter                string
+	HelpDetails               string
+	HelpAliases               string
+	HelpAccess                string
+	UnknownCommand            string
+	CommandNeedsChannel       string
+	HelpHelp                  string
+	HelpAdd                   string
+	HelpDelete                string
+	HelpList                  string
+	HelpStandupTimeSet        string
+	HelpStandupTimeRemove     string
+	HelpStandupTime           string
+	HelpTimetableSet          string
+	HelpTimetableRemove       string
+	HelpTimetableShow         string
+	HelpReportByProject       string
+	HelpReportByUser          string
+	HelpReportByUserInProject string
+	HelpStandupRulesSet       string
+	HelpStandupRulesShow      string
+	HelpInterviewMode         string
+	HelpThreadMode            string
+	HelpBlockers              string
+	HelpDeliveryStatus        string
+
+	UsageHelp                  string
+	UsageAdd                   string
+	UsageDelete                string
+	UsageList                  string
+	UsageStandupTimeSet        string
+	UsageTimetableSet          string
+	UsageTimetableRemove       string
+	UsageTimetableShow         string
+	UsageReportByProject       string
+	UsageReportByUser          string
+	UsageReportByUserInProject string
+	UsageStandupRulesSet       string
+	UsageInterviewMode         string
+	UsageThreadMode            string
+	UsageBlockers              string
+	UsageMyStats               string
+	UsageDigestSet             string
+	UsageDashboard             string
+	UsageAPIToken              string
+	UsageWebhookSet            string
+	UsageWebhookList           string
+	UsageSyncMembers           string
+	UsageLanguageSet           string
+	UsageChannelSettings       string
+	UsageRoles                 string
+
+	ResponseUploaded string
+	ReportInProgress string
+
+	WrongReportFormat string
+	ReportExported    string
+
+	HelpDigestSet        string
+	HelpDigestList       string
+	WrongDigestPeriod    string
+	WrongDigestTime      string
+	WrongDigestRecipient string
+	DigestNotFound       string
+	DigestRemoved        string
+	DigestSaved          string
+	DigestItem           string
+	DigestAllChannels    string
+	DigestListHead       string
+	DigestListEmpty      string
+	DigestWeeklyHead     string
+	DigestMonthlyHead    string
+	DigestChannel        string
+	DigestMember         string
+	DigestMissed         string
+
+	StandupOnTime     string
+	StandupLate       string
+	PunctualityHead   string
+	PunctualityMember string
+
+	HelpMyStats        string
+	WrongStatsPeriod   string
+	MyStatsHead        string
+	MyStatsNoChannels  string
+	MyStatsChannels    string
+	MyStatsSubmitted   string
+	MyStatsStreak      string
+	MyStatsPunctuality string
+	MyStatsExcused     string
+
+	HelpDashboard             string
+	DashboardLink             string
+	DashboardSent             string
+	DashboardLinkExpired      string
+	DashboardDisabled         string
+	DashboardTitle            string
+	DashboardHeatmap          string
+	DashboardTrend            string
+	DashboardNonReporters     string
+	DashboardEveryoneReported string
+	DashboardBlockers         string
+	DashboardNoBlockers       string
+	DashboardOnTime           string
+	DashboardLate             string
+	DashboardMissed           string
+	DashboardNotTracked       string
+
+	HelpAPIToken      string
+	APITokenCreated   string
+	APITokenRevoked   string
+	APITokenListEmpty string
+	APITokenListHead  string
+	APITokenItem      string
+	WrongAPITokenRole string
+
+	HelpWebhookSet   string
+	HelpWebhookList  string
+	WebhookCreated   string
+	WebhookRemoved   string
+	WebhookNotFound  string
+	WrongWebhook     string
+	WebhookListEmpty string
+	WebhookListHead  string
+	WebhookItem      string
+	WebhookLogEmpty  string
+	WebhookLogHead   string
+	WebhookLogItem   string
+
+	HelpRoles         string
+	RolesWrongFormat  string
+	RolesHead         string
+	RolesItem         string
+	RoleBindingsHead  string
+	RoleBindingsItem  string
+	RoleBindingsEmpty string
+	RoleAllChannels   string
+	UserRoles         string
+	RoleBound         string
+	RoleUnbound       string
+	RoleUnknown       string
+	RoleDefined       string
+	RoleRemoved       string
+	WrongRole         string
+
+	HelpSyncMembers         string
+	SyncMembersNotSynced    string
+	SyncMembersCurrent      string
+	SyncMembersRemoved      string
+	SyncMembersDiffHead     string
+	SyncMembersAdd          string
+	SyncMembersRemove       string
+	SyncMembersNotInChannel string
+	SyncMembersNoChanges    string
+	SyncMembersConfirm      string
+	SyncMembersApplied      string
+	WrongUserGroup          string
+
+	HelpLanguageSet       string
+	LanguageShow          string
+	LanguageDefault       string
+	LanguageSameAsChannel string
+	LanguageChannelSet    string
+	LanguageChannelReset  string
+	LanguageUserSet       string
+	LanguageUserReset     string
+	LanguageWrongFormat   string
+	WrongLanguage         string
+
+	HelpChannelSettings        string
+	ChannelSettingsHead        string
+	ChannelSettingsItem        string
+	ChannelSettingsInherited   string
+	ChannelSettingsUpdated     string
+	ChannelSettingsReset       string
+	ChannelSettingsWrongFormat string
+	WrongChannelSetting        string
+
+	localizer *i18n.Localizer
 }
 
-// GetTranslation sets translation files for config
-func GetTranslation(lang string) (Translate, error) {
-	bundle := &i18n.Bundle{DefaultLanguage: language.English}
-	bundle.RegisterUnmarshalFunc("toml", toml.Unmarshal)
-	wd, err := os.Getwd()
+// Locales returns languages which have message files, sorted
+func Locales() []string {
+	files, err := translationFiles.ReadDir("translations")
 	if err != nil {
-		return Translate{}, err
+		logrus.Errorf("config: ReadDir failed: %v\n", err)
+		return nil
 	}
-	if !strings.HasSuffix(wd, "comedian") {
-		wd = filepath.Dir(wd)
+	locales := []string{}
+	for _, file := range files {
+		locales = append(locales, strings.TrimSuffix(file.Name(), path.Ext(file.Name())))
 	}
-	_, err = bundle.LoadMessageFile(fmt.Sprintf("%s/config/ru.toml", wd))
-	if err != nil {
-		return Translate{}, err
-	}
-	_, err = bundle.LoadMessageFile(fmt.Sprintf("%s/config/en.toml", wd))
+	sort.Strings(locales)
+	return locales
+}
+
+// newBundle returns empty bundle which reads toml message files
+func newBundle() *i18n.Bundle {
+	b := &i18n.Bundle{DefaultLanguage: language.English}
+	b.RegisterUnmarshalFunc("toml", toml.Unmarshal)
+	return b
+}
+
+// parseMessageFile adds messages of the locale to the bundle
+func parseMessageFile(b *i18n.Bundle, locale string) (*i18n.MessageFile, error) {
+	name := "translations/" + locale + ".toml"
+	buf, err := translationFiles.ReadFile(name)
 	if err != nil {
-		return Translate{}, err
+		return nil, err
 	}
-	localizer := i18n.NewLocalizer(bundle, lang)
+	return b.ParseMessageFileBytes(buf, name)
+}
+
+// loadBundle parses embedded message files once
+func loadBundle() (*i18n.Bundle, error) {
+	bundleOnce.Do(func() {
+		b := newBundle()
+		for _, locale := range Locales() {
+			if _, err := parseMessageFile(b, locale); err != nil {
+				bundleErr = err
+				return
+			}
+		}
+		bundle = b
+	})
+	return bundle, bundleErr
+}
+
+// GetTranslation localizes every string field of Translate by its name.
+// Messages missing in the language are taken from English
+func GetTranslation(lang string) (Translate, error) {
+	b, err := loadBundle()
 	if err != nil {
-		logrus.Errorf("slack: GetLocalizer failed: %v\n", err)
 		return Translate{}, err
 	}
-	m := make(map[string]string)
-	r := []string{
-		"Worklogs", "noCommits", "noStandup", "WorklogsTime",
-		"hasCommits", "hasStandup", "isRook", "notifyAllDone",
-		"notifyNotAll", "notifyManagerNotAll", "notifyUsersWarning",
-		"notifyDirectMessage",
-		"reportByProjectAndUser", "reportOnProjectHead", "reportOnProjectCollectorData", "reportOnUserHead",
-		"reportOnProjectAndUserHead", "reportNoData", "reportDate",
-		"reportStandupFromUser", "reportIgnoredStandup", "reportShowChannel",
-		"reportCollectorDataUser",
-		"helloManager", "standupAccepted",
-		"listNoStandupers",
-		"listNoAdmins",
-		"listStandupers",
-		"listAdmins",
-		"listNoPMs",
-		"listPMs",
-		"addStandupTimeNoUsers",
-		"addStandupTime",
-		"removeStandupTimeWithUsers",
-		"removeStandupTime",
-		"showNoStandupTime",
-		"showStandupTime",
-		"wrongNArgs",
-		"dateError1", "dateError2",
-		"userDidNotStandup", "userDidStandup",
-		"userDidNotStandupInChannel", "userDidStandupInChannel",
-		"PMAssigned",
-		"PMRemoved",
-		"wrongUsernameError",
-
-		"selectUsersToAdd",
-		"selectUsersToDelete",
-		"CanNotFindMember",
-		"selectUsersToAddAsAdmin",
-		"noSuchUserInWorkspace",
-		"userNotAdmin",
-		"wrongProjectName",
-		"DaysDivider",
-		"TimeDivider",
-		"TimetableNoUsers",
-		"TimetableCreated",
-		"TimetableUpdated",
-		"CanNotUpdateTimetable",
-		"NotAStanduper",
-		"NoTimetableSet",
-		"TimetableShow",
-		"CanNotDeleteTimetable",
-		"TimetableDeleted",
-		"IndividualStandupersWarning",
-		"IndividualStandupersLate",
-		"EmptyTimetable",
-		"TimetableShowMonday",
-		"TimetableShowTuesday",
-		"TimetableShowWednesday",
-		"TimetableShowThursday",
-		"TimetableShowFriday",
-		"TimetableShowSaturday",
-		"TimetableShowSunday",
-		"ComedianIsNotInChannel",
-
-		"StandupHandleUserNotAssigned",
-		"StandupHandleOneDayOneStandup",
-		"StandupHandleCouldNotSaveStandup",
-		"StandupHandleNoProblemsMentioned",
-		"StandupHandleNoYesterdayWorkMentioned",
-		"StandupHandleNoTodayPlansMentioned",
-		"StandupHandleUpdatedStandup",
-		"StandupHandleCreatedStandup",
-		"ErrorRooksReportWeekend",
-		"ReportHeaderMonday",
-		"ReportHeader",
-		"ReportHeaderWeekly",
-		"UserIsNotPM",
-		"AccessAtLeastPM",
-		"AccessAtLeastAdmin",
-		"AccessAtLeastSuperAdmin",
-		"AccessAtLeastAdminOrOwner",
-		"AccessAtLeastPMOrOwner",
-		"NeedCorrectUserRole",
-		"AddMembersFailed",
-		"AddMembersExist",
-		"AddMembersAdded",
-		"AddPMsFailed",
-		"AddPMsExist",
-		"AddPMsAdded",
-		"AddAdminsFailed",
-		"AddAdminsExist",
-		"AddAdminsAdded",
-		"SomethingWentWrong",
-		"HelpCommand",
-		"EmptyReportForSunday",
-	}
-
-	for _, t := range r {
-		translated, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: t})
+	t := Translate{localizer: i18n.NewLocalizer(b, lang)}
+	v := reflect.ValueOf(&t).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.Type.Kind() != reflect.String {
+			continue
+		}
+		translated, err := t.localizer.Localize(&i18n.LocalizeConfig{MessageID: field.Name})
 		if err != nil {
-			logrus.Errorf("slack: Localize failed: %v\n", err)
-			return Translate{}, err
+			logrus.Errorf("config: Localize failed: %v\n", err)
+			continue
 		}
-		m[t] = translated
+		v.Field(i).SetString(translated)
 	}
+	return t, nil
+}
 
-	t := Translate{
-
-		AddMembersFailed: m["AddMembersFailed"],
-		AddMembersExist:  m["AddMembersExist"],
-		AddMembersAdded:  m["AddMembersAdded"],
-
-		AddPMsFailed: m["AddPMsFailed"],
-		AddPMsExist:  m["AddPMsExist"],
-		AddPMsAdded:  m["AddPMsAdded"],
-
-		AddAdminsFailed: m["AddAdminsFailed"],
-		AddAdminsExist:  m["AddAdminsExist"],
-		AddAdminsAdded:  m["AddAdminsAdded"],
-
-		ListNoStandupers: m["listNoStandupers"],
-		ListNoAdmins:     m["listNoAdmins"],
-		ListStandupers:   m["listStandupers"],
-		ListAdmins:       m["listAdmins"],
-		ListNoPMs:        m["listNoPMs"],
-		ListPMs:          m["listPMs"],
-
-		AddStandupTimeNoUsers:        m["addStandupTimeNoUsers"],
-		AddStandupTime:               m["addStandupTime"],
-		RemoveStandupTimeWithUsers:   m["removeStandupTimeWithUsers"],
-		RemoveStandupTime:            m["removeStandupTime"],
-		ShowNoStandupTime:            m["showNoStandupTime"],
-		ShowStandupTime:              m["showStandupTime"],
-		WrongNArgs:                   m["wrongNArgs"],
-		Worklogs:                     m["Worklogs"],
-		NoCommits:                    m["noCommits"],
-		NoStandup:                    m["noStandup"],
-		WorklogsTime:                 m["WorklogsTime"],
-		HasCommits:                   m["hasCommits"],
-		HasStandup:                   m["hasStandup"],
-		IsRook:                       m["isRook"],
-		NotifyAllDone:                m["notifyAllDone"],
-		NotifyNotAll:                 m["notifyNotAll"],
-		NotifyManagerNotAll:          m["notifyManagerNotAll"],
-		NotifyUsersWarning:           m["notifyUsersWarning"],
-		NotifyDirectMessage:          m["notifyDirectMessage"],
-		ReportByProjectAndUser:       m["reportByProjectAndUser"],
-		ReportOnProjectHead:          m["reportOnProjectHead"],
-		ReportOnProjectCollectorData: m["reportOnProjectCollectorData"],
-		ReportOnUserHead:             m["reportOnUserHead"],
-		ReportOnProjectAndUserHead:   m["reportOnProjectAndUserHead"],
-		ReportNoData:                 m["reportNoData"],
-		ReportDate:                   m["reportDate"],
-		ReportStandupFromUser:        m["reportStandupFromUser"],
-		ReportIgnoredStandup:         m["reportIgnoredStandup"],
-		ReportShowChannel:            m["reportShowChannel"],
-		ReportCollectorDataUser:      m["reportCollectorDataUser"],
-		DateError1:                   m["dateError1"],
-		DateError2:                   m["dateError2"],
-		HelloManager:                 m["helloManager"],
-		StandupAccepted:              m["standupAccepted"],
-		UserDidNotStandup:            m["userDidNotStandup"],
-		UserDidStandup:               m["userDidStandup"],
-		UserDidNotStandupInChannel:   m["userDidNotStandupInChannel"],
-		UserDidStandupInChannel:      m["userDidStandupInChannel"],
-		PMAssigned:                   m["PMAssigned"],
-		PMRemoved:                    m["PMRemoved"],
-
-		WrongUsernameError: m["wrongUsernameError"],
-
-		SelectUsersToAdd:        m["selectUsersToAdd"],
-		SelectUsersToDelete:     m["selectUsersToDelete"],
-		CanNotFindMember:        m["CanNotFindMember"],
-		SelectUsersToAddAsAdmin: m["selectUsersToAddAsAdmin"],
-		NoSuchUserInWorkspace:   m["noSuchUserInWorkspace"],
-		UserNotAdmin:            m["userNotAdmin"],
-		WrongProjectName:        m["wrongProjectName"],
-
-		DaysDivider:                 m["DaysDivider"],
-		TimeDivider:                 m["TimeDivider"],
-		TimetableNoUsers:            m["TimetableNoUsers"],
-		TimetableCreated:            m["TimetableCreated"],
-		TimetableUpdated:            m["TimetableUpdated"],
-		CanNotUpdateTimetable:       m["CanNotUpdateTimetable"],
-		NotAStanduper:               m["NotAStanduper"],
-		NoTimetableSet:              m["NoTimetableSet"],
-		TimetableShow:               m["TimetableShow"],
-		CanNotDeleteTimetable:       m["CanNotDeleteTimetable"],
-		TimetableDeleted:            m["TimetableDeleted"],
-		IndividualStandupersWarning: m["IndividualStandupersWarning"],
-		IndividualStandupersLate:    m["IndividualStandupersLate"],
-		EmptyTimetable:              m["EmptyTimetable"],
-		TimetableShowMonday:         m["TimetableShowMonday"],
-		TimetableShowTuesday:        m["TimetableShowTuesday"],
-		TimetableShowWednesday:      m["TimetableShowWednesday"],
-		TimetableShowThursday:       m["TimetableShowThursday"],
-		TimetableShowFriday:         m["TimetableShowFriday"],
-		TimetableShowSaturday:       m["TimetableShowSaturday"],
-		TimetableShowSunday:         m["TimetableShowSunday"],
-		ComedianIsNotInChannel:      m["ComedianIsNotInChannel"],
-
-		StandupHandleUserNotAssigned:          m["StandupHandleUserNotAssigned"],
-		StandupHandleOneDayOneStandup:         m["StandupHandleOneDayOneStandup"],
-		StandupHandleCouldNotSaveStandup:      m["StandupHandleCouldNotSaveStandup"],
-		StandupHandleNoProblemsMentioned:      m["StandupHandleNoProblemsMentioned"],
-		StandupHandleNoYesterdayWorkMentioned: m["StandupHandleNoYesterdayWorkMentioned"],
-		StandupHandleNoTodayPlansMentioned:    m["StandupHandleNoTodayPlansMentioned"],
-		StandupHandleUpdatedStandup:           m["StandupHandleUpdatedStandup"],
-		StandupHandleCreatedStandup:           m["StandupHandleCreatedStandup"],
-
-		ErrorRooksReportWeekend: m["ErrorRooksReportWeekend"],
-		ReportHeaderMonday:      m["ReportHeaderMonday"],
-		ReportHeader:            m["ReportHeader"],
-		ReportHeaderWeekly:      m["ReportHeaderWeekly"],
-
-		AccessAtLeastPM:           m["AccessAtLeastPM"],
-		AccessAtLeastAdmin:        m["AccessAtLeastAdmin"],
-		AccessAtLeastSuperAdmin:   m["AccessAtLeastSuperAdmin"],
-		AccessAtLeastAdminOrOwner: m["AccessAtLeastAdminOrOwner"],
-		AccessAtLeastPMOrOwner:    m["AccessAtLeastPMOrOwner"],
-
-		NeedCorrectUserRole:  m["NeedCorrectUserRole"],
-		SomethingWentWrong:   m["SomethingWentWrong"],
-		HelpCommand:          m["HelpCommand"],
-		EmptyReportForSunday: m["EmptyReportForSunday"],
+// Plural returns plural message with the form for the count, like "1 day" or "5 days"
+func (t Translate) Plural(messageID string, count interface{}) string {
+	if t.localizer == nil {
+		return fmt.Sprint(count)
 	}
-
-	return t, nil
+	translated, err := t.localizer.Localize(&i18n.LocalizeConfig{
+		MessageID:    messageID,
+		PluralCount:  count,
+		TemplateData: map[string]interface{}{"Count": count},
+	})
+	if err != nil {
+		logrus.Errorf("config: Localize failed: %v\n", err)
+		return fmt.Sprint(count)
+	}
+	return translated
 }
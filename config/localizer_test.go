@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// formatVerbs returns format verbs of the message in order, %s and %v are interchangeable
+func formatVerbs(message string) []string {
+	verbs := formatVerb.FindAllString(message, -1)
+	for i, verb := range verbs {
+		if strings.HasSuffix(verb, "s") {
+			verbs[i] = strings.TrimSuffix(verb, "s") + "v"
+		}
+	}
+	return verbs
+}
+
+// messageIDs returns ids of Translate fields followed by ids of plural messages
+func messageIDs() []string {
+	ids := []string{}
+	typ := reflect.TypeOf(Translate{})
+	for i := 0; i < typ.NumField(); i++ {
+		if typ.Field(i).Type.Kind() == reflect.String {
+			ids = append(ids, typ.Field(i).Name)
+		}
+	}
+	return append(ids, PluralDays, PluralMinutes, PluralCharacters)
+}
+
+func TestLocales(t *testing.T) {
+	locales := Locales()
+	for _, locale := range []string{"de", "en", "kk", "ru", "uk"} {
+		assert.Contains(t, locales, locale)
+	}
+	assert.Equal(t, locales, Languages)
+}
+
+func TestMessageFiles(t *testing.T) {
+	messages := map[string]map[string]*i18n.Message{}
+	for _, locale := range Locales() {
+		file, err := parseMessageFile(newBundle(), locale)
+		if !assert.NoError(t, err, locale) {
+			continue
+		}
+		messages[locale] = map[string]*i18n.Message{}
+		for _, m := range file.Messages {
+			messages[locale][m.ID] = m
+		}
+	}
+
+	ids := messageIDs()
+	known := map[string]bool{}
+	for _, id := range ids {
+		known[id] = true
+	}
+	for locale, localeMessages := range messages {
+		for _, id := range ids {
+			m, ok := localeMessages[id]
+			if !assert.True(t, ok, "%v: missing key %v", locale, id) {
+				continue
+			}
+			assert.NotEmpty(t, m.Other, "%v: empty message %v", locale, id)
+			assert.Equal(t, formatVerbs(messages["en"][id].Other), formatVerbs(m.Other), "%v: format verbs of %v differ from English", locale, id)
+		}
+		for id := range localeMessages {
+			assert.True(t, known[id], "%v: unknown key %v", locale, id)
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for _, locale := range Locales() {
+		tr, err := GetTranslation(locale)
+		assert.NoError(t, err)
+		for _, id := range []string{PluralDays, PluralMinutes, PluralCharacters} {
+			for _, count := range []int{0, 1, 2, 5, 11, 21, 22, 25} {
+				text := tr.Plural(id, count)
+				assert.True(t, strings.HasPrefix(text, fmt.Sprint(count)+" "), "%v: %v for %v is %q", locale, id, count, text)
+			}
+		}
+	}
+
+	en, err := GetTranslation("en")
+	assert.NoError(t, err)
+	assert.Equal(t, "1 day", en.Plural(PluralDays, 1))
+	assert.Equal(t, "5 days", en.Plural(PluralDays, 5))
+
+	ru, err := GetTranslation("ru")
+	assert.NoError(t, err)
+	assert.Equal(t, "1 день", ru.Plural(PluralDays, 1))
+	assert.Equal(t, "3 дня", ru.Plural(PluralDays, 3))
+	assert.Equal(t, "5 дней", ru.Plural(PluralDays, 5))
+	assert.Equal(t, "21 день", ru.Plural(PluralDays, 21))
+
+	assert.Equal(t, "7", Translate{}.Plural(PluralDays, 7))
+}
@@ -0,0 +1,528 @@
+// Package fakeslack implements a local fake of Slack Web API and RTM used for end-to-end testing
+package fakeslack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+)
+
+// Message is a message posted to the fake Slack by the bot
+type Message struct {
+	Method      string
+	Channel     string
+	User        string
+	Text        string
+	ThreadTS    string
+	Attachments []slack.Attachment
+	TS          string
+}
+
+// Reaction is a reaction added by the bot
+type Reaction struct {
+	Name      string
+	Channel   string
+	Timestamp string
+}
+
+// File is a file uploaded by the bot
+type File struct {
+	Channels []string
+	Filename string
+	Filetype string
+	Title    string
+	Content  string
+}
+
+// Channel is a channel of the fake workspace
+type Channel struct {
+	ID         string
+	Name       string
+	Members    []string
+	IsArchived bool
+}
+
+// UserGroup is a user group of the fake workspace
+type UserGroup struct {
+	ID      string
+	Handle  string
+	Members []string
+}
+
+// Server is a fake Slack workspace served over HTTP
+type Server struct {
+	BotID   string
+	BotName string
+
+	server    *httptest.Server
+	mu        sync.Mutex
+	users     []slack.User
+	channels  map[string]*Channel
+	groups    []*UserGroup
+	messages  []Message
+	reactions []Reaction
+	files     []File
+	conns     []*websocket.Conn
+	limited   map[string]time.Duration
+	lastTS    int64
+	connected chan struct{}
+}
+
+// NewServer creates and starts a fake Slack server with a bot user
+func NewServer(botID, botName string) *Server {
+	s := &Server{
+		BotID:     botID,
+		BotName:   botName,
+		channels:  map[string]*Channel{},
+		limited:   map[string]time.Duration{},
+		connected: make(chan struct{}, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/rtm.connect", s.rtmConnect)
+	mux.HandleFunc("/api/chat.postMessage", s.postMessage)
+	mux.HandleFunc("/api/chat.postEphemeral", s.postEphemeral)
+	mux.HandleFunc("/api/chat.getPermalink", s.getPermalink)
+	mux.HandleFunc("/api/im.open", s.imOpen)
+	mux.HandleFunc("/api/users.list", s.usersList)
+	mux.HandleFunc("/api/reactions.add", s.reactionsAdd)
+	mux.HandleFunc("/api/conversations.info", s.conversationsInfo)
+	mux.HandleFunc("/api/conversations.members", s.conversationsMembers)
+	mux.HandleFunc("/api/usergroups.list", s.userGroupsList)
+	mux.HandleFunc("/api/usergroups.users.list", s.userGroupsUsersList)
+	mux.HandleFunc("/api/auth.test", s.authTest)
+	mux.HandleFunc("/api/files.upload", s.filesUpload)
+	mux.HandleFunc("/response", s.response)
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "unknown_method"})
+	})
+	mux.HandleFunc("/rtm", s.rtm)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// APIURL returns base URL of the Web API, it should be assigned to slack.SLACK_API
+func (s *Server) APIURL() string {
+	return s.server.URL + "/api/"
+}
+
+// ResponseURL returns URL to be passed as response_url of slash commands
+func (s *Server) ResponseURL() string {
+	return s.server.URL + "/response"
+}
+
+// Close stops the server and closes RTM connections
+func (s *Server) Close() {
+	s.mu.Lock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.server.Close()
+}
+
+// AddUser adds a user to the workspace
+func (s *Server) AddUser(user slack.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = append(s.users, user)
+}
+
+// AddChannel adds a channel with members to the workspace
+func (s *Server) AddChannel(id, name string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channels[id] = &Channel{ID: id, Name: name, Members: members}
+}
+
+// AddUserGroup adds a user group with members to the workspace, adding it again replaces its members
+func (s *Server) AddUserGroup(id, handle string, members ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.groups {
+		if g.ID == id {
+			g.Handle = handle
+			g.Members = members
+			return
+		}
+	}
+	s.groups = append(s.groups, &UserGroup{ID: id, Handle: handle, Members: members})
+}
+
+// RateLimit makes the next call of the method fail with 429 and Retry-After header
+func (s *Server) RateLimit(method string, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limited[method] = retryAfter
+}
+
+// WaitForConnection blocks until the bot connects to RTM or timeout expires
+func (s *Server) WaitForConnection(timeout time.Duration) bool {
+	select {
+	case <-s.connected:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// SendEvent sends an event to every RTM connection
+func (s *Server) SendEvent(event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SendMessage sends message event from user to the channel and returns its timestamp
+func (s *Server) SendMessage(channel, user, text string) (string, error) {
+	ts := s.nextTS()
+	return ts, s.SendEvent(map[string]string{
+		"type":    "message",
+		"channel": channel,
+		"user":    user,
+		"text":    text,
+		"ts":      ts,
+	})
+}
+
+// JoinChannel adds user to the channel and sends member_joined_channel event
+func (s *Server) JoinChannel(channel, user string) error {
+	s.mu.Lock()
+	if c, ok := s.channels[channel]; ok {
+		c.Members = append(c.Members, user)
+	}
+	s.mu.Unlock()
+	return s.SendEvent(map[string]string{
+		"type":    "member_joined_channel",
+		"channel": channel,
+		"user":    user,
+	})
+}
+
+// Messages returns messages posted by the bot
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.messages...)
+}
+
+// Reactions returns reactions added by the bot
+func (s *Server) Reactions() []Reaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Reaction{}, s.reactions...)
+}
+
+// Files returns files uploaded by the bot
+func (s *Server) Files() []File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]File{}, s.files...)
+}
+
+// WaitForMessage polls posted messages until one of them matches or timeout expires
+func (s *Server) WaitForMessage(timeout time.Duration, match func(Message) bool) (Message, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, m := range s.Messages() {
+			if match(m) {
+				return m, true
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return Message{}, false
+}
+
+// WaitForReaction polls added reactions until one on the message appears or timeout expires
+func (s *Server) WaitForReaction(timeout time.Duration, timestamp string) (Reaction, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, r := range s.Reactions() {
+			if r.Timestamp == timestamp {
+				return r, true
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return Reaction{}, false
+}
+
+func (s *Server) nextTS() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ts := time.Now().UnixNano() / 1000
+	if ts <= s.lastTS {
+		ts = s.lastTS + 1
+	}
+	s.lastTS = ts
+	return fmt.Sprintf("%d.%06d", ts/1000000, ts%1000000)
+}
+
+// rateLimited responds with 429 if the method was asked to be rate limited
+func (s *Server) rateLimited(w http.ResponseWriter, method string) bool {
+	s.mu.Lock()
+	retryAfter, ok := s.limited[method]
+	delete(s.limited, method)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())))
+	w.WriteHeader(http.StatusTooManyRequests)
+	return true
+}
+
+func (s *Server) rtmConnect(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ok":   true,
+		"url":  "ws" + strings.TrimPrefix(s.server.URL, "http") + "/rtm",
+		"self": map[string]string{"id": s.BotID, "name": s.BotName},
+		"team": map[string]string{"id": "TFAKE", "name": "fake", "domain": "fake"},
+	})
+}
+
+func (s *Server) rtm(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn.WriteJSON(map[string]string{"type": "hello"})
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+	select {
+	case s.connected <- struct{}{}:
+	default:
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		ping := struct {
+			ID        int    `json:"id"`
+			Type      string `json:"type"`
+			Timestamp int64  `json:"timestamp"`
+		}{}
+		if json.Unmarshal(data, &ping) != nil || ping.Type != "ping" {
+			continue
+		}
+		s.mu.Lock()
+		conn.WriteJSON(map[string]interface{}{"type": "pong", "reply_to": ping.ID, "timestamp": ping.Timestamp})
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) postMessage(w http.ResponseWriter, r *http.Request) {
+	if s.rateLimited(w, "chat.postMessage") {
+		return
+	}
+	m := Message{
+		Method:   "chat.postMessage",
+		Channel:  r.FormValue("channel"),
+		Text:     r.FormValue("text"),
+		ThreadTS: r.FormValue("thread_ts"),
+		TS:       s.nextTS(),
+	}
+	if attachments := r.FormValue("attachments"); attachments != "" {
+		json.Unmarshal([]byte(attachments), &m.Attachments)
+	}
+	s.mu.Lock()
+	s.messages = append(s.messages, m)
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"ok": true, "channel": m.Channel, "ts": m.TS})
+}
+
+func (s *Server) postEphemeral(w http.ResponseWriter, r *http.Request) {
+	if s.rateLimited(w, "chat.postEphemeral") {
+		return
+	}
+	m := Message{
+		Method:  "chat.postEphemeral",
+		Channel: r.FormValue("channel"),
+		User:    r.FormValue("user"),
+		Text:    r.FormValue("text"),
+		TS:      s.nextTS(),
+	}
+	s.mu.Lock()
+	s.messages = append(s.messages, m)
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"ok": true, "message_ts": m.TS})
+}
+
+func (s *Server) imOpen(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ok":      true,
+		"channel": map[string]string{"id": "D" + r.FormValue("user")},
+	})
+}
+
+func (s *Server) usersList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	users := append([]slack.User{}, s.users...)
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"members":           users,
+		"response_metadata": map[string]string{"next_cursor": ""},
+	})
+}
+
+func (s *Server) reactionsAdd(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.reactions = append(s.reactions, Reaction{
+		Name:      r.FormValue("name"),
+		Channel:   r.FormValue("channel"),
+		Timestamp: r.FormValue("timestamp"),
+	})
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"ok": true})
+}
+
+func (s *Server) conversationsInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c, ok := s.channels[r.FormValue("channel")]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok": true,
+		"channel": map[string]interface{}{
+			"id":          c.ID,
+			"name":        c.Name,
+			"is_channel":  true,
+			"is_archived": c.IsArchived,
+			"is_member":   s.isMember(c, s.BotID),
+		},
+	})
+}
+
+func (s *Server) conversationsMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	c, ok := s.channels[r.FormValue("channel")]
+	var members []string
+	if ok {
+		members = append(members, c.Members...)
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"members":           members,
+		"response_metadata": map[string]string{"next_cursor": ""},
+	})
+}
+
+func (s *Server) userGroupsList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	groups := []slack.UserGroup{}
+	for _, g := range s.groups {
+		groups = append(groups, slack.UserGroup{ID: g.ID, Handle: g.Handle, Name: g.Handle, IsUserGroup: true, UserCount: len(g.Members)})
+	}
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{"ok": true, "usergroups": groups})
+}
+
+func (s *Server) userGroupsUsersList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.groups {
+		if g.ID == r.FormValue("usergroup") {
+			writeJSON(w, map[string]interface{}{"ok": true, "users": append([]string{}, g.Members...)})
+			return
+		}
+	}
+	writeJSON(w, map[string]interface{}{"ok": false, "error": "no_such_subteam"})
+}
+
+func (s *Server) authTest(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]interface{}{
+		"ok":      true,
+		"user_id": s.BotID,
+		"user":    s.BotName,
+		"team_id": "TFAKE",
+		"team":    "fake",
+	})
+}
+
+func (s *Server) getPermalink(w http.ResponseWriter, r *http.Request) {
+	channel := r.FormValue("channel")
+	writeJSON(w, map[string]interface{}{
+		"ok":        true,
+		"channel":   channel,
+		"permalink": fmt.Sprintf("https://fake.slack.com/archives/%v/p%v", channel, strings.Replace(r.FormValue("message_ts"), ".", "", -1)),
+	})
+}
+
+func (s *Server) filesUpload(w http.ResponseWriter, r *http.Request) {
+	f := File{
+		Channels: strings.Split(r.FormValue("channels"), ","),
+		Filename: r.FormValue("filename"),
+		Filetype: r.FormValue("filetype"),
+		Title:    r.FormValue("title"),
+		Content:  r.FormValue("content"),
+	}
+	s.mu.Lock()
+	s.files = append(s.files, f)
+	id := fmt.Sprintf("F%d", len(s.files))
+	s.mu.Unlock()
+	writeJSON(w, map[string]interface{}{
+		"ok":   true,
+		"file": map[string]interface{}{"id": id, "name": f.Filename, "title": f.Title, "filetype": f.Filetype},
+	})
+}
+
+// response records messages posted to response_url, they are stored with "response_url" method
+func (s *Server) response(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		ResponseType string `json:"response_type"`
+		Text         string `json:"text"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.messages = append(s.messages, Message{Method: "response_url", Text: payload.Text})
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) isMember(c *Channel, userID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, member := range c.Members {
+		if member == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
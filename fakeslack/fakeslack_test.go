@@ -0,0 +1,101 @@
+package fakeslack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	server := NewServer("UBOT", "comedian")
+	defer server.Close()
+	slack.SLACK_API = server.APIURL()
+	defer func() { slack.SLACK_API = "https://slack.com/api/" }()
+
+	server.AddUser(slack.User{ID: "UDEV", Name: "dev"})
+	server.AddChannel("CGENERAL", "general", "UBOT", "UDEV")
+	api := slack.New("xoxb-fake")
+
+	users, err := api.GetUsers()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(users))
+
+	channel, err := api.GetConversationInfo("CGENERAL", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "general", channel.Name)
+	assert.Equal(t, true, channel.IsMember)
+	_, err = api.GetConversationInfo("CUNKNOWN", false)
+	assert.Error(t, err)
+
+	members, _, err := api.GetUsersInConversation(&slack.GetUsersInConversationParameters{ChannelID: "CGENERAL"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UBOT", "UDEV"}, members)
+
+	server.AddUserGroup("SDEVS", "devs", "UDEV")
+	groups, err := api.GetUserGroups()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, "devs", groups[0].Handle)
+	groupMembers, err := api.GetUserGroupMembers("SDEVS")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UDEV"}, groupMembers)
+	_, err = api.GetUserGroupMembers("SUNKNOWN")
+	assert.Error(t, err)
+
+	_, _, imID, err := api.OpenIMChannel("UDEV")
+	assert.NoError(t, err)
+	assert.Equal(t, "DUDEV", imID)
+
+	_, ts, err := api.PostMessage("CGENERAL", "Hello!", slack.PostMessageParameters{})
+	assert.NoError(t, err)
+	_, err = api.PostEphemeral("CGENERAL", "UDEV", slack.MsgOptionText("Psst!", true))
+	assert.NoError(t, err)
+	assert.NoError(t, api.AddReaction("heavy_check_mark", slack.ItemRef{Channel: "CGENERAL", Timestamp: ts}))
+
+	messages := server.Messages()
+	assert.Equal(t, 2, len(messages))
+	assert.Equal(t, "Hello!", messages[0].Text)
+	assert.Equal(t, "UDEV", messages[1].User)
+	_, ok := server.WaitForReaction(time.Second, ts)
+	assert.Equal(t, true, ok)
+
+	_, err = api.UploadFile(slack.FileUploadParameters{Content: "a,b", Filetype: "csv", Filename: "report.csv", Channels: []string{"DUDEV"}})
+	assert.NoError(t, err)
+	files := server.Files()
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, "a,b", files[0].Content)
+	assert.Equal(t, []string{"DUDEV"}, files[0].Channels)
+
+	server.RateLimit("chat.postMessage", time.Second)
+	_, _, err = api.PostMessage("CGENERAL", "Too fast", slack.PostMessageParameters{})
+	rle, ok := err.(*slack.RateLimitedError)
+	assert.Equal(t, true, ok)
+	if ok {
+		assert.Equal(t, time.Second, rle.RetryAfter)
+	}
+
+	rtm := api.NewRTM()
+	go rtm.ManageConnection()
+	defer rtm.Disconnect()
+	assert.Equal(t, true, server.WaitForConnection(5*time.Second))
+
+	ts, err = server.SendMessage("CGENERAL", "UDEV", "<@UBOT> hi")
+	assert.NoError(t, err)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-rtm.IncomingEvents:
+			msg, ok := event.Data.(*slack.MessageEvent)
+			if !ok {
+				continue
+			}
+			assert.Equal(t, ts, msg.Timestamp)
+			assert.Equal(t, "<@UBOT> hi", msg.Text)
+			return
+		case <-timeout:
+			t.Fatal("message event was not received")
+		}
+	}
+}
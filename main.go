@@ -37,5 +37,7 @@ func main() {
 
 	go func() { log.Fatal(notifier.Start()) }()
 
+	go slack.WatchConfig()
+
 	slack.Run()
 }
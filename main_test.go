@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maddevsio/comedian/api"
+	"github.com/maddevsio/comedian/chat"
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/fakeslack"
+	"github.com/maddevsio/comedian/notifier"
+	"github.com/maddevsio/comedian/reporting"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndToEnd(t *testing.T) {
+	server := fakeslack.NewServer("UBOT", "comedian")
+	defer server.Close()
+	slack.SLACK_API = server.APIURL()
+	defer func() { slack.SLACK_API = "https://slack.com/api/" }()
+
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.HTTPBindAddr = freeAddr(t)
+	c.ReportTime = "00:00"
+
+	server.AddUser(slack.User{ID: c.ManagerSlackUserID, Name: "manager", IsAdmin: true})
+	server.AddUser(slack.User{ID: "UDEVONE", Name: "devone"})
+	server.AddUser(slack.User{ID: "UDEVTWO", Name: "devtwo"})
+	server.AddUser(slack.User{ID: "UDEVTHREE", Name: "devthree"})
+	server.AddChannel("CENDTOEND", "endtoend", "UDEVONE", "UDEVTWO", "UDEVTHREE")
+	server.AddUserGroup("SQA", "qa", "UDEVTHREE")
+	server.AddUserGroup("SBACKEND", "backend", "UDEVONE", "UDEVTWO")
+
+	s, err := chat.NewSlack(c)
+	assert.NoError(t, err)
+	rest, err := api.NewRESTAPI(s)
+	assert.NoError(t, err)
+	go rest.Start()
+	assert.Equal(t, true, eventually(func() bool {
+		conn, err := net.Dial("tcp", c.HTTPBindAddr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}))
+	n, err := notifier.NewNotifier(s)
+	assert.NoError(t, err)
+	go s.Run()
+	assert.Equal(t, true, server.WaitForConnection(5*time.Second))
+
+	// bot joins the channel
+	assert.NoError(t, server.JoinChannel("CENDTOEND", "UBOT"))
+	assert.Equal(t, true, eventually(func() bool {
+		_, err := s.DB.SelectChannel("CENDTOEND")
+		return err == nil
+	}))
+	channel, err := s.DB.SelectChannel("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, "endtoend", channel.ChannelName)
+
+	// manager adds members
+	text := command(t, c.HTTPBindAddr, "/add", "<@UDEVONE|devone> <@UDEVTWO|devtwo>", c.ManagerSlackUserID)
+	assert.Contains(t, text, "devone")
+	members, err := s.DB.ListChannelMembers("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(members))
+
+	// user group is expanded to its members
+	text = command(t, c.HTTPBindAddr, "/add", "<!subteam^SQA|@qa>", c.ManagerSlackUserID)
+	assert.Contains(t, text, "devthree")
+	members, err = s.DB.ListChannelMembers("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(members))
+
+	// syncing with a user group shows changes first and applies them when confirmed
+	text = command(t, c.HTTPBindAddr, "/sync_members", "@backend", c.ManagerSlackUserID)
+	assert.Contains(t, text, "<@UDEVTHREE>")
+	members, err = s.DB.ListChannelMembers("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(members))
+	text = command(t, c.HTTPBindAddr, "/sync_members", "@backend apply", c.ManagerSlackUserID)
+	assert.Contains(t, text, "<@UDEVTHREE>")
+	members, err = s.DB.ListChannelMembers("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(members))
+	_, err = s.DB.FindChannelMemberByUserID("UDEVTHREE", "CENDTOEND")
+	assert.Error(t, err)
+
+	// group which lost all its members does not empty the channel
+	server.AddUserGroup("SBACKEND", "backend")
+	s.ReconcileMemberSyncs()
+	members, err = s.DB.ListChannelMembers("CENDTOEND")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(members))
+	assert.NoError(t, s.DB.DeleteMemberSync("CENDTOEND"))
+
+	// member posts standup
+	ts, err := server.SendMessage("CENDTOEND", "UDEVONE", "<@UBOT> yesterday: fixed bugs, today: write tests, problems: none")
+	assert.NoError(t, err)
+	_, ok := server.WaitForReaction(10*time.Second, ts)
+	assert.Equal(t, true, ok)
+	standup, err := s.DB.SelectStandupByMessageTS(ts)
+	assert.NoError(t, err)
+
+	// the one who did not write standup is reminded
+	n.SendWarning("CENDTOEND")
+	_, ok = server.WaitForMessage(5*time.Second, func(m fakeslack.Message) bool {
+		return m.Channel == "CENDTOEND" && strings.Contains(m.Text, "<@UDEVTWO>") && !strings.Contains(m.Text, "<@UDEVONE>")
+	})
+	assert.Equal(t, true, ok)
+
+	// the daily report is posted to the channel and to the reporting channel on the next day,
+	// so the standup is moved to yesterday and reports are due since midnight
+	db, err := sqlx.Open("mysql", c.DatabaseURL)
+	assert.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("UPDATE `standups` SET created=? WHERE id=?", time.Now().UTC().AddDate(0, 0, -1), standup.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, s.DB.UpdateReportSent("CENDTOEND", time.Now().AddDate(0, 0, -1)))
+	assert.NoError(t, s.DB.UpdateReportSent("", time.Now().AddDate(0, 0, -1)))
+	reporting.NewReporter(s).DisplayTeamReportsOnTime()
+
+	report, ok := server.WaitForMessage(5*time.Second, isDailyReport(c, "CENDTOEND"))
+	assert.Equal(t, true, ok)
+	assert.Equal(t, c.Translate.ReportHeader, report.Text)
+	devOne := attachment(report, fmt.Sprintf(c.Translate.IsRook, "UDEVONE", "endtoend"))
+	if assert.Equal(t, 1, len(devOne.Fields)) {
+		assert.Contains(t, devOne.Fields[0].Value, c.Translate.HasStandup)
+	}
+	assert.Equal(t, "good", devOne.Color)
+	_, ok = server.WaitForMessage(5*time.Second, isDailyReport(c, c.ReportingChannel))
+	assert.Equal(t, true, ok)
+
+	assert.NoError(t, s.DB.DeleteStandup(standup.ID))
+	for _, member := range members {
+		assert.NoError(t, s.DB.DeleteChannelMember(member.UserID, member.ChannelID))
+	}
+	assert.NoError(t, s.DB.DeleteChannel(channel.ID))
+}
+
+// isDailyReport matches the daily report posted to the channel which mentions UDEVONE from #endtoend
+func isDailyReport(c config.Config, channel string) func(fakeslack.Message) bool {
+	return func(m fakeslack.Message) bool {
+		return m.Channel == channel && m.Text == c.Translate.ReportHeader &&
+			attachment(m, fmt.Sprintf(c.Translate.IsRook, "UDEVONE", "endtoend")).Text != ""
+	}
+}
+
+// attachment returns attachment of the message with the text
+func attachment(m fakeslack.Message, text string) slack.Attachment {
+	for _, a := range m.Attachments {
+		if a.Text == text {
+			return a
+		}
+	}
+	return slack.Attachment{}
+}
+
+func command(t *testing.T, addr, command, text, userID string) string {
+	resp, err := http.PostForm("http://"+addr+"/commands", url.Values{
+		"command":      {command},
+		"text":         {text},
+		"channel_id":   {"CENDTOEND"},
+		"channel_name": {"endtoend"},
+		"user_id":      {userID},
+	})
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func eventually(condition func() bool) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package metrics
+
+import "time"
+
+var (
+	jobRuns     = NewCounter("comedian_scheduler_job_runs_total", "Runs of scheduled jobs.", "job")
+	jobDuration = NewHistogram("comedian_scheduler_job_duration_seconds", "Duration of scheduled jobs in seconds.", DefaultBuckets, "job")
+)
+
+// Job wraps scheduled job to count its runs and measure how long they take
+func Job(name string, job func()) func() {
+	return func() {
+		start := time.Now()
+		defer func() {
+			jobRuns.Inc(name)
+			jobDuration.ObserveSince(start, name)
+		}()
+		job()
+	}
+}
@@ -0,0 +1,252 @@
+// Package metrics collects counters, gauges and histograms and exposes them in Prometheus text format
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are histogram buckets in seconds suitable for request and query latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Types of metrics
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry holds metrics and writes them in Prometheus text exposition format
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// DefaultRegistry is registry metrics created by package functions belong to
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]*metric{}}
+}
+
+// metric is a family of series of the same name which differ in label values
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts of observations per bucket, the last one is +Inf
+	counts []uint64
+	sum    float64
+}
+
+// Counter is a metric which only goes up
+type Counter struct{ m *metric }
+
+// Gauge is a metric which can be set to any value
+type Gauge struct{ m *metric }
+
+// Histogram counts observations in buckets
+type Histogram struct{ m *metric }
+
+// NewCounter creates counter in the default registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labels...)
+}
+
+// NewGauge creates gauge in the default registry
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labels...)
+}
+
+// NewHistogram creates histogram in the default registry
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labels...)
+}
+
+// NewCounter creates counter in the registry
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, typeCounter, nil, labels)}
+}
+
+// NewGauge creates gauge in the registry
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, typeGauge, nil, labels)}
+}
+
+// NewHistogram creates histogram in the registry. Buckets are upper bounds sorted in increasing order
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.register(name, help, typeHistogram, buckets, labels)}
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	m := &metric{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.metrics[name] = m
+	return m
+}
+
+// with returns series with the label values, creating it if needed. Caller holds m.mu
+func (m *metric) with(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %v expects %v label values, got %v", m.name, len(m.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string{}, labelValues...)}
+		if m.kind == typeHistogram {
+			s.counts = make([]uint64, len(m.buckets)+1)
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Inc increments counter with the label values by one
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases counter with the label values by v, which must not be negative
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	c.m.with(labelValues).value += v
+}
+
+// Set sets gauge with the label values to v
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.with(labelValues).value = v
+}
+
+// Reset removes all series of the gauge, so label values which are gone are not exposed anymore
+func (g *Gauge) Reset() {
+	g.m.mu.Lock()
+	defer g.m.mu.Unlock()
+	g.m.series = map[string]*series{}
+}
+
+// Observe adds observation v to histogram with the label values
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.with(labelValues)
+	i := sort.SearchFloat64s(h.m.buckets, v)
+	s.counts[i]++
+	s.sum += v
+}
+
+// ObserveSince adds time passed since start in seconds to histogram with the label values
+func (h *Histogram) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// WriteTo writes all metrics of the registry in Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	r.mu.Unlock()
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		r.mu.Lock()
+		m := r.metrics[name]
+		r.mu.Unlock()
+		m.write(&b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (m *metric) write(b *strings.Builder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(b, "# HELP %v %v\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(b, "# TYPE %v %v\n", m.name, m.kind)
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.kind != typeHistogram {
+			fmt.Fprintf(b, "%v%v %v\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatValue(s.value))
+			continue
+		}
+		var count uint64
+		for i, upper := range m.buckets {
+			count += s.counts[i]
+			fmt.Fprintf(b, "%v_bucket%v %v\n", m.name, formatLabels(m.labels, s.labelValues, "le", formatValue(upper)), count)
+		}
+		count += s.counts[len(m.buckets)]
+		fmt.Fprintf(b, "%v_bucket%v %v\n", m.name, formatLabels(m.labels, s.labelValues, "le", "+Inf"), count)
+		fmt.Fprintf(b, "%v_sum%v %v\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%v_count%v %v\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), count)
+	}
+}
+
+// formatLabels renders labels like {name="value",le="0.5"}, extra label is added when its name is not empty
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	pairs := []string{}
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(v)
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	commands := r.NewCounter("test_commands_total", "Slash commands.", "command", "result")
+	commands.Inc("add", "ok")
+	commands.Inc("add", "ok")
+	commands.Add(3, "list", "denied")
+	nonReporters := r.NewGauge("test_non_reporters", "Non reporters.", "channel")
+	nonReporters.Set(2, `back"end`)
+	latency := r.NewHistogram("test_latency_seconds", "Latency.\nIn seconds.", []float64{0.1, 1})
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	var b strings.Builder
+	_, err := r.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP test_commands_total Slash commands.
+# TYPE test_commands_total counter
+test_commands_total{command="add",result="ok"} 2
+test_commands_total{command="list",result="denied"} 3
+# HELP test_latency_seconds Latency.\nIn seconds.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{le="0.1"} 1
+test_latency_seconds_bucket{le="1"} 2
+test_latency_seconds_bucket{le="+Inf"} 3
+test_latency_seconds_sum 5.55
+test_latency_seconds_count 3
+# HELP test_non_reporters Non reporters.
+# TYPE test_non_reporters gauge
+test_non_reporters{channel="back\"end"} 2
+`, b.String())
+
+	nonReporters.Reset()
+	b.Reset()
+	_, err = r.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.NotContains(t, b.String(), "back")
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("test_total", "Test.", "label")
+	assert.Panics(t, func() { r.NewCounter("test_total", "Test.") })
+	assert.Panics(t, func() { counter.Inc() })
+	assert.Panics(t, func() { counter.Add(-1, "value") })
+}
+
+func TestJob(t *testing.T) {
+	runs := 0
+	job := Job("test_job", func() { runs++ })
+	job()
+	job()
+	assert.Equal(t, 2, runs)
+
+	var b strings.Builder
+	_, err := DefaultRegistry.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `comedian_scheduler_job_runs_total{job="test_job"} 2`)
+}
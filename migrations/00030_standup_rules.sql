@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `standup_rules` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL,
+    `sections` TEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `min_length` INTEGER NOT NULL DEFAULT 0,
+    `ticket_pattern` VARCHAR(255) NOT NULL DEFAULT '',
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    UNIQUE KEY (`channel_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `standup_rules`;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `interviews` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL,
+    `user_id` VARCHAR(255) NOT NULL,
+    `step` INTEGER NOT NULL DEFAULT 0,
+    `answers` TEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    KEY (`user_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `interviews`;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `channels` ADD `threaded` BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `channels` DROP `threaded`;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `standup_threads` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL,
+    `thread_ts` VARCHAR(255) NOT NULL,
+    `created` DATETIME NOT NULL,
+    KEY (`thread_ts`),
+    KEY (`channel_id`, `created`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `standup_threads`;
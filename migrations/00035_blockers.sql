@@ -0,0 +1,22 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `blockers` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL,
+    `user_id` VARCHAR(255) NOT NULL,
+    `standup_id` INTEGER NOT NULL,
+    `message_ts` VARCHAR(255) NOT NULL,
+    `text` TEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `resolved` BOOLEAN NOT NULL DEFAULT FALSE,
+    `resolved_by` VARCHAR(255) NOT NULL DEFAULT '',
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    KEY (`channel_id`),
+    UNIQUE KEY (`standup_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `blockers`;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `outbound_messages` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `kind` VARCHAR(32) NOT NULL,
+    `channel_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `user_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `thread_ts` VARCHAR(255) NOT NULL DEFAULT '',
+    `text` TEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `attachments` TEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `status` VARCHAR(32) NOT NULL,
+    `attempts` INTEGER NOT NULL DEFAULT 0,
+    `last_error` VARCHAR(255) NOT NULL DEFAULT '',
+    `next_attempt` DATETIME NOT NULL,
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    KEY (`status`, `next_attempt`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `outbound_messages`;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `digests` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `period` VARCHAR(32) NOT NULL,
+    `time` VARCHAR(5) NOT NULL,
+    `recipients` VARCHAR(1024) NOT NULL,
+    `last_sent` DATETIME NOT NULL,
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `digests`;
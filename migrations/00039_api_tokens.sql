@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `api_tokens` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(255) NOT NULL,
+    `token_hash` VARCHAR(64) NOT NULL UNIQUE,
+    `role` VARCHAR(32) NOT NULL,
+    `channel_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `created` DATETIME NOT NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `api_tokens`;
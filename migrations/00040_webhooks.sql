@@ -0,0 +1,34 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `webhooks` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `url` VARCHAR(1024) NOT NULL,
+    `secret` VARCHAR(255) NOT NULL,
+    `events` VARCHAR(255) NOT NULL,
+    `channel_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `webhook_id` INTEGER NOT NULL,
+    `event` VARCHAR(64) NOT NULL,
+    `payload` MEDIUMTEXT COLLATE utf8mb4_unicode_ci NOT NULL,
+    `status` VARCHAR(32) NOT NULL,
+    `attempts` INTEGER NOT NULL DEFAULT 0,
+    `response_code` INTEGER NOT NULL DEFAULT 0,
+    `last_error` VARCHAR(255) NOT NULL DEFAULT '',
+    `next_attempt` DATETIME NOT NULL,
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    KEY (`status`, `next_attempt`),
+    KEY (`webhook_id`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `webhook_deliveries`;
+DROP TABLE `webhooks`;
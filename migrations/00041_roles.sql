@@ -0,0 +1,26 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `roles` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(64) NOT NULL UNIQUE,
+    `permissions` VARCHAR(1024) NOT NULL,
+    `created` DATETIME NOT NULL
+);
+
+CREATE TABLE `role_bindings` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id` VARCHAR(255) NOT NULL,
+    `role` VARCHAR(64) NOT NULL,
+    `channel_id` VARCHAR(255) NOT NULL DEFAULT '',
+    `created` DATETIME NOT NULL,
+    UNIQUE KEY (`user_id`, `role`, `channel_id`)
+);
+
+INSERT INTO `roles` (name, permissions, created) VALUES ('report_viewer', 'reports.own,reports.view', NOW());
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `role_bindings`;
+DROP TABLE `roles`;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `member_syncs` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL UNIQUE,
+    `usergroup_id` VARCHAR(255) NOT NULL,
+    `usergroup_handle` VARCHAR(255) NOT NULL,
+    `last_synced` DATETIME NOT NULL,
+    `created` DATETIME NOT NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `member_syncs`;
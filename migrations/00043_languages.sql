@@ -0,0 +1,9 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE `channels` ADD `language` VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD `language` VARCHAR(16) NOT NULL DEFAULT '';
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE `channels` DROP `language`;
+ALTER TABLE `users` DROP `language`;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `channel_settings` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `channel_id` VARCHAR(255) NOT NULL,
+    `name` VARCHAR(64) NOT NULL,
+    `value` VARCHAR(1024) NOT NULL,
+    `created` DATETIME NOT NULL,
+    `modified` DATETIME NOT NULL,
+    UNIQUE KEY `channel_setting` (`channel_id`, `name`)
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `channel_settings`;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+
+CREATE TABLE `reports_sent` (
+    `channel_id` VARCHAR(255) NOT NULL PRIMARY KEY,
+    `last_sent` DATETIME NOT NULL
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+
+DROP TABLE `reports_sent`;
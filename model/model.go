@@ -1,8 +1,11 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/maddevsio/comedian/config"
@@ -26,6 +29,7 @@ type (
 		UserName string `db:"user_name" json:"user_name"`
 		UserID   string `db:"user_id" json:"user_id"`
 		Role     string `db:"role" json:"role"`
+		Language string `db:"language" json:"language"`
 	}
 
 	// Channel model used for serialization/deserialization stored Channels
@@ -34,6 +38,10 @@ type (
 		ChannelName string `db:"channel_name" json:"channel_name"`
 		ChannelID   string `db:"channel_id" json:"channel_id"`
 		StandupTime int64  `db:"channel_standup_time" json:"time"`
+		Interview   bool   `db:"interview" json:"interview"`
+		Threaded    bool   `db:"threaded" json:"threaded"`
+		Archived    bool   `db:"archived" json:"archived"`
+		Language    string `db:"language" json:"language"`
 	}
 
 	// ChannelMember model used for serialization/deserialization stored ChannelMembers
@@ -68,6 +76,252 @@ type (
 		StandupID   int64     `db:"standup_id" json:"standupId"`
 		StandupText string    `db:"standuptext" json:"standuptext"`
 	}
+
+	// StandupRules model used for serialization/deserialization stored standup validation rules
+	StandupRules struct {
+		ID            int64     `db:"id" json:"id"`
+		ChannelID     string    `db:"channel_id" json:"channel_id"`
+		Sections      string    `db:"sections" json:"sections"`
+		MinLength     int       `db:"min_length" json:"min_length"`
+		TicketPattern string    `db:"ticket_pattern" json:"ticket_pattern"`
+		Created       time.Time `db:"created" json:"created"`
+		Modified      time.Time `db:"modified" json:"modified"`
+	}
+
+	// StandupSection is a part of standup that has to be mentioned in a message.
+	// Section is detected either by one of its keywords or by its regular expression
+	StandupSection struct {
+		Name     string   `json:"name"`
+		Keywords []string `json:"keywords,omitempty"`
+		Pattern  string   `json:"pattern,omitempty"`
+	}
+
+	// Interview model used for serialization/deserialization stored standup interviews held in direct messages
+	Interview struct {
+		ID        int64     `db:"id" json:"id"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		UserID    string    `db:"user_id" json:"user_id"`
+		Step      int       `db:"step" json:"step"`
+		Answers   string    `db:"answers" json:"answers"`
+		Created   time.Time `db:"created" json:"created"`
+		Modified  time.Time `db:"modified" json:"modified"`
+	}
+
+	// StandupThread model used for serialization/deserialization stored daily standup threads
+	StandupThread struct {
+		ID        int64     `db:"id" json:"id"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		ThreadTS  string    `db:"thread_ts" json:"thread_ts"`
+		Created   time.Time `db:"created" json:"created"`
+		// SummaryPosted is set once summary of the standups is posted into the thread
+		SummaryPosted bool `db:"summary_posted" json:"summary_posted"`
+	}
+
+	// Blocker model used for serialization/deserialization stored blockers reported in standups
+	Blocker struct {
+		ID         int64     `db:"id" json:"id"`
+		ChannelID  string    `db:"channel_id" json:"channel_id"`
+		UserID     string    `db:"user_id" json:"user_id"`
+		StandupID  int64     `db:"standup_id" json:"standup_id"`
+		MessageTS  string    `db:"message_ts" json:"message_ts"`
+		Text       string    `db:"text" json:"text"`
+		Resolved   bool      `db:"resolved" json:"resolved"`
+		ResolvedBy string    `db:"resolved_by" json:"resolved_by"`
+		Created    time.Time `db:"created" json:"created"`
+		Modified   time.Time `db:"modified" json:"modified"`
+	}
+
+	// OutboundMessage model used for serialization/deserialization stored messages waiting for delivery to Slack
+	OutboundMessage struct {
+		ID          int64     `db:"id" json:"id"`
+		Kind        string    `db:"kind" json:"kind"`
+		ChannelID   string    `db:"channel_id" json:"channel_id"`
+		UserID      string    `db:"user_id" json:"user_id"`
+		ThreadTS    string    `db:"thread_ts" json:"thread_ts"`
+		Text        string    `db:"text" json:"text"`
+		Attachments string    `db:"attachments" json:"attachments"`
+		Status      string    `db:"status" json:"status"`
+		Attempts    int       `db:"attempts" json:"attempts"`
+		LastError   string    `db:"last_error" json:"last_error"`
+		NextAttempt time.Time `db:"next_attempt" json:"next_attempt"`
+		Created     time.Time `db:"created" json:"created"`
+		Modified    time.Time `db:"modified" json:"modified"`
+	}
+
+	// Digest model used for serialization/deserialization stored recurring digests
+	Digest struct {
+		ID         int64     `db:"id" json:"id"`
+		ChannelID  string    `db:"channel_id" json:"channel_id"`
+		Period     string    `db:"period" json:"period"`
+		Time       string    `db:"time" json:"time"`
+		Recipients string    `db:"recipients" json:"recipients"`
+		LastSent   time.Time `db:"last_sent" json:"last_sent"`
+		Created    time.Time `db:"created" json:"created"`
+		Modified   time.Time `db:"modified" json:"modified"`
+	}
+
+	// APIToken model used for serialization/deserialization stored tokens of JSON API clients
+	APIToken struct {
+		ID        int64     `db:"id" json:"id"`
+		Name      string    `db:"name" json:"name"`
+		TokenHash string    `db:"token_hash" json:"-"`
+		Role      string    `db:"role" json:"role"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		Created   time.Time `db:"created" json:"created"`
+	}
+
+	// Webhook model used for serialization/deserialization stored subscriptions to standup events
+	Webhook struct {
+		ID        int64     `db:"id" json:"id"`
+		URL       string    `db:"url" json:"url"`
+		Secret    string    `db:"secret" json:"-"`
+		Events    string    `db:"events" json:"events"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		Created   time.Time `db:"created" json:"created"`
+		Modified  time.Time `db:"modified" json:"modified"`
+	}
+
+	// WebhookDelivery model used for serialization/deserialization stored deliveries of events to webhooks
+	WebhookDelivery struct {
+		ID           int64     `db:"id" json:"id"`
+		WebhookID    int64     `db:"webhook_id" json:"webhook_id"`
+		Event        string    `db:"event" json:"event"`
+		Payload      string    `db:"payload" json:"payload"`
+		Status       string    `db:"status" json:"status"`
+		Attempts     int       `db:"attempts" json:"attempts"`
+		ResponseCode int       `db:"response_code" json:"response_code"`
+		LastError    string    `db:"last_error" json:"last_error"`
+		NextAttempt  time.Time `db:"next_attempt" json:"next_attempt"`
+		Created      time.Time `db:"created" json:"created"`
+		Modified     time.Time `db:"modified" json:"modified"`
+	}
+
+	// Role model used for serialization/deserialization stored custom roles, a named set of permissions
+	Role struct {
+		ID          int64     `db:"id" json:"id"`
+		Name        string    `db:"name" json:"name"`
+		Permissions string    `db:"permissions" json:"permissions"`
+		Created     time.Time `db:"created" json:"created"`
+	}
+
+	// RoleBinding model used for serialization/deserialization stored roles given to users.
+	// Bindings without channel apply to all channels
+	RoleBinding struct {
+		ID        int64     `db:"id" json:"id"`
+		UserID    string    `db:"user_id" json:"user_id"`
+		Role      string    `db:"role" json:"role"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		Created   time.Time `db:"created" json:"created"`
+	}
+
+	// MemberSync model used for serialization/deserialization stored links of channels to Slack user groups
+	// their members are kept in sync with
+	MemberSync struct {
+		ID              int64     `db:"id" json:"id"`
+		ChannelID       string    `db:"channel_id" json:"channel_id"`
+		UserGroupID     string    `db:"usergroup_id" json:"usergroup_id"`
+		UserGroupHandle string    `db:"usergroup_handle" json:"usergroup_handle"`
+		LastSynced      time.Time `db:"last_synced" json:"last_synced"`
+		Created         time.Time `db:"created" json:"created"`
+	}
+
+	// ChannelSetting model used for serialization/deserialization stored settings of channels,
+	// settings a channel does not have are inherited from the config
+	ChannelSetting struct {
+		ID        int64     `db:"id" json:"id"`
+		ChannelID string    `db:"channel_id" json:"channel_id"`
+		Name      string    `db:"name" json:"name"`
+		Value     string    `db:"value" json:"value"`
+		Created   time.Time `db:"created" json:"created"`
+		Modified  time.Time `db:"modified" json:"modified"`
+	}
+)
+
+// Settings channels can have
+const (
+	SettingMaxReminders     = "max_reminders"
+	SettingReminderInterval = "reminder_interval"
+	SettingWarningTime      = "warning_time"
+	SettingReportTime       = "report_time"
+	SettingReportRecipients = "report_recipients"
+	SettingValidation       = "validation"
+	// SettingLanguage is stored with the channel, see Channel.Language
+	SettingLanguage = "language"
+)
+
+// ChannelSettings lists settings channels can have in the order they are shown
+var ChannelSettings = []string{SettingMaxReminders, SettingReminderInterval, SettingWarningTime, SettingReportTime, SettingReportRecipients, SettingValidation}
+
+// Strictness of standup validation: strict rejects standups which break the rules,
+// warn accepts them and tells the author what is missing, off accepts any standup
+const (
+	ValidationStrict = "strict"
+	ValidationWarn   = "warn"
+	ValidationOff    = "off"
+)
+
+// Events webhooks can subscribe to
+const (
+	EventStandupCreated  = "standup.created"
+	EventStandupEdited   = "standup.edited"
+	EventStandupDeleted  = "standup.deleted"
+	EventDeadlineMissed  = "deadline.missed"
+	EventReportGenerated = "report.generated"
+)
+
+// WebhookEvents lists events webhooks can subscribe to
+var WebhookEvents = []string{EventStandupCreated, EventStandupEdited, EventStandupDeleted, EventDeadlineMissed, EventReportGenerated}
+
+// Roles of JSON API tokens: admins access everything, PMs access their channel,
+// viewers access channels, members, timetables and reports without standup texts
+const (
+	APIRoleAdmin  = "admin"
+	APIRolePM     = "pm"
+	APIRoleViewer = "viewer"
+)
+
+// Permissions commands require
+const (
+	PermMembersView     = "members.view"
+	PermMembersManage   = "members.manage"
+	PermRolesManage     = "roles.manage"
+	PermScheduleView    = "schedule.view"
+	PermScheduleManage  = "schedule.manage"
+	PermReportsOwn      = "reports.own"
+	PermReportsView     = "reports.view"
+	PermBlockersView    = "blockers.view"
+	PermBlockersResolve = "blockers.resolve"
+	PermDigestsManage   = "digests.manage"
+	PermWorkspaceManage = "workspace.manage"
+)
+
+// Permissions lists all permissions roles can be made of
+var Permissions = []string{
+	PermMembersView, PermMembersManage, PermRolesManage, PermScheduleView, PermScheduleManage, PermReportsOwn,
+	PermReportsView, PermBlockersView, PermBlockersResolve, PermDigestsManage, PermWorkspaceManage,
+}
+
+// Built in roles. Admins and PMs are also given by /add, everyone is a viewer
+const (
+	RoleAdmin  = "admin"
+	RolePM     = "pm"
+	RoleViewer = "viewer"
+)
+
+// BuiltinRoles maps built in roles to their permissions
+var BuiltinRoles = map[string][]string{
+	RoleViewer: {PermMembersView, PermScheduleView, PermReportsOwn, PermBlockersView},
+	RolePM: {
+		PermMembersView, PermScheduleView, PermReportsOwn, PermBlockersView,
+		PermMembersManage, PermScheduleManage, PermReportsView, PermBlockersResolve, PermDigestsManage,
+	},
+	RoleAdmin: Permissions,
+}
+
+// Digest periods
+const (
+	DigestWeekly  = "weekly"
+	DigestMonthly = "monthly"
 )
 
 // Validate validates Standup struct
@@ -101,6 +355,216 @@ func (c StandupEditHistory) Validate() error {
 	return nil
 }
 
+// Validate validates StandupRules struct
+func (r StandupRules) Validate() error {
+	if r.ChannelID == "" {
+		err := errors.New("Channel cannot be empty")
+		return err
+	}
+	if r.MinLength < 0 {
+		err := errors.New("Minimum length cannot be negative")
+		return err
+	}
+	return nil
+}
+
+// ListSections decodes sections required by the rules
+func (r StandupRules) ListSections() ([]StandupSection, error) {
+	sections := []StandupSection{}
+	if r.Sections == "" {
+		return sections, nil
+	}
+	err := json.Unmarshal([]byte(r.Sections), &sections)
+	return sections, err
+}
+
+// SetSections encodes sections required by the rules
+func (r *StandupRules) SetSections(sections []StandupSection) error {
+	data, err := json.Marshal(sections)
+	if err != nil {
+		return err
+	}
+	r.Sections = string(data)
+	return nil
+}
+
+// Validate validates Interview struct
+func (i Interview) Validate() error {
+	if i.UserID == "" || i.ChannelID == "" {
+		err := errors.New("User/Channel cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// ListAnswers decodes answers given during the interview
+func (i Interview) ListAnswers() ([]string, error) {
+	answers := []string{}
+	if i.Answers == "" {
+		return answers, nil
+	}
+	err := json.Unmarshal([]byte(i.Answers), &answers)
+	return answers, err
+}
+
+// AddAnswer saves answer to the current question and moves interview to the next one
+func (i *Interview) AddAnswer(answer string) error {
+	answers, err := i.ListAnswers()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(append(answers, answer))
+	if err != nil {
+		return err
+	}
+	i.Answers = string(data)
+	i.Step++
+	return nil
+}
+
+// Validate validates StandupThread struct
+func (t StandupThread) Validate() error {
+	if t.ChannelID == "" || t.ThreadTS == "" {
+		err := errors.New("Channel/Thread cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates Blocker struct
+func (b Blocker) Validate() error {
+	if b.UserID == "" || b.ChannelID == "" {
+		err := errors.New("User/Channel cannot be empty")
+		return err
+	}
+	if b.Text == "" {
+		err := errors.New("Blocker text cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates OutboundMessage struct
+func (m OutboundMessage) Validate() error {
+	if m.Kind == "" || m.Status == "" {
+		err := errors.New("Kind/Status cannot be empty")
+		return err
+	}
+	if m.ChannelID == "" && m.UserID == "" {
+		err := errors.New("Channel/User cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates Digest struct
+func (d Digest) Validate() error {
+	if d.Period != DigestWeekly && d.Period != DigestMonthly {
+		err := errors.New("Period should be weekly or monthly")
+		return err
+	}
+	if d.Time == "" || d.Recipients == "" {
+		err := errors.New("Time/Recipients cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// RecipientsList returns channel and user IDs digest is sent to
+func (d Digest) RecipientsList() []string {
+	recipients := []string{}
+	for _, recipient := range strings.Split(d.Recipients, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+// Validate validates APIToken struct
+func (t APIToken) Validate() error {
+	if t.Name == "" || t.TokenHash == "" {
+		err := errors.New("Name/Token cannot be empty")
+		return err
+	}
+	switch t.Role {
+	case APIRoleAdmin, APIRoleViewer:
+	case APIRolePM:
+		if t.ChannelID == "" {
+			err := errors.New("PM token should be limited to a channel")
+			return err
+		}
+	default:
+		err := errors.New("Role should be admin, pm or viewer")
+		return err
+	}
+	return nil
+}
+
+// CanReadStandups reports whether token gives access to standup texts
+func (t APIToken) CanReadStandups() bool {
+	return t.Role == APIRoleAdmin || t.Role == APIRolePM
+}
+
+// CanAccessChannel reports whether token gives access to the channel
+func (t APIToken) CanAccessChannel(channelID string) bool {
+	return t.ChannelID == "" || t.ChannelID == channelID
+}
+
+// Validate validates Webhook struct
+func (w Webhook) Validate() error {
+	if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+		err := errors.New("URL should start with http:// or https://")
+		return err
+	}
+	if w.Secret == "" {
+		err := errors.New("Secret cannot be empty")
+		return err
+	}
+	if len(w.EventsList()) == 0 {
+		err := errors.New("Events cannot be empty")
+		return err
+	}
+	for _, event := range w.EventsList() {
+		known := false
+		for _, e := range WebhookEvents {
+			if event == e {
+				known = true
+			}
+		}
+		if !known {
+			err := fmt.Errorf("Unknown event %v", event)
+			return err
+		}
+	}
+	return nil
+}
+
+// EventsList returns events webhook is subscribed to
+func (w Webhook) EventsList() []string {
+	events := []string{}
+	for _, event := range strings.Split(w.Events, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Subscribed reports whether webhook should receive the event which happened in the channel.
+// Webhooks without channel receive events of all channels
+func (w Webhook) Subscribed(event, channelID string) bool {
+	if w.ChannelID != "" && w.ChannelID != channelID {
+		return false
+	}
+	for _, e := range w.EventsList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
 //IsAdmin returns user status
 func (u User) IsAdmin() bool {
 	if u.Role == "admin" {
@@ -109,41 +573,53 @@ func (u User) IsAdmin() bool {
 	return false
 }
 
-//Show shows timetable
-func (tt TimeTable) Show() string {
-	c, _ := config.Get()
+// Roles returns built in roles of the user in a channel: everyone is a viewer,
+// admins are admins everywhere and PMs of the channel are PMs in it
+func (u User) Roles(roleInChannel string) []string {
+	roles := []string{RoleViewer}
+	if u.IsAdmin() {
+		roles = append(roles, RoleAdmin)
+	}
+	if roleInChannel == RolePM {
+		roles = append(roles, RolePM)
+	}
+	return roles
+}
+
+//Show shows timetable in the language of the translation
+func (tt TimeTable) Show(t config.Translate) string {
 	timeTableString := ""
 	if tt.Monday != 0 {
 		monday := time.Unix(tt.Monday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowMonday, monday.Hour(), monday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowMonday, monday.Hour(), monday.Minute())
 	}
 	if tt.Tuesday != 0 {
 		tuesday := time.Unix(tt.Tuesday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowTuesday, tuesday.Hour(), tuesday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowTuesday, tuesday.Hour(), tuesday.Minute())
 	}
 	if tt.Wednesday != 0 {
 		wednesday := time.Unix(tt.Wednesday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowWednesday, wednesday.Hour(), wednesday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowWednesday, wednesday.Hour(), wednesday.Minute())
 	}
 	if tt.Thursday != 0 {
 		thursday := time.Unix(tt.Thursday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowThursday, thursday.Hour(), thursday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowThursday, thursday.Hour(), thursday.Minute())
 	}
 	if tt.Friday != 0 {
 		friday := time.Unix(tt.Friday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowFriday, friday.Hour(), friday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowFriday, friday.Hour(), friday.Minute())
 	}
 	if tt.Saturday != 0 {
 		saturday := time.Unix(tt.Saturday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowSaturday, saturday.Hour(), saturday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowSaturday, saturday.Hour(), saturday.Minute())
 	}
 	if tt.Sunday != 0 {
 		sunday := time.Unix(tt.Sunday, 0)
-		timeTableString += fmt.Sprintf(c.Translate.TimetableShowSunday, sunday.Hour(), sunday.Minute())
+		timeTableString += fmt.Sprintf(t.TimetableShowSunday, sunday.Hour(), sunday.Minute())
 	}
 
 	if timeTableString == "" {
-		return c.Translate.EmptyTimetable
+		return t.EmptyTimetable
 	} else {
 		timeTableString += "|"
 	}
@@ -179,3 +655,121 @@ func (tt TimeTable) IsEmpty() bool {
 	}
 	return false
 }
+
+// Validate validates Role struct
+func (r Role) Validate() error {
+	if r.Name == "" {
+		err := errors.New("Name cannot be empty")
+		return err
+	}
+	if _, ok := BuiltinRoles[r.Name]; ok {
+		err := fmt.Errorf("Role %v is built in", r.Name)
+		return err
+	}
+	if len(r.PermissionsList()) == 0 {
+		err := errors.New("Permissions cannot be empty")
+		return err
+	}
+	for _, permission := range r.PermissionsList() {
+		if !KnownPermission(permission) {
+			err := fmt.Errorf("Unknown permission %v", permission)
+			return err
+		}
+	}
+	return nil
+}
+
+// PermissionsList returns permissions of the role
+func (r Role) PermissionsList() []string {
+	permissions := []string{}
+	for _, permission := range strings.Split(r.Permissions, ",") {
+		if permission = strings.TrimSpace(permission); permission != "" {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// KnownPermission reports whether permission exists
+func KnownPermission(permission string) bool {
+	for _, p := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates RoleBinding struct
+func (b RoleBinding) Validate() error {
+	if b.UserID == "" || b.Role == "" {
+		err := errors.New("User/Role cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// AppliesTo reports whether binding gives its role in the channel
+func (b RoleBinding) AppliesTo(channelID string) bool {
+	return b.ChannelID == "" || b.ChannelID == channelID
+}
+
+// Validate validates MemberSync struct
+func (m MemberSync) Validate() error {
+	if m.ChannelID == "" || m.UserGroupID == "" {
+		err := errors.New("Channel/User group cannot be empty")
+		return err
+	}
+	return nil
+}
+
+// Validate validates ChannelSetting struct and its value
+func (cs ChannelSetting) Validate() error {
+	if cs.ChannelID == "" || cs.Value == "" {
+		err := errors.New("Channel/Value cannot be empty")
+		return err
+	}
+	switch cs.Name {
+	case SettingMaxReminders, SettingReminderInterval:
+		n, err := cs.Int()
+		if err != nil || n < 0 {
+			return fmt.Errorf("%v should be a number of times or minutes, got %v", cs.Name, cs.Value)
+		}
+	case SettingWarningTime:
+		n, err := cs.Int()
+		if err != nil || n < 0 || n >= 24*60 {
+			return fmt.Errorf("%v should be a number of minutes less than a day, got %v", cs.Name, cs.Value)
+		}
+	case SettingReportTime:
+		if _, err := time.Parse("15:04", cs.Value); err != nil {
+			return fmt.Errorf("%v should be hh:mm, got %v", cs.Name, cs.Value)
+		}
+	case SettingReportRecipients:
+		if len(cs.List()) == 0 {
+			return fmt.Errorf("%v should list channels or users", cs.Name)
+		}
+	case SettingValidation:
+		if cs.Value != ValidationStrict && cs.Value != ValidationWarn && cs.Value != ValidationOff {
+			return fmt.Errorf("%v should be %v, %v or %v, got %v", cs.Name, ValidationStrict, ValidationWarn, ValidationOff, cs.Value)
+		}
+	default:
+		return fmt.Errorf("unknown setting %v", cs.Name)
+	}
+	return nil
+}
+
+// Int returns value of numeric setting
+func (cs ChannelSetting) Int() (int, error) {
+	return strconv.Atoi(cs.Value)
+}
+
+// List returns channel and user IDs of list setting
+func (cs ChannelSetting) List() []string {
+	items := []string{}
+	for _, item := range strings.Split(cs.Value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,5 @@
+package notifier
+
+import "github.com/maddevsio/comedian/metrics"
+
+var remindersSent = metrics.NewCounter("comedian_reminders_sent_total", "Reminders sent about upcoming or missing standups, by kind.", "kind")
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff"
+	"github.com/maddevsio/comedian/metrics"
 	"github.com/maddevsio/comedian/model"
 
 	"github.com/maddevsio/comedian/chat"
@@ -17,14 +18,13 @@ import (
 
 // Notifier struct is used to notify users about upcoming or skipped standups
 type Notifier struct {
-	s    *chat.Slack
-	db   storage.Storage
-	conf config.Config
+	s  *chat.Slack
+	db storage.Storage
 }
 
 // NewNotifier creates a new notifier
 func NewNotifier(slack *chat.Slack) (*Notifier, error) {
-	notifier := &Notifier{s: slack, db: slack.DB, conf: slack.Conf}
+	notifier := &Notifier{s: slack, db: slack.DB}
 	return notifier, nil
 }
 
@@ -32,12 +32,14 @@ func NewNotifier(slack *chat.Slack) (*Notifier, error) {
 func (n *Notifier) Start() error {
 	notificationForChannels := time.NewTicker(time.Second * 60).C
 	notificationForTimeTable := time.NewTicker(time.Second * 60).C
+	n.s.Heartbeat("notifier")
 	for {
 		select {
 		case <-notificationForChannels:
-			n.NotifyChannels()
+			n.s.Heartbeat("notifier")
+			metrics.Job("notify_channels", n.NotifyChannels)()
 		case <-notificationForTimeTable:
-			n.NotifyIndividuals()
+			metrics.Job("notify_individuals", n.NotifyIndividuals)()
 		}
 	}
 }
@@ -54,11 +56,16 @@ func (n *Notifier) NotifyChannels() {
 	}
 	// For each standup time, if standup time is now, start reminder
 	for _, channel := range channels {
-		if channel.StandupTime == 0 {
+		if channel.StandupTime == 0 || channel.Archived {
 			continue
 		}
+		settings := n.s.ChannelSettings(channel.ChannelID)
 		standupTime := time.Unix(channel.StandupTime, 0)
-		warningTime := time.Unix(channel.StandupTime-n.conf.ReminderTime*60, 0)
+		warningTime := time.Unix(channel.StandupTime-settings.ReminderTime*60, 0)
+		// the thread is started every minute till it is posted, so that it survives rate limits
+		if channel.Threaded && threadDue(n.s.Config().ThreadTime, warningTime, standupTime, time.Now()) {
+			n.s.StartStandupThread(channel.ChannelID)
+		}
 		if time.Now().Hour() == warningTime.Hour() && time.Now().Minute() == warningTime.Minute() {
 			n.SendWarning(channel.ChannelID)
 		}
@@ -68,6 +75,18 @@ func (n *Notifier) NotifyChannels() {
 	}
 }
 
+// threadDue tells if the standup thread should be open by now: it starts at thread time, or at
+// warning time if that is earlier, and is not started after the deadline any more
+func threadDue(threadTime string, warningTime, standupTime, now time.Time) bool {
+	minutes := func(t time.Time) int { return t.Hour()*60 + t.Minute() }
+	start := minutes(warningTime)
+	if t, err := time.Parse("15:04", threadTime); err == nil && minutes(t) < start {
+		start = minutes(t)
+	}
+	current := minutes(now)
+	return current >= start && current < minutes(standupTime)
+}
+
 // NotifyIndividuals reminds users of channels about upcoming or missing standups
 func (n *Notifier) NotifyIndividuals() {
 	day := strings.ToLower(time.Now().Weekday().String())
@@ -79,7 +98,7 @@ func (n *Notifier) NotifyIndividuals() {
 
 	for _, tt := range tts {
 		standupTime := time.Unix(tt.ShowDeadlineOn(day), 0)
-		warningTime := time.Unix(tt.ShowDeadlineOn(day)-n.conf.ReminderTime*60, 0)
+		warningTime := time.Unix(tt.ShowDeadlineOn(day)-n.memberSettings(tt.ChannelMemberID).ReminderTime*60, 0)
 
 		if time.Now().Hour() == warningTime.Hour() && time.Now().Minute() == warningTime.Minute() {
 			n.SendIndividualWarning(tt.ChannelMemberID)
@@ -110,11 +129,13 @@ func (n *Notifier) SendWarning(channelID string) {
 	for _, user := range nonReporters {
 		nonReportersIDs = append(nonReportersIDs, "<@"+user.UserID+">")
 	}
-	err = n.s.SendMessage(channelID, fmt.Sprintf(n.conf.Translate.NotifyUsersWarning, strings.Join(nonReportersIDs, ", "), n.conf.ReminderTime), nil)
+	t := n.s.Translation(channelID, "")
+	err = n.s.SendMessage(channelID, fmt.Sprintf(t.NotifyUsersWarning, strings.Join(nonReportersIDs, ", "), t.Plural(config.PluralMinutes, n.s.ChannelSettings(channelID).ReminderTime)), nil)
 	if err != nil {
 		logrus.Errorf("notifier: n.s.SendMessage failed: %v\n", err)
 		return
 	}
+	remindersSent.Inc("warning")
 }
 
 // SendIndividualWarning reminds users in chat about upcoming standups
@@ -124,13 +145,20 @@ func (n *Notifier) SendIndividualWarning(channelMemberID int64) {
 		logrus.Errorf("SelectChannelMember failed: %v", err)
 		return
 	}
+	channel, err := n.db.SelectChannel(chm.ChannelID)
+	if err != nil || channel.Archived {
+		return
+	}
 	submittedStandup := n.db.SubmittedStandupToday(chm.UserID, chm.ChannelID)
 	if !submittedStandup {
-		err = n.s.SendMessage(chm.ChannelID, fmt.Sprintf(n.conf.Translate.IndividualStandupersWarning, chm.UserID, n.conf.ReminderTime), nil)
+		t := n.s.Translation(chm.ChannelID, "")
+		reminderTime := n.s.ChannelSettings(channel.ChannelID).ReminderTime
+		err = n.s.SendMessage(chm.ChannelID, fmt.Sprintf(t.IndividualStandupersWarning, chm.UserID, t.Plural(config.PluralMinutes, reminderTime)), nil)
 		if err != nil {
 			logrus.Errorf("notifier: n.s.SendMessage failed: %v\n", err)
 			return
 		}
+		remindersSent.Inc("individual_warning")
 		return
 	}
 	logrus.Infof("%v is not non reporter", chm.UserID)
@@ -160,18 +188,29 @@ func (n *Notifier) SendChannelNotification(channelID string) {
 		}
 	}
 	if len(nonReporters) == 0 {
-		err := n.s.SendMessage(channelID, n.conf.Translate.NotifyAllDone, nil)
+		err := n.s.SendMessage(channelID, n.s.Translation(channelID, "").NotifyAllDone, nil)
 		if err != nil {
 			logrus.Errorf("notifier: s.SendMessage failed: %v\n", err)
 		}
 		return
 	}
+	for _, nonReporter := range nonReporters {
+		n.s.EmitEvent(model.EventDeadlineMissed, channelID, nonReporter)
+	}
 
 	channel, err := n.db.SelectChannel(channelID)
 	if err != nil {
 		logrus.Errorf("notifier: SelectChannel failed: %v\n", err)
 		return
 	}
+	settings := n.s.ChannelSettings(channel.ChannelID)
+
+	if channel.Interview {
+		for _, nonReporter := range nonReporters {
+			n.s.StartInterview(channelID, nonReporter.UserID)
+		}
+		return
+	}
 
 	repeats := 0
 
@@ -195,24 +234,27 @@ func (n *Notifier) SendChannelNotification(channelID string) {
 		}
 		logrus.Infof("notifier: Notifier non reporters: %v", nonReporters)
 
-		if repeats < n.conf.ReminderRepeatsMax && len(nonReporters) > 0 {
-			n.s.SendMessage(channelID, fmt.Sprintf(n.conf.Translate.NotifyNotAll, strings.Join(nonReportersSlackIDs, ", ")), nil)
+		if repeats < settings.ReminderRepeatsMax && len(nonReporters) > 0 {
+			n.s.SendMessage(channelID, fmt.Sprintf(n.s.Translation(channelID, "").NotifyNotAll, strings.Join(nonReportersSlackIDs, ", ")), nil)
+			remindersSent.Inc("channel")
 			repeats++
 			err := errors.New("Continue backoff")
 			return err
 		}
 		// othervise Direct Message non reporters
 		for _, nonReporter := range nonReporters {
-			err := n.s.SendUserMessage(nonReporter.UserID, fmt.Sprintf(n.conf.Translate.NotifyDirectMessage, nonReporter.UserID, channel.ChannelID, channel.ChannelName))
+			err := n.s.SendUserMessage(nonReporter.UserID, fmt.Sprintf(n.s.Translation(channel.ChannelID, nonReporter.UserID).NotifyDirectMessage, nonReporter.UserID, channel.ChannelID, channel.ChannelName))
 			if err != nil {
 				logrus.Errorf("notifier: s.SendMessage failed: %v\n", err)
+				continue
 			}
+			remindersSent.Inc("direct")
 		}
 		//n.notifyAdminsAboutNonReporters(channelID, nonReportersSlackIDs)
 		return nil
 	}
 
-	b := backoff.NewConstantBackOff(time.Duration(n.conf.NotifierInterval) * time.Minute)
+	b := backoff.NewConstantBackOff(time.Duration(settings.NotifierInterval) * time.Minute)
 	err = backoff.Retry(notifyNotAll, b)
 	if err != nil {
 		logrus.Errorf("notifier: backoff.Retry failed: %v\n", err)
@@ -231,35 +273,56 @@ func (n *Notifier) SendIndividualNotification(channelMemberID int64) {
 		logrus.Errorf("notifier: SelectChannel failed: %v\n", err)
 		return
 	}
+	if channel.Archived {
+		return
+	}
+	settings := n.s.ChannelSettings(channel.ChannelID)
 	submittedStandup := n.db.SubmittedStandupToday(chm.UserID, chm.ChannelID)
 	if submittedStandup {
 		return
 	}
+	n.s.EmitEvent(model.EventDeadlineMissed, chm.ChannelID, chm)
+	if channel.Interview {
+		n.s.StartInterview(chm.ChannelID, chm.UserID)
+		return
+	}
 	repeats := 0
 	notify := func() error {
 		submittedStandup := n.db.SubmittedStandupToday(chm.UserID, chm.ChannelID)
-		if repeats < n.conf.ReminderRepeatsMax && !submittedStandup {
-			n.s.SendMessage(channel.ChannelID, fmt.Sprintf(n.conf.Translate.IndividualStandupersLate, chm.UserID), nil)
+		if repeats < settings.ReminderRepeatsMax && !submittedStandup {
+			n.s.SendMessage(channel.ChannelID, fmt.Sprintf(n.s.Translation(channel.ChannelID, "").IndividualStandupersLate, chm.UserID), nil)
+			remindersSent.Inc("individual")
 			repeats++
 			err := errors.New("Continue backoff")
 			return err
 		}
 		if !submittedStandup {
-			err := n.s.SendUserMessage(chm.UserID, fmt.Sprintf(n.conf.Translate.NotifyDirectMessage, chm.UserID, channel.ChannelID, channel.ChannelName))
+			err := n.s.SendUserMessage(chm.UserID, fmt.Sprintf(n.s.Translation(channel.ChannelID, chm.UserID).NotifyDirectMessage, chm.UserID, channel.ChannelID, channel.ChannelName))
 			if err != nil {
 				logrus.Errorf("notifier: s.SendMessage failed: %v\n", err)
+			} else {
+				remindersSent.Inc("direct")
 			}
 		}
 		logrus.Infof("User %v submitted standup!", chm.UserID)
 		return nil
 	}
-	b := backoff.NewConstantBackOff(time.Duration(n.conf.NotifierInterval) * time.Minute)
+	b := backoff.NewConstantBackOff(time.Duration(settings.NotifierInterval) * time.Minute)
 	err = backoff.Retry(notify, b)
 	if err != nil {
 		logrus.Errorf("notifier: backoff.Retry failed: %v\n", err)
 	}
 }
 
+// memberSettings returns current settings of the channel of the member
+func (n *Notifier) memberSettings(channelMemberID int64) chat.ChannelSettings {
+	chm, err := n.db.SelectChannelMember(channelMemberID)
+	if err != nil {
+		return n.s.ChannelSettings("")
+	}
+	return n.s.ChannelSettings(chm.ChannelID)
+}
+
 // getNonReporters returns a list of standupers that did not write standups
 func (n *Notifier) getCurrentDayNonReporters(channelID string) ([]model.ChannelMember, error) {
 	timeFrom := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.UTC)
@@ -299,3 +299,25 @@ func TestChannelsNotification(t *testing.T) {
 	assert.NoError(t, n.db.DeleteStandupTime(channel.ChannelID))
 
 }
+
+func TestThreadDue(t *testing.T) {
+	at := func(hour, min int) time.Time { return time.Date(2018, 6, 1, hour, min, 0, 0, time.Local) }
+	testCases := []struct {
+		threadTime string
+		warning    time.Time
+		deadline   time.Time
+		now        time.Time
+		due        bool
+	}{
+		{"09:00", at(10, 0), at(10, 0), at(8, 59), false},
+		{"09:00", at(10, 0), at(10, 0), at(9, 0), true},
+		{"09:00", at(10, 0), at(10, 0), at(9, 59), true},
+		{"09:00", at(10, 0), at(10, 0), at(10, 0), false},
+		{"09:00", at(8, 20), at(8, 30), at(8, 20), true},
+		{"09:00", at(8, 20), at(8, 30), at(8, 19), false},
+		{"broken", at(10, 0), at(10, 0), at(9, 0), false},
+	}
+	for _, tt := range testCases {
+		assert.Equal(t, tt.due, threadDue(tt.threadTime, tt.warning, tt.deadline, tt.now), "%v %v", tt.threadTime, tt.now)
+	}
+}
@@ -0,0 +1,153 @@
+package reporting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/model"
+	"github.com/sirupsen/logrus"
+)
+
+// sendDigests sends weekly and monthly digests which are due
+func (r *Reporter) sendDigests() {
+	digests, err := r.db.ListDigests()
+	if err != nil {
+		logrus.Errorf("reporting: ListDigests failed: %v\n", err)
+		return
+	}
+	now := time.Now()
+	for _, digest := range digests {
+		if !digestDue(digest, now) {
+			continue
+		}
+		// digest is marked as sent before it is built, so the next tick does not send it twice
+		digest.LastSent = now
+		digest, err := r.db.UpdateDigest(digest)
+		if err != nil {
+			logrus.Errorf("reporting: UpdateDigest failed: %v\n", err)
+			continue
+		}
+		go r.sendDigest(digest, now)
+	}
+}
+
+func (r *Reporter) sendDigest(digest model.Digest, now time.Time) {
+	// digest is built once for every language recipients read
+	texts := map[string]string{}
+	for _, recipient := range digest.RecipientsList() {
+		isUser := strings.HasPrefix(recipient, "U") || strings.HasPrefix(recipient, "W")
+		lang := r.s.Language(recipient, "")
+		if isUser {
+			lang = r.s.Language("", recipient)
+		}
+		text, ok := texts[lang]
+		if !ok {
+			var err error
+			text, err = r.Localized(r.config().Translation(lang)).Digest(digest, now)
+			if err != nil {
+				logrus.Errorf("reporting: Digest #%v failed: %v\n", digest.ID, err)
+				return
+			}
+			texts[lang] = text
+		}
+		var err error
+		if isUser {
+			err = r.s.SendUserMessage(recipient, text)
+		} else {
+			err = r.s.SendMessage(recipient, text, nil)
+		}
+		if err != nil {
+			logrus.Errorf("reporting: sending digest #%v to %v failed: %v\n", digest.ID, recipient, err)
+		}
+	}
+}
+
+// Digest builds digest text for the period which has ended by now
+func (r *Reporter) Digest(digest model.Digest, now time.Time) (string, error) {
+	dateFrom, dateTo := digestPeriod(digest.Period, now)
+	head := r.conf.Translate.DigestWeeklyHead
+	if digest.Period == model.DigestMonthly {
+		head = r.conf.Translate.DigestMonthlyHead
+	}
+	text := fmt.Sprintf(head, dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02"))
+
+	channels := []model.Channel{}
+	if digest.ChannelID != "" {
+		channel, err := r.db.SelectChannel(digest.ChannelID)
+		if err != nil {
+			return "", err
+		}
+		channels = append(channels, channel)
+	} else {
+		all, err := r.db.GetAllChannels()
+		if err != nil {
+			return "", err
+		}
+		for _, channel := range all {
+			if !channel.Archived {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	hasData := false
+	for _, channel := range channels {
+		report, err := r.StandupReportByProject(channel, dateFrom, dateTo)
+		if err != nil {
+			logrus.Errorf("reporting: StandupReportByProject failed: %v\n", err)
+			continue
+		}
+		stats := CollectStats(report.Entries)
+		if len(stats) == 0 {
+			continue
+		}
+		hasData = true
+		text += fmt.Sprintf(r.conf.Translate.DigestChannel, channel.ChannelName)
+		for _, s := range stats {
+			t := r.conf.Translate
+			text += fmt.Sprintf(t.DigestMember, s.UserID, s.Submitted, t.Plural(config.PluralDays, s.Days), s.SubmissionRate(), s.OnTimeRate(), t.Plural(config.PluralDays, s.Streak), t.Plural(config.PluralDays, s.LongestStreak))
+			if len(s.Missed) == 0 {
+				continue
+			}
+			missed := []string{}
+			for _, day := range s.Missed {
+				missed = append(missed, day.Format("2006-01-02"))
+			}
+			text += fmt.Sprintf(r.conf.Translate.DigestMissed, strings.Join(missed, ", "))
+		}
+	}
+	if !hasData {
+		text += r.conf.Translate.ReportNoData
+	}
+	return text, nil
+}
+
+// digestDue reports whether digest should be sent now: weekly digests are sent on Mondays,
+// monthly ones on the first day of month, both at digest time
+func digestDue(digest model.Digest, now time.Time) bool {
+	switch digest.Period {
+	case model.DigestWeekly:
+		if now.Weekday() != time.Monday {
+			return false
+		}
+	case model.DigestMonthly:
+		if now.Day() != 1 {
+			return false
+		}
+	default:
+		return false
+	}
+	return dueAt(digest.Time, digest.LastSent, now)
+}
+
+// digestPeriod returns the first and the last day of the week or month which has ended by now
+func digestPeriod(period string, now time.Time) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == model.DigestMonthly {
+		dateFrom := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+		return dateFrom, dateFrom.AddDate(0, 1, -1)
+	}
+	return today.AddDate(0, 0, -7), today.AddDate(0, 0, -1)
+}
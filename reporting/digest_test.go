@@ -0,0 +1,51 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDigestDue(t *testing.T) {
+	monday := time.Date(2018, 6, 4, 10, 30, 0, 0, time.UTC)
+	weekly := model.Digest{Period: model.DigestWeekly, Time: "10:00", LastSent: monday.AddDate(0, 0, -7)}
+	assert.Equal(t, true, digestDue(weekly, monday))
+	assert.Equal(t, false, digestDue(weekly, monday.Add(-time.Hour)))
+	assert.Equal(t, false, digestDue(weekly, monday.AddDate(0, 0, 1)))
+
+	weekly.LastSent = monday
+	assert.Equal(t, false, digestDue(weekly, monday.Add(time.Minute)))
+
+	firstDay := time.Date(2018, 7, 1, 10, 0, 0, 0, time.UTC)
+	monthly := model.Digest{Period: model.DigestMonthly, Time: "10:00", LastSent: firstDay.AddDate(0, -1, 0)}
+	assert.Equal(t, true, digestDue(monthly, firstDay))
+	assert.Equal(t, false, digestDue(monthly, firstDay.AddDate(0, 0, 1)))
+
+	monthly.Time = "wrong"
+	assert.Equal(t, false, digestDue(monthly, firstDay))
+}
+
+func TestDueAt(t *testing.T) {
+	now := time.Date(2018, 6, 4, 18, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	assert.Equal(t, true, dueAt("18:00", yesterday, now))
+	// a skipped minute is caught up on the next tick
+	assert.Equal(t, true, dueAt("17:59", yesterday, now))
+	assert.Equal(t, false, dueAt("18:01", yesterday, now))
+	assert.Equal(t, false, dueAt("17:00", now.Add(-30*time.Minute), now))
+	assert.Equal(t, false, dueAt("wrong", yesterday, now))
+}
+
+func TestDigestPeriod(t *testing.T) {
+	now := time.Date(2018, 6, 4, 10, 30, 0, 0, time.UTC)
+	dateFrom, dateTo := digestPeriod(model.DigestWeekly, now)
+	assert.Equal(t, time.Date(2018, 5, 28, 0, 0, 0, 0, time.UTC), dateFrom)
+	assert.Equal(t, time.Date(2018, 6, 3, 0, 0, 0, 0, time.UTC), dateTo)
+
+	now = time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	dateFrom, dateTo = digestPeriod(model.DigestMonthly, now)
+	assert.Equal(t, time.Date(2018, 2, 1, 0, 0, 0, 0, time.UTC), dateFrom)
+	assert.Equal(t, time.Date(2018, 2, 28, 0, 0, 0, 0, time.UTC), dateTo)
+}
@@ -0,0 +1,157 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/maddevsio/comedian/config"
+)
+
+// Formats reports can be exported to
+const (
+	FormatText     = "text"
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists supported export formats
+var Formats = []string{FormatText, FormatCSV, FormatJSON, FormatMarkdown}
+
+// ExportEntry is report entry as it is written to CSV and JSON files and returned by JSON API
+type ExportEntry struct {
+	Date        string `json:"date"`
+	ChannelID   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+	Status      string `json:"status"`
+	Submitted   string `json:"submitted"`
+	Deadline    string `json:"deadline"`
+	// LatenessMinutes is empty when there is no deadline or no standup
+	LatenessMinutes string `json:"lateness_minutes"`
+	Standup         string `json:"standup"`
+}
+
+// Text renders report as Slack message text
+func (r Report) Text(noData string) string {
+	text := r.ReportHead
+	if len(r.ReportBody) == 0 {
+		return text + noData
+	}
+	for _, t := range r.ReportBody {
+		text += t.Text
+	}
+	return text + r.ReportTail
+}
+
+// Export renders report in the format and returns file content, Slack filetype and file extension.
+// Texts of Markdown documents are taken from the translation
+func (r Report) Export(format string, t config.Translate) (string, string, string, error) {
+	switch format {
+	case FormatCSV:
+		content, err := r.CSV()
+		return content, "csv", "csv", err
+	case FormatJSON:
+		content, err := r.JSON()
+		return content, "json", "json", err
+	case FormatMarkdown:
+		return r.Markdown(t), "markdown", "md", nil
+	case FormatText:
+		return r.Text(""), "text", "txt", nil
+	}
+	return "", "", "", fmt.Errorf("unknown format %v", format)
+}
+
+// CSV renders report entries as comma separated values with a header row
+func (r Report) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	err := w.Write([]string{"date", "channel_id", "channel_name", "user_id", "user_name", "status", "submitted", "deadline", "lateness_minutes", "standup"})
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range r.ExportEntries() {
+		err := w.Write([]string{entry.Date, entry.ChannelID, csvText(entry.ChannelName), entry.UserID, csvText(entry.UserName), entry.Status, entry.Submitted, entry.Deadline, entry.LatenessMinutes, csvText(entry.Standup)})
+		if err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// csvText escapes text written by users, so that spreadsheets do not take it for a formula
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// JSON renders report entries as JSON array
+func (r Report) JSON() (string, error) {
+	data, err := json.MarshalIndent(r.ExportEntries(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Markdown renders report as a document with a section per day
+func (r Report) Markdown(t config.Translate) string {
+	text := "# " + strings.TrimSpace(r.ReportHead) + "\n"
+	date := ""
+	for i, entry := range r.ExportEntries() {
+		if entry.Date != date {
+			date = entry.Date
+			text += "\n## " + date + "\n"
+		}
+		member := entry.UserID
+		if entry.UserName != "" {
+			member = entry.UserName
+		}
+		text += fmt.Sprintf("\n### #%v, %v: %v", entry.ChannelName, member, entry.Status)
+		if entry.Submitted != "" {
+			text += " " + entry.Submitted
+		}
+		if lateness := r.Entries[i].Lateness(); lateness > 0 {
+			text += ", " + strings.TrimSpace(fmt.Sprintf(t.StandupLate, t.Plural(config.PluralMinutes, minutes(lateness))))
+		}
+		text += "\n"
+		if entry.Standup != "" {
+			text += "\n> " + strings.Replace(strings.TrimSpace(entry.Standup), "\n", "\n> ", -1) + "\n"
+		}
+	}
+	return text
+}
+
+// ExportEntries formats report entries for export
+func (r Report) ExportEntries() []ExportEntry {
+	entries := make([]ExportEntry, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		e := ExportEntry{
+			Date:        entry.Date.Format("2006-01-02"),
+			ChannelID:   entry.ChannelID,
+			ChannelName: entry.ChannelName,
+			UserID:      entry.UserID,
+			UserName:    entry.UserName,
+			Status:      entry.Status,
+			Standup:     entry.Standup,
+		}
+		if !entry.Submitted.IsZero() {
+			e.Submitted = entry.Submitted.Format("2006-01-02 15:04:05")
+		}
+		if !entry.Deadline.IsZero() {
+			e.Deadline = entry.Deadline.Format("2006-01-02 15:04:05")
+			if entry.Status == StatusSubmitted {
+				e.LatenessMinutes = fmt.Sprint(minutes(entry.Lateness()))
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
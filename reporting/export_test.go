@@ -0,0 +1,77 @@
+package reporting
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/maddevsio/comedian/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	tr, err := config.GetTranslation("en")
+	assert.NoError(t, err)
+	day := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		ReportHead: "Full Report on project #channame from 2018-06-04 to 2018-06-04:\n\n",
+		ReportBody: []ReportBodyContent{{day, "Report for: 2018-06-04\n"}},
+		Entries: []ReportEntry{
+			{Date: day, ChannelID: "chanid", ChannelName: "channame", UserID: "userID1", UserName: "user1", Status: StatusSubmitted, Submitted: day.Add(9 * time.Hour), Deadline: day.Add(8*time.Hour + 45*time.Minute), Standup: "yesterday: fixed \"bugs\",\ntoday: tests"},
+			{Date: day, ChannelID: "chanid", ChannelName: "channame", UserID: "userID2", Status: StatusMissed},
+		},
+	}
+
+	assert.Equal(t, report.ReportHead+"Report for: 2018-06-04\n", report.Text("no data"))
+	report.ReportTail = "tail"
+	assert.Equal(t, report.ReportHead+"Report for: 2018-06-04\ntail", report.Text("no data"))
+	assert.Equal(t, "head no data", Report{ReportHead: "head "}.Text("no data"))
+
+	content, filetype, extension, err := report.Export(FormatCSV, tr)
+	assert.NoError(t, err)
+	assert.Equal(t, "csv", filetype)
+	assert.Equal(t, "csv", extension)
+	expected := "date,channel_id,channel_name,user_id,user_name,status,submitted,deadline,lateness_minutes,standup\n" +
+		"2018-06-04,chanid,channame,userID1,user1,submitted,2018-06-04 09:00:00,2018-06-04 08:45:00,15,\"yesterday: fixed \"\"bugs\"\",\ntoday: tests\"\n" +
+		"2018-06-04,chanid,channame,userID2,,missed,,,,\n"
+	assert.Equal(t, expected, content)
+
+	content, filetype, _, err = report.Export(FormatJSON, tr)
+	assert.NoError(t, err)
+	assert.Equal(t, "json", filetype)
+	entries := []map[string]string{}
+	assert.NoError(t, json.Unmarshal([]byte(content), &entries))
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "2018-06-04 09:00:00", entries[0]["submitted"])
+	assert.Equal(t, "15", entries[0]["lateness_minutes"])
+	assert.Equal(t, "missed", entries[1]["status"])
+
+	content, _, extension, err = report.Export(FormatMarkdown, tr)
+	assert.NoError(t, err)
+	assert.Equal(t, "md", extension)
+	expected = "# Full Report on project #channame from 2018-06-04 to 2018-06-04:\n" +
+		"\n## 2018-06-04\n" +
+		"\n### #channame, user1: submitted 2018-06-04 09:00:00, 15 minutes late :snail:\n" +
+		"\n> yesterday: fixed \"bugs\",\n> today: tests\n" +
+		"\n### #channame, userID2: missed\n"
+	assert.Equal(t, expected, content)
+
+	_, _, _, err = report.Export("xls", tr)
+	assert.Error(t, err)
+}
+
+func TestCSVFormulas(t *testing.T) {
+	day := time.Date(2018, 6, 4, 0, 0, 0, 0, time.UTC)
+	report := Report{Entries: []ReportEntry{
+		{Date: day, ChannelID: "chanid", ChannelName: "channame", UserID: "userID1", UserName: "@user1", Status: StatusSubmitted, Standup: "=HYPERLINK(\"http://evil\")"},
+		{Date: day, ChannelID: "chanid", ChannelName: "channame", UserID: "userID2", UserName: "user2", Status: StatusSubmitted, Standup: "-fixed bugs"},
+		{Date: day, ChannelID: "chanid", ChannelName: "channame", UserID: "userID3", UserName: "user3", Status: StatusSubmitted, Standup: "fixed 1+1=2"},
+	}}
+	content, err := report.CSV()
+	assert.NoError(t, err)
+	expected := "date,channel_id,channel_name,user_id,user_name,status,submitted,deadline,lateness_minutes,standup\n" +
+		"2018-06-04,chanid,channame,userID1,'@user1,submitted,,,,\"'=HYPERLINK(\"\"http://evil\"\")\"\n" +
+		"2018-06-04,chanid,channame,userID2,user2,submitted,,,,'-fixed bugs\n" +
+		"2018-06-04,chanid,channame,userID3,user3,submitted,,,,fixed 1+1=2\n"
+	assert.Equal(t, expected, content)
+}
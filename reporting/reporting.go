@@ -2,11 +2,13 @@ package reporting
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jasonlvhit/gocron"
 	"github.com/maddevsio/comedian/chat"
 	"github.com/maddevsio/comedian/config"
+	"github.com/maddevsio/comedian/metrics"
 	"github.com/maddevsio/comedian/model"
 	"github.com/maddevsio/comedian/storage"
 	"github.com/maddevsio/comedian/utils"
@@ -25,6 +27,45 @@ type Reporter struct {
 type Report struct {
 	ReportHead string
 	ReportBody []ReportBodyContent
+	// ReportTail summarizes punctuality of members over the report period
+	ReportTail string
+	// Entries hold report data used to export report to files
+	Entries []ReportEntry
+}
+
+// Statuses of report entries
+const (
+	StatusSubmitted = "submitted"
+	StatusMissed    = "missed"
+)
+
+// ReportEntry describes standup of a member in a channel on a specific day
+type ReportEntry struct {
+	Date        time.Time
+	ChannelID   string
+	ChannelName string
+	UserID      string
+	UserName    string
+	Status      string
+	Submitted   time.Time
+	Deadline    time.Time
+	Standup     string
+}
+
+// OnTime reports whether standup was submitted before the deadline
+func (e ReportEntry) OnTime() bool {
+	if e.Status != StatusSubmitted {
+		return false
+	}
+	return e.Lateness() == 0
+}
+
+// Lateness returns how long after the deadline standup was submitted
+func (e ReportEntry) Lateness() time.Duration {
+	if e.Status != StatusSubmitted || e.Deadline.IsZero() || !e.Submitted.After(e.Deadline) {
+		return 0
+	}
+	return e.Submitted.Sub(e.Deadline)
 }
 
 //ReportBodyContent used to generate report body content
@@ -35,51 +76,239 @@ type ReportBodyContent struct {
 
 // NewReporter creates a new reporter instance
 func NewReporter(slack *chat.Slack) *Reporter {
-	reporter := &Reporter{s: slack, db: slack.DB, conf: slack.Conf}
+	reporter := &Reporter{s: slack, db: slack.DB, conf: slack.Config()}
 	return reporter
 }
 
+// Localized returns a copy of the reporter which renders reports with the translation
+func (r *Reporter) Localized(t config.Translate) *Reporter {
+	lr := *r
+	lr.conf.Translate = t
+	return &lr
+}
+
+// config returns current configuration, which changes when the config file is reloaded
+func (r *Reporter) config() config.Config {
+	if r.s == nil {
+		return r.conf
+	}
+	return r.s.Config()
+}
+
+// forChannel returns reporter which renders reports in language of the channel
+func (r *Reporter) forChannel(channelID string) *Reporter {
+	if r.s == nil {
+		return r
+	}
+	return r.Localized(r.s.Translation(channelID, ""))
+}
+
 // Start starts all team monitoring treads
 func (r *Reporter) Start() {
-	gocron.Every(1).Day().At(r.conf.ReportTime).Do(r.displayYesterdayTeamReport)
+	gocron.Every(1).Minute().Do(r.DisplayTeamReportsOnTime)
+	gocron.Every(1).Minute().Do(metrics.Job("thread_summaries", r.displayThreadSummaries))
+	gocron.Every(1).Minute().Do(metrics.Job("digests", r.sendDigests))
 }
 
-// teamReport generates report on users who submit standups
-func (r *Reporter) displayYesterdayTeamReport() {
-	var allReports []slack.Attachment
+// teamReport is the channel reports_sent keeps the team report under
+const teamReport = ""
 
+// DisplayTeamReportsOnTime sends the team report at report time of the config and reports
+// of channels which set their own report time or recipients at their report time. It is checked every minute instead of being scheduled once,
+// so that report time changed in the config file or in channel settings applies at once.
+// Reports are built in background, so that the scheduler is not blocked
+func (r *Reporter) DisplayTeamReportsOnTime() {
+	now := time.Now()
 	channels, err := r.db.GetAllChannels()
 	if err != nil {
 		logrus.Errorf("GetAllChannels failed: %v", err)
 		return
 	}
-
 	for _, channel := range channels {
-		var attachments []slack.Attachment
+		if channel.Archived {
+			continue
+		}
+		settings := r.s.ChannelSettings(channel.ChannelID)
+		if !settings.ChannelReport() {
+			continue
+		}
+		if !r.reportDue(channel.ChannelID, settings.ReportTime, now) {
+			continue
+		}
+		channel, recipients := channel, settings.ReportRecipients
+		go metrics.Job("channel_report", func() { r.displayYesterdayChannelReport(channel, recipients) })()
+	}
+	if r.reportDue(teamReport, r.config().ReportTime, now) {
+		go metrics.Job("team_report", r.displayYesterdayTeamReport)()
+	}
+}
 
-		channelMembers, err := r.db.ListChannelMembers(channel.ChannelID)
+// reportDue reports whether daily report of the channel should be sent now. Like digests, the report
+// is marked as sent before it is built, so the next tick does not send it twice, and a report whose
+// minute was skipped by the scheduler is sent on the next tick
+func (r *Reporter) reportDue(channelID, reportTime string, now time.Time) bool {
+	lastSent, err := r.db.LastReportSent(channelID)
+	if err != nil {
+		logrus.Errorf("reporting: LastReportSent failed: %v\n", err)
+		return false
+	}
+	if lastSent.IsZero() {
+		// the first check only remembers the time, so a report sent before the upgrade is not repeated
+		if err := r.db.UpdateReportSent(channelID, now); err != nil {
+			logrus.Errorf("reporting: UpdateReportSent failed: %v\n", err)
+		}
+		return false
+	}
+	if !dueAt(reportTime, lastSent, now) {
+		return false
+	}
+	if err := r.db.UpdateReportSent(channelID, now); err != nil {
+		logrus.Errorf("reporting: UpdateReportSent failed: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// dueAt reports whether hh:mm time has come today and nothing has been sent since
+func dueAt(clock string, lastSent, now time.Time) bool {
+	hour, min, err := utils.FormatTime(clock)
+	if err != nil {
+		return false
+	}
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, now.Location())
+	return !now.Before(scheduled) && lastSent.Before(scheduled)
+}
+
+// displayThreadSummaries posts summaries into standup threads of channels which deadline has passed.
+// Threads remember that summary is posted, so a minute skipped by the scheduler does not lose it
+func (r *Reporter) displayThreadSummaries() {
+	channels, err := r.db.GetAllChannels()
+	if err != nil {
+		logrus.Errorf("GetAllChannels failed: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, channel := range channels {
+		if !channel.Threaded || channel.StandupTime == 0 || channel.Archived {
+			continue
+		}
+		deadline := time.Unix(channel.StandupTime, 0)
+		if now.Before(time.Date(now.Year(), now.Month(), now.Day(), deadline.Hour(), deadline.Minute(), 0, 0, now.Location())) {
+			continue
+		}
+		thread, err := r.db.SelectStandupThread(channel.ChannelID, now)
 		if err != nil {
-			logrus.Errorf("ListChannelMembers failed for channel %v: %v", channel.ChannelName, err)
+			logrus.Debugf("No standup thread today in %v: %v", channel.ChannelName, err)
 			continue
 		}
+		if thread.SummaryPosted {
+			continue
+		}
+		// thread is marked before summary is posted, so the next tick does not post it twice
+		thread.SummaryPosted = true
+		thread, err = r.db.UpdateStandupThread(thread)
+		if err != nil {
+			logrus.Errorf("UpdateStandupThread failed for channel %v: %v", channel.ChannelName, err)
+			continue
+		}
+		summary, err := r.forChannel(channel.ChannelID).ThreadSummary(channel)
+		if err != nil {
+			logrus.Errorf("ThreadSummary failed for channel %v: %v", channel.ChannelName, err)
+			continue
+		}
+		r.s.SendThreadMessage(channel.ChannelID, thread.ThreadTS, summary)
+	}
+}
 
-		if len(channelMembers) == 0 {
-			logrus.Infof("Skip %v channel", channel.ChannelID)
+// ThreadSummary lists members who did and did not submit standups in channel today
+func (r *Reporter) ThreadSummary(channel model.Channel) (string, error) {
+	dateFrom := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.Local)
+	members, err := r.db.ListChannelMembers(channel.ChannelID)
+	if err != nil {
+		return "", err
+	}
+	var submitted, missed []string
+	for _, member := range members {
+		if member.RoleInChannel == "pm" || !r.db.MemberShouldBeTracked(member.ID, dateFrom) {
+			continue
+		}
+		if r.db.SubmittedStandupToday(member.UserID, channel.ChannelID) {
+			submitted = append(submitted, fmt.Sprintf("<@%v>", member.UserID))
 			continue
 		}
+		missed = append(missed, fmt.Sprintf("<@%v>", member.UserID))
+	}
 
-		for _, member := range channelMembers {
-			attachment := r.generateReportAttachment(member, channel)
-			if len(attachment.Fields) == 0 {
-				continue
-			}
-			attachment.Text = fmt.Sprintf(r.conf.Translate.IsRook, member.UserID, channel.ChannelName)
+	text := fmt.Sprintf(r.conf.Translate.ThreadSummaryHeader, dateFrom.Format("2006-01-02"))
+	if len(submitted) != 0 {
+		text += fmt.Sprintf(r.conf.Translate.ThreadSummarySubmitted, strings.Join(submitted, ", "))
+	}
+	if len(missed) != 0 {
+		text += fmt.Sprintf(r.conf.Translate.ThreadSummaryMissed, strings.Join(missed, ", "))
+	}
+	if len(submitted) == 0 && len(missed) == 0 {
+		text += r.conf.Translate.ReportNoData
+	}
+	return text, nil
+}
+
+// displayYesterdayChannelReport sends report on members of the channel to recipients of its reports
+func (r *Reporter) displayYesterdayChannelReport(channel model.Channel, recipients []string) {
+	cr := r.forChannel(channel.ChannelID)
+	attachments, ok := cr.channelReportAttachments(channel)
+	if !ok {
+		return
+	}
+	for _, recipient := range recipients {
+		r.s.SendMessage(recipient, cr.conf.Translate.ReportHeader, attachments)
+	}
+	r.emitReportGenerated(channel)
+}
 
-			attachments = append(attachments, attachment)
+// channelReportAttachments generates report attachments on members of the channel,
+// channels without members are skipped
+func (r *Reporter) channelReportAttachments(channel model.Channel) ([]slack.Attachment, bool) {
+	var attachments []slack.Attachment
+	channelMembers, err := r.db.ListChannelMembers(channel.ChannelID)
+	if err != nil {
+		logrus.Errorf("ListChannelMembers failed for channel %v: %v", channel.ChannelName, err)
+		return nil, false
+	}
+
+	if len(channelMembers) == 0 {
+		logrus.Infof("Skip %v channel", channel.ChannelID)
+		return nil, false
+	}
+
+	for _, member := range channelMembers {
+		attachment := r.generateReportAttachment(member, channel)
+		if len(attachment.Fields) == 0 {
+			continue
 		}
+		attachment.Text = fmt.Sprintf(r.conf.Translate.IsRook, member.UserID, channel.ChannelName)
+
+		attachments = append(attachments, attachment)
+	}
+	return attachments, true
+}
 
-		r.s.SendMessage(channel.ChannelID, r.conf.Translate.ReportHeader, attachments)
+// displayYesterdayTeamReport sends report on members of all channels to the reporting channel
+func (r *Reporter) displayYesterdayTeamReport() {
+	var allReports []slack.Attachment
+
+	channels, err := r.db.GetAllChannels()
+	if err != nil {
+		logrus.Errorf("GetAllChannels failed: %v", err)
+		return
+	}
 
+	reportingChannel := r.config().ReportingChannel
+	rr := r.forChannel(reportingChannel)
+	for _, channel := range channels {
+		if channel.Archived {
+			continue
+		}
+		attachments, _ := rr.channelReportAttachments(channel)
 		allReports = append(allReports, attachments...)
 	}
 
@@ -87,7 +316,31 @@ func (r *Reporter) displayYesterdayTeamReport() {
 		return
 	}
 
-	r.s.SendMessage(r.conf.ReportingChannel, r.conf.Translate.ReportHeader, allReports)
+	r.s.SendMessage(reportingChannel, rr.conf.Translate.ReportHeader, allReports)
+}
+
+// DailyReport is payload of report.generated webhook event
+type DailyReport struct {
+	ChannelID   string        `json:"channel_id"`
+	ChannelName string        `json:"channel_name"`
+	Date        string        `json:"date"`
+	Entries     []ExportEntry `json:"entries"`
+}
+
+// emitReportGenerated notifies webhooks about daily report of the channel for yesterday
+func (r *Reporter) emitReportGenerated(channel model.Channel) {
+	yesterday := time.Now().AddDate(0, 0, -1)
+	report, err := r.StandupReportByProject(channel, yesterday, yesterday)
+	if err != nil {
+		logrus.Errorf("StandupReportByProject failed for channel %v: %v", channel.ChannelName, err)
+		return
+	}
+	r.s.EmitEvent(model.EventReportGenerated, channel.ChannelID, DailyReport{
+		ChannelID:   channel.ChannelID,
+		ChannelName: channel.ChannelName,
+		Date:        yesterday.Format("2006-01-02"),
+		Entries:     report.ExportEntries(),
+	})
 }
 
 func (r *Reporter) generateReportAttachment(member model.ChannelMember, project model.Channel) slack.Attachment {
@@ -98,16 +351,51 @@ func (r *Reporter) generateReportAttachment(member model.ChannelMember, project
 	startDateTime := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.Local)
 	endDateTime := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 23, 59, 59, 0, time.Local)
 
-	isNonReporter, err := r.db.IsNonReporter(member.UserID, member.ChannelID, startDateTime, endDateTime)
+	entry, err := r.reportEntry(member, project.ChannelName, startDateTime, endDateTime)
 	if err != nil {
-		logrus.Infof("User is non reporter failed: %v", err)
+		logrus.Infof("reportEntry failed: %v", err)
 	}
+	isNonReporter := err == nil && entry.Status == StatusMissed
 
 	fieldValue, points := r.prepareAttachment(member, isNonReporter)
+	if entry.Status == StatusSubmitted {
+		fieldValue += r.punctuality(entry)
+	}
 
 	return r.generateAttachment(fieldValue, points)
 }
 
+// punctuality tells whether standup was submitted on time or how many minutes late it was
+func (r *Reporter) punctuality(entry ReportEntry) string {
+	if entry.Deadline.IsZero() {
+		return ""
+	}
+	if entry.OnTime() {
+		return r.conf.Translate.StandupOnTime
+	}
+	return fmt.Sprintf(r.conf.Translate.StandupLate, r.conf.Translate.Plural(config.PluralMinutes, minutes(entry.Lateness())))
+}
+
+// punctualitySummary aggregates punctuality of every member in every channel of report entries
+func (r *Reporter) punctualitySummary(entries []ReportEntry) string {
+	text := ""
+	for _, s := range CollectStats(entries) {
+		if s.Submitted == 0 {
+			continue
+		}
+		text += fmt.Sprintf(r.conf.Translate.PunctualityMember, s.UserID, s.ChannelName, s.OnTime, s.Submitted, s.PunctualityRate(), s.AverageLatenessMinutes())
+	}
+	if text == "" {
+		return ""
+	}
+	return r.conf.Translate.PunctualityHead + text
+}
+
+// minutes rounds duration up to whole minutes
+func minutes(d time.Duration) int64 {
+	return int64((d + time.Minute - 1) / time.Minute)
+}
+
 func (r *Reporter) prepareAttachment(user model.ChannelMember, isNonReporter bool) (string, int) {
 	var standup string
 	var points int
@@ -171,21 +459,17 @@ func (r *Reporter) StandupReportByProject(channel model.Channel, dateFrom, dateT
 				logrus.Infof("member should not be tracked: %v", member.UserID)
 				continue
 			}
-			userIsNonReporter, err := r.db.IsNonReporter(member.UserID, channel.ChannelID, dateFrom, dateTo)
+			entry, err := r.reportEntry(member, channel.ChannelName, dateFrom, dateTo)
 			if err != nil {
-				logrus.Errorf("reporting.go reportByProject IsNonReporter failed: %v", err)
+				logrus.Errorf("reporting: reportByProject reportEntry failed: %v", err)
 				continue
 			}
-			if userIsNonReporter {
+			report.Entries = append(report.Entries, entry)
+			if entry.Status == StatusMissed {
 				dayInfo += fmt.Sprintf(r.conf.Translate.UserDidNotStandup, member.UserID)
 			} else {
-				standup, err := r.db.SelectStandupsFiltered(member.UserID, channel.ChannelID, dateFrom, dateTo)
-				if err != nil {
-					logrus.Errorf("reporting:SelectStandupsFiltered failed: %v", err)
-					continue
-				}
 				dayInfo += fmt.Sprintf(r.conf.Translate.UserDidStandup, member.UserID)
-				dayInfo += fmt.Sprintf("%v \n", standup.Comment)
+				dayInfo += fmt.Sprintf("%v \n", entry.Standup)
 			}
 			dayInfo += "================================================\n"
 		}
@@ -197,6 +481,7 @@ func (r *Reporter) StandupReportByProject(channel model.Channel, dateFrom, dateT
 		}
 
 	}
+	report.ReportTail = r.punctualitySummary(report.Entries)
 	return report, nil
 }
 
@@ -231,20 +516,17 @@ func (r *Reporter) StandupReportByUser(slackUserID string, dateFrom, dateTo time
 				logrus.Infof("member should not be tracked: %v", slackUserID)
 				continue
 			}
-			userIsNonReporter, err := r.db.IsNonReporter(slackUserID, channel, dateFrom, dateTo)
+			entry, err := r.reportEntry(member, channelName, dateFrom, dateTo)
 			if err != nil {
-				logrus.Errorf("reporting.go reportByUser IsNonReporter failed: %v", err)
+				logrus.Errorf("reporting.go reportByUser reportEntry failed: %v", err)
 				continue
 			}
-			if userIsNonReporter {
+			report.Entries = append(report.Entries, entry)
+			if entry.Status == StatusMissed {
 				dayInfo += fmt.Sprintf(r.conf.Translate.UserDidNotStandupInChannel, channelName, slackUserID)
 			} else {
-				standup, err := r.db.SelectStandupsFiltered(slackUserID, channel, dateFrom, dateTo)
-				if err != nil {
-					logrus.Errorf("reporting.go reportByUser SelectStandupsFiltered failed: %v", err)
-				}
 				dayInfo += fmt.Sprintf(r.conf.Translate.UserDidStandupInChannel, channelName, slackUserID)
-				dayInfo += fmt.Sprintf("%v \n", standup.Comment)
+				dayInfo += fmt.Sprintf("%v \n", entry.Standup)
 			}
 			dayInfo += "================================================\n"
 		}
@@ -255,6 +537,7 @@ func (r *Reporter) StandupReportByUser(slackUserID string, dateFrom, dateTo time
 			report.ReportBody = append(report.ReportBody, rbc)
 		}
 	}
+	report.ReportTail = r.punctualitySummary(report.Entries)
 	return report, nil
 }
 
@@ -280,22 +563,18 @@ func (r *Reporter) StandupReportByProjectAndUser(channel model.Channel, slackUse
 			logrus.Infof("member should not be tracked: %v", slackUserID)
 			continue
 		}
-		userIsNonReporter, err := r.db.IsNonReporter(slackUserID, channel.ChannelID, dateFrom, dateTo)
+		entry, err := r.reportEntry(member, channel.ChannelName, dateFrom, dateTo)
 		if err != nil {
-			logrus.Errorf("reporting.go reportByProjectAndUser IsNonReporter failed: %v", err)
+			logrus.Errorf("reporting.go reportByProjectAndUser reportEntry failed: %v", err)
 			continue
 		}
-		if userIsNonReporter {
+		report.Entries = append(report.Entries, entry)
+		if entry.Status == StatusMissed {
 			dayInfo += fmt.Sprintf(r.conf.Translate.UserDidNotStandup, slackUserID)
 			dayInfo += "\n"
 		} else {
-			standup, err := r.db.SelectStandupsFiltered(slackUserID, channel.ChannelID, dateFrom, dateTo)
-			if err != nil {
-				logrus.Errorf("reporting.go reportByProjectAndUser SelectStandupsFiltered failed: %v", err)
-				continue
-			}
 			dayInfo += fmt.Sprintf(r.conf.Translate.UserDidStandup, slackUserID)
-			dayInfo += fmt.Sprintf("%v \n", standup.Comment)
+			dayInfo += fmt.Sprintf("%v \n", entry.Standup)
 		}
 		if dayInfo != "" {
 			text := fmt.Sprintf(r.conf.Translate.ReportDate, dateFrom.Format("2006-01-02"))
@@ -304,5 +583,59 @@ func (r *Reporter) StandupReportByProjectAndUser(channel model.Channel, slackUse
 			report.ReportBody = append(report.ReportBody, rbc)
 		}
 	}
+	report.ReportTail = r.punctualitySummary(report.Entries)
 	return report, nil
 }
+
+// reportEntry collects standup of the channel member submitted in time period
+func (r *Reporter) reportEntry(member model.ChannelMember, channelName string, dateFrom, dateTo time.Time) (ReportEntry, error) {
+	entry := ReportEntry{
+		Date:        dateFrom,
+		ChannelID:   member.ChannelID,
+		ChannelName: channelName,
+		UserID:      member.UserID,
+		Status:      StatusMissed,
+		Deadline:    r.deadline(member, dateFrom),
+	}
+	if user, err := r.db.SelectUser(member.UserID); err == nil {
+		entry.UserName = user.UserName
+	}
+	userIsNonReporter, err := r.db.IsNonReporter(member.UserID, member.ChannelID, dateFrom, dateTo)
+	if err != nil {
+		return entry, err
+	}
+	if userIsNonReporter {
+		return entry, nil
+	}
+	standup, err := r.db.SelectStandupsFiltered(member.UserID, member.ChannelID, dateFrom, dateTo)
+	if err != nil {
+		return entry, err
+	}
+	entry.Status = StatusSubmitted
+	entry.Submitted = standup.Created
+	entry.Standup = standup.Comment
+	return entry, nil
+}
+
+// deadline returns standup deadline of the member on the day from individual timetable
+// or channel standup time. Zero time is returned if member has no deadline
+func (r *Reporter) deadline(member model.ChannelMember, day time.Time) time.Time {
+	deadline := int64(0)
+	if r.db.MemberHasTimeTable(member.ID) {
+		timetable, err := r.db.SelectTimeTable(member.ID)
+		if err == nil {
+			deadline = timetable.ShowDeadlineOn(strings.ToLower(day.Weekday().String()))
+		}
+	}
+	if deadline == 0 {
+		channel, err := r.db.SelectChannel(member.ChannelID)
+		if err == nil {
+			deadline = channel.StandupTime
+		}
+	}
+	if deadline == 0 {
+		return time.Time{}
+	}
+	t := time.Unix(deadline, 0)
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, time.Local)
+}
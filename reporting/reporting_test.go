@@ -224,6 +224,30 @@ func TestStandupReportByProjectAndUser(t *testing.T) {
 	assert.NoError(t, r.db.DeleteChannelMember(user1.UserID, user1.ChannelID))
 	assert.NoError(t, r.db.DeleteChannel(channel.ID))
 }
+func TestPunctuality(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	c.Translate.StandupOnTime = "on time"
+	c.Translate.StandupLate = "%v late"
+	c.Translate.PunctualityHead = "Punctuality:\n"
+	c.Translate.PunctualityMember = "<@%v> in #%v: %v of %v on time (%v%%), %v minutes late on average\n"
+	r := &Reporter{conf: c}
+
+	deadline := time.Date(2018, 6, 4, 10, 0, 0, 0, time.UTC)
+	onTime := ReportEntry{ChannelName: "chan", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline.Add(-time.Minute), Deadline: deadline}
+	late := ReportEntry{ChannelName: "chan", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline.Add(90 * time.Second), Deadline: deadline}
+	noDeadline := ReportEntry{ChannelName: "chan", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline}
+	missed := ReportEntry{ChannelName: "chan", UserID: "userID2", Status: StatusMissed, Deadline: deadline}
+
+	assert.Equal(t, "on time", r.punctuality(onTime))
+	assert.Equal(t, "2 minutes late", r.punctuality(late))
+	assert.Equal(t, "", r.punctuality(noDeadline))
+	assert.Equal(t, time.Duration(0), missed.Lateness())
+
+	assert.Equal(t, "Punctuality:\n<@userID1> in #chan: 2 of 3 on time (66%), 2 minutes late on average\n", r.punctualitySummary([]ReportEntry{onTime, late, noDeadline, missed}))
+	assert.Equal(t, "", r.punctualitySummary([]ReportEntry{missed}))
+}
+
 func TestPrepareAttachment(t *testing.T) {
 	c, err := config.Get()
 	assert.NoError(t, err)
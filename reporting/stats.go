@@ -0,0 +1,210 @@
+package reporting
+
+import (
+	"sort"
+	"time"
+
+	"github.com/maddevsio/comedian/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// MemberStats summarizes standups of a member in a channel over a period
+type MemberStats struct {
+	ChannelID   string
+	ChannelName string
+	UserID      string
+	UserName    string
+	// Days is the number of days member was expected to submit standup
+	Days      int
+	Submitted int
+	OnTime    int
+	// Late is the number of standups submitted after the deadline, Lateness is their total delay
+	Late     int
+	Lateness time.Duration
+	// Streak is the number of days in a row with standups at the end of the period
+	Streak        int
+	LongestStreak int
+	Missed        []time.Time
+}
+
+// UserStats summarizes standups of a user in all channels over a period. Days, Submitted, OnTime
+// and Late count standups expected in every channel, while streaks are counted in days
+type UserStats struct {
+	MemberStats
+	// Channels are names of channels user is tracked in
+	Channels []string
+	// Excused is the number of days user was not expected to submit standups in any channel according to timetables
+	Excused int
+}
+
+// SubmissionRate returns percentage of days with submitted standups
+func (s MemberStats) SubmissionRate() int {
+	return percent(s.Submitted, s.Days)
+}
+
+// OnTimeRate returns percentage of days with standups submitted before the deadline
+func (s MemberStats) OnTimeRate() int {
+	return percent(s.OnTime, s.Days)
+}
+
+// PunctualityRate returns percentage of submitted standups which were on time
+func (s MemberStats) PunctualityRate() int {
+	return percent(s.OnTime, s.Submitted)
+}
+
+// AverageLateness returns average delay of late standups
+func (s MemberStats) AverageLateness() time.Duration {
+	if s.Late == 0 {
+		return 0
+	}
+	return s.Lateness / time.Duration(s.Late)
+}
+
+// AverageLatenessMinutes returns average delay of late standups rounded up to minutes
+func (s MemberStats) AverageLatenessMinutes() int64 {
+	return minutes(s.AverageLateness())
+}
+
+// CollectStats summarizes report entries per channel member. Entries are expected to be ordered by date
+func CollectStats(entries []ReportEntry) []MemberStats {
+	stats := []MemberStats{}
+	index := map[string]int{}
+	for _, entry := range entries {
+		key := entry.ChannelID + "/" + entry.UserID
+		i, ok := index[key]
+		if !ok {
+			i = len(stats)
+			index[key] = i
+			stats = append(stats, MemberStats{
+				ChannelID:   entry.ChannelID,
+				ChannelName: entry.ChannelName,
+				UserID:      entry.UserID,
+				UserName:    entry.UserName,
+			})
+		}
+		s := &stats[i]
+		s.Days++
+		if entry.Status != StatusSubmitted {
+			s.Streak = 0
+			s.Missed = append(s.Missed, entry.Date)
+			continue
+		}
+		s.Submitted++
+		if entry.OnTime() {
+			s.OnTime++
+		} else {
+			s.Late++
+			s.Lateness += entry.Lateness()
+		}
+		s.Streak++
+		if s.Streak > s.LongestStreak {
+			s.LongestStreak = s.Streak
+		}
+	}
+	return stats
+}
+
+func percent(part, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
+
+// UserStats computes statistics of the user standups in all channels for the period
+func (r *Reporter) UserStats(userID string, dateFrom, dateTo time.Time) (UserStats, error) {
+	stats := UserStats{}
+	stats.UserID = userID
+	dateFromBegin, numberOfDays, err := utils.SetupDays(dateFrom, dateTo)
+	if err != nil {
+		return stats, err
+	}
+	channels, err := r.db.GetUserChannels(userID)
+	if err != nil {
+		return stats, err
+	}
+	entries := []ReportEntry{}
+	excused := map[time.Time]bool{}
+	tracked := map[time.Time]bool{}
+	for _, channelID := range channels {
+		channelName, err := r.db.GetChannelName(channelID)
+		if err != nil {
+			logrus.Errorf("reporting: UserStats GetChannelName failed: %v", err)
+			continue
+		}
+		member, err := r.db.FindChannelMemberByUserID(userID, channelID)
+		if err != nil {
+			logrus.Infof("FindChannelMemberByUserID failed: %v", err)
+			continue
+		}
+		stats.Channels = append(stats.Channels, channelName)
+		for day := 0; day <= numberOfDays; day++ {
+			dateFrom := dateFromBegin.AddDate(0, 0, day)
+			if !r.db.MemberShouldBeTracked(member.ID, dateFrom) {
+				excused[dateFrom] = true
+				continue
+			}
+			tracked[dateFrom] = true
+			entry, err := r.reportEntry(member, channelName, dateFrom, dateFrom.Add(24*time.Hour))
+			if err != nil {
+				logrus.Errorf("reporting: UserStats reportEntry failed: %v", err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	stats.MemberStats = combineStats(entries)
+	stats.UserID = userID
+	stats.Excused = excusedDays(excused, tracked)
+	return stats, nil
+}
+
+// excusedDays counts days off, on which user is not tracked in any of the channels
+func excusedDays(excused, tracked map[time.Time]bool) int {
+	days := 0
+	for date := range excused {
+		if !tracked[date] {
+			days++
+		}
+	}
+	return days
+}
+
+// combineStats summarizes entries of a user in several channels. A day continues the streak
+// only if standups are submitted in every channel user is tracked in on that day
+func combineStats(entries []ReportEntry) MemberStats {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Date.Before(entries[j].Date) })
+	s := MemberStats{}
+	for i, entry := range entries {
+		s.Days++
+		if entry.Status == StatusSubmitted {
+			s.Submitted++
+			if entry.OnTime() {
+				s.OnTime++
+			} else {
+				s.Late++
+				s.Lateness += entry.Lateness()
+			}
+		}
+		if i+1 < len(entries) && entries[i+1].Date.Equal(entry.Date) {
+			continue
+		}
+		// the last entry of the day, check every standup of the day
+		complete := true
+		for j := i; j >= 0 && entries[j].Date.Equal(entry.Date); j-- {
+			if entries[j].Status != StatusSubmitted {
+				complete = false
+			}
+		}
+		if !complete {
+			s.Streak = 0
+			s.Missed = append(s.Missed, entry.Date)
+			continue
+		}
+		s.Streak++
+		if s.Streak > s.LongestStreak {
+			s.LongestStreak = s.Streak
+		}
+	}
+	return s
+}
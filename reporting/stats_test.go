@@ -0,0 +1,86 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, 6, d, 0, 0, 0, 0, time.UTC) }
+	deadline := func(d int) time.Time { return time.Date(2018, 6, d, 10, 0, 0, 0, time.UTC) }
+	entries := []ReportEntry{
+		{Date: day(4), ChannelID: "chanid", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline(4).Add(-time.Hour), Deadline: deadline(4)},
+		{Date: day(4), ChannelID: "chanid", UserID: "userID2", Status: StatusMissed, Deadline: deadline(4)},
+		{Date: day(5), ChannelID: "chanid", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline(5).Add(time.Hour), Deadline: deadline(5)},
+		{Date: day(5), ChannelID: "chanid", UserID: "userID2", Status: StatusSubmitted, Submitted: deadline(5)},
+		{Date: day(6), ChannelID: "chanid", UserID: "userID1", Status: StatusMissed, Deadline: deadline(6)},
+		{Date: day(6), ChannelID: "chanid", UserID: "userID2", Status: StatusSubmitted, Submitted: deadline(6)},
+		{Date: day(7), ChannelID: "chanid", UserID: "userID1", Status: StatusSubmitted, Submitted: deadline(7)},
+	}
+
+	stats := CollectStats(entries)
+	assert.Equal(t, 2, len(stats))
+
+	assert.Equal(t, "userID1", stats[0].UserID)
+	assert.Equal(t, 4, stats[0].Days)
+	assert.Equal(t, 3, stats[0].Submitted)
+	assert.Equal(t, 2, stats[0].OnTime)
+	assert.Equal(t, 1, stats[0].Streak)
+	assert.Equal(t, 2, stats[0].LongestStreak)
+	assert.Equal(t, []time.Time{day(6)}, stats[0].Missed)
+	assert.Equal(t, 75, stats[0].SubmissionRate())
+	assert.Equal(t, 50, stats[0].OnTimeRate())
+	assert.Equal(t, 66, stats[0].PunctualityRate())
+	assert.Equal(t, 1, stats[0].Late)
+	assert.Equal(t, time.Hour, stats[0].AverageLateness())
+
+	assert.Equal(t, "userID2", stats[1].UserID)
+	assert.Equal(t, 3, stats[1].Days)
+	assert.Equal(t, 2, stats[1].Streak)
+	assert.Equal(t, 66, stats[1].OnTimeRate())
+
+	assert.Equal(t, time.Duration(0), stats[1].AverageLateness())
+	assert.Equal(t, 0, MemberStats{}.SubmissionRate())
+}
+
+func TestCombineStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, 6, d, 0, 0, 0, 0, time.UTC) }
+	deadline := func(d int) time.Time { return time.Date(2018, 6, d, 10, 0, 0, 0, time.UTC) }
+	entries := []ReportEntry{
+		{Date: day(4), ChannelID: "chan1", Status: StatusSubmitted, Submitted: deadline(4), Deadline: deadline(4)},
+		{Date: day(5), ChannelID: "chan1", Status: StatusSubmitted, Submitted: deadline(5).Add(30 * time.Minute), Deadline: deadline(5)},
+		{Date: day(6), ChannelID: "chan1", Status: StatusSubmitted, Submitted: deadline(6), Deadline: deadline(6)},
+		{Date: day(4), ChannelID: "chan2", Status: StatusSubmitted, Submitted: deadline(4), Deadline: deadline(4)},
+		{Date: day(5), ChannelID: "chan2", Status: StatusMissed, Deadline: deadline(5)},
+		{Date: day(6), ChannelID: "chan2", Status: StatusSubmitted, Submitted: deadline(6).Add(10 * time.Minute), Deadline: deadline(6)},
+	}
+
+	s := combineStats(entries)
+	assert.Equal(t, 6, s.Days)
+	assert.Equal(t, 5, s.Submitted)
+	assert.Equal(t, 3, s.OnTime)
+	assert.Equal(t, 2, s.Late)
+	assert.Equal(t, 20*time.Minute, s.AverageLateness())
+	assert.Equal(t, 1, s.Streak)
+	assert.Equal(t, 1, s.LongestStreak)
+	assert.Equal(t, []time.Time{day(5)}, s.Missed)
+
+	assert.Equal(t, MemberStats{}, combineStats(nil))
+}
+
+func TestExcusedDays(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2018, 6, d, 0, 0, 0, 0, time.UTC) }
+	// chan1 does not track user on 9th and 10th, chan2 tracks user on 9th only
+	excused := map[time.Time]bool{day(9): true, day(10): true}
+	tracked := map[time.Time]bool{day(9): true}
+	assert.Equal(t, 1, excusedDays(excused, tracked))
+
+	// both channels do not track user on 10th
+	excused = map[time.Time]bool{day(10): true}
+	tracked = map[time.Time]bool{}
+	assert.Equal(t, 1, excusedDays(excused, tracked))
+
+	assert.Equal(t, 0, excusedDays(map[time.Time]bool{}, tracked))
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/maddevsio/comedian/metrics"
+)
+
+var queryDuration = metrics.NewHistogram("comedian_db_query_duration_seconds", "Duration of database queries in seconds, by statement and table.", metrics.DefaultBuckets, "operation", "table")
+
+var tableName = regexp.MustCompile("(?i)(?:from|into|update)\\s+`?([a-z_]+)`?")
+
+// instrumentedDB measures latency of queries made through it
+type instrumentedDB struct {
+	*sqlx.DB
+}
+
+// Exec executes query and records its duration
+func (db instrumentedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(time.Now(), query)
+	return db.DB.Exec(query, args...)
+}
+
+// Get selects single row into dest and records query duration
+func (db instrumentedDB) Get(dest interface{}, query string, args ...interface{}) error {
+	defer observeQuery(time.Now(), query)
+	return db.DB.Get(dest, query, args...)
+}
+
+// Select selects rows into dest and records query duration
+func (db instrumentedDB) Select(dest interface{}, query string, args ...interface{}) error {
+	defer observeQuery(time.Now(), query)
+	return db.DB.Select(dest, query, args...)
+}
+
+func observeQuery(start time.Time, query string) {
+	operation, table := queryLabels(query)
+	queryDuration.ObserveSince(start, operation, table)
+}
+
+// queryLabels returns statement, like select or update, and the first table the query reads or writes
+func queryLabels(query string) (string, string) {
+	operation := "unknown"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToLower(fields[0])
+	}
+	table := "unknown"
+	if match := tableName.FindStringSubmatch(query); match != nil {
+		table = match[1]
+	}
+	return operation, table
+}
@@ -1,7 +1,11 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"io/ioutil"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,7 +19,7 @@ import (
 
 // MySQL provides api for work with mysql database
 type MySQL struct {
-	conn *sqlx.DB
+	conn instrumentedDB
 }
 
 // NewMySQL creates a new instance of database API
@@ -26,7 +30,7 @@ func NewMySQL(c config.Config) (*MySQL, error) {
 	}
 	conn.SetConnMaxLifetime(time.Second)
 	m := &MySQL{}
-	m.conn = conn
+	m.conn = instrumentedDB{conn}
 
 	return m, nil
 }
@@ -347,6 +351,20 @@ func (m *MySQL) GetChannels() ([]model.Channel, error) {
 	return c, err
 }
 
+// UpdateChannel updates Channel entry in database
+func (m *MySQL) UpdateChannel(c model.Channel) (model.Channel, error) {
+	_, err := m.conn.Exec(
+		"UPDATE `channels` SET channel_name=?, channel_standup_time=?, interview=?, threaded=?, archived=?, language=? WHERE id=?",
+		c.ChannelName, c.StandupTime, c.Interview, c.Threaded, c.Archived, c.Language, c.ID,
+	)
+	if err != nil {
+		return c, err
+	}
+	var i model.Channel
+	err = m.conn.Get(&i, "SELECT * FROM `channels` WHERE id=?", c.ID)
+	return i, err
+}
+
 // DeleteChannel deletes Channel entry from database
 func (m *MySQL) DeleteChannel(id int64) error {
 	_, err := m.conn.Exec("DELETE FROM `channels` WHERE id=?", id)
@@ -374,8 +392,8 @@ func (m *MySQL) CreateUser(c model.User) (model.User, error) {
 // UpdateUser updates User entry in database
 func (m *MySQL) UpdateUser(c model.User) (model.User, error) {
 	_, err := m.conn.Exec(
-		"UPDATE `users` SET role=? WHERE id=?",
-		c.Role, c.ID,
+		"UPDATE `users` SET role=?, language=? WHERE id=?",
+		c.Role, c.Language, c.ID,
 	)
 	if err != nil {
 		return c, err
@@ -535,3 +553,759 @@ func (m *MySQL) MemberShouldBeTracked(id int64, date time.Time) bool {
 
 	return false
 }
+
+// CreateStandupRules creates standup rules entry in database
+func (m *MySQL) CreateStandupRules(r model.StandupRules) (model.StandupRules, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `standup_rules` (channel_id, sections, min_length, ticket_pattern, created, modified) VALUES (?, ?, ?, ?, ?, ?)",
+		r.ChannelID, r.Sections, r.MinLength, r.TicketPattern, time.Now().UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		return r, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return r, err
+	}
+	r.ID = id
+
+	return r, nil
+}
+
+// UpdateStandupRules updates standup rules entry in database
+func (m *MySQL) UpdateStandupRules(r model.StandupRules) (model.StandupRules, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE `standup_rules` SET sections=?, min_length=?, ticket_pattern=?, modified=? WHERE id=?",
+		r.Sections, r.MinLength, r.TicketPattern, time.Now().UTC(), r.ID,
+	)
+	if err != nil {
+		return r, err
+	}
+	var i model.StandupRules
+	err = m.conn.Get(&i, "SELECT * FROM `standup_rules` WHERE id=?", r.ID)
+	return i, err
+}
+
+// SelectStandupRules selects standup rules entry for channel from database
+func (m *MySQL) SelectStandupRules(channelID string) (model.StandupRules, error) {
+	var r model.StandupRules
+	err := m.conn.Get(&r, "SELECT * FROM `standup_rules` WHERE channel_id=?", channelID)
+	return r, err
+}
+
+// DeleteStandupRules deletes standup rules entry for channel from database
+func (m *MySQL) DeleteStandupRules(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM `standup_rules` WHERE channel_id=?", channelID)
+	return err
+}
+
+// CreateInterview creates interview entry in database
+func (m *MySQL) CreateInterview(i model.Interview) (model.Interview, error) {
+	err := i.Validate()
+	if err != nil {
+		return i, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `interviews` (channel_id, user_id, step, answers, created, modified) VALUES (?, ?, ?, ?, ?, ?)",
+		i.ChannelID, i.UserID, i.Step, i.Answers, time.Now().UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		return i, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return i, err
+	}
+	i.ID = id
+
+	return i, nil
+}
+
+// UpdateInterview updates interview entry in database
+func (m *MySQL) UpdateInterview(i model.Interview) (model.Interview, error) {
+	_, err := m.conn.Exec(
+		"UPDATE `interviews` SET step=?, answers=?, modified=? WHERE id=?",
+		i.Step, i.Answers, time.Now().UTC(), i.ID,
+	)
+	if err != nil {
+		return i, err
+	}
+	var updated model.Interview
+	err = m.conn.Get(&updated, "SELECT * FROM `interviews` WHERE id=?", i.ID)
+	return updated, err
+}
+
+// ListUserInterviews returns interviews pending for user ordered by creation
+func (m *MySQL) ListUserInterviews(userID string) ([]model.Interview, error) {
+	items := []model.Interview{}
+	err := m.conn.Select(&items, "SELECT * FROM `interviews` WHERE user_id=? ORDER BY id", userID)
+	return items, err
+}
+
+// ListInterviews returns all pending interviews
+func (m *MySQL) ListInterviews() ([]model.Interview, error) {
+	items := []model.Interview{}
+	err := m.conn.Select(&items, "SELECT * FROM `interviews`")
+	return items, err
+}
+
+// DeleteInterview deletes interview entry from database
+func (m *MySQL) DeleteInterview(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `interviews` WHERE id=?", id)
+	return err
+}
+
+// CreateStandupThread creates standup thread entry in database
+func (m *MySQL) CreateStandupThread(t model.StandupThread) (model.StandupThread, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	t.Created = time.Now()
+	res, err := m.conn.Exec(
+		"INSERT INTO `standup_threads` (channel_id, thread_ts, created) VALUES (?, ?, ?)",
+		t.ChannelID, t.ThreadTS, t.Created,
+	)
+	if err != nil {
+		return t, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return t, err
+	}
+	t.ID = id
+
+	return t, nil
+}
+
+// SelectStandupThread selects standup thread started in channel on the date
+func (m *MySQL) SelectStandupThread(channelID string, date time.Time) (model.StandupThread, error) {
+	var t model.StandupThread
+	dateFrom := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	dateTo := dateFrom.Add(24 * time.Hour)
+	err := m.conn.Get(&t, "SELECT * FROM `standup_threads` WHERE channel_id=? AND created BETWEEN ? AND ? ORDER BY id DESC LIMIT 1", channelID, dateFrom, dateTo)
+	return t, err
+}
+
+// SelectStandupThreadByTS selects standup thread entry by timestamp of its parent message
+func (m *MySQL) SelectStandupThreadByTS(threadTS string) (model.StandupThread, error) {
+	var t model.StandupThread
+	err := m.conn.Get(&t, "SELECT * FROM `standup_threads` WHERE thread_ts=?", threadTS)
+	return t, err
+}
+
+// UpdateStandupThread updates standup thread entry in database
+func (m *MySQL) UpdateStandupThread(t model.StandupThread) (model.StandupThread, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	_, err = m.conn.Exec("UPDATE `standup_threads` SET summary_posted=? WHERE id=?", t.SummaryPosted, t.ID)
+	if err != nil {
+		return t, err
+	}
+	var updated model.StandupThread
+	err = m.conn.Get(&updated, "SELECT * FROM `standup_threads` WHERE id=?", t.ID)
+	return updated, err
+}
+
+// DeleteStandupThread deletes standup thread entry from database
+func (m *MySQL) DeleteStandupThread(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `standup_threads` WHERE id=?", id)
+	return err
+}
+
+// CreateBlocker creates blocker entry in database
+func (m *MySQL) CreateBlocker(b model.Blocker) (model.Blocker, error) {
+	err := b.Validate()
+	if err != nil {
+		return b, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `blockers` (channel_id, user_id, standup_id, message_ts, text, resolved, resolved_by, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		b.ChannelID, b.UserID, b.StandupID, b.MessageTS, b.Text, b.Resolved, b.ResolvedBy, time.Now().UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		return b, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return b, err
+	}
+	b.ID = id
+
+	return b, nil
+}
+
+// UpdateBlocker updates blocker entry in database
+func (m *MySQL) UpdateBlocker(b model.Blocker) (model.Blocker, error) {
+	err := b.Validate()
+	if err != nil {
+		return b, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE `blockers` SET text=?, resolved=?, resolved_by=?, modified=? WHERE id=?",
+		b.Text, b.Resolved, b.ResolvedBy, time.Now().UTC(), b.ID,
+	)
+	if err != nil {
+		return b, err
+	}
+	var updated model.Blocker
+	err = m.conn.Get(&updated, "SELECT * FROM `blockers` WHERE id=?", b.ID)
+	return updated, err
+}
+
+// SelectBlocker selects blocker entry from database
+func (m *MySQL) SelectBlocker(id int64) (model.Blocker, error) {
+	var b model.Blocker
+	err := m.conn.Get(&b, "SELECT * FROM `blockers` WHERE id=?", id)
+	return b, err
+}
+
+// SelectBlockerByStandup selects blocker reported in the standup
+func (m *MySQL) SelectBlockerByStandup(standupID int64) (model.Blocker, error) {
+	var b model.Blocker
+	err := m.conn.Get(&b, "SELECT * FROM `blockers` WHERE standup_id=?", standupID)
+	return b, err
+}
+
+// ListOpenBlockers returns unresolved blockers of the channel
+func (m *MySQL) ListOpenBlockers(channelID string) ([]model.Blocker, error) {
+	items := []model.Blocker{}
+	err := m.conn.Select(&items, "SELECT * FROM `blockers` WHERE channel_id=? AND resolved=FALSE ORDER BY id", channelID)
+	return items, err
+}
+
+// DeleteBlocker deletes blocker entry from database
+func (m *MySQL) DeleteBlocker(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `blockers` WHERE id=?", id)
+	return err
+}
+
+// CreateOutboundMessage creates outbound message entry in database
+func (m *MySQL) CreateOutboundMessage(o model.OutboundMessage) (model.OutboundMessage, error) {
+	err := o.Validate()
+	if err != nil {
+		return o, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `outbound_messages` (kind, channel_id, user_id, thread_ts, text, attachments, status, attempts, last_error, next_attempt, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		o.Kind, o.ChannelID, o.UserID, o.ThreadTS, o.Text, o.Attachments, o.Status, o.Attempts, o.LastError, o.NextAttempt, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return o, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return o, err
+	}
+	o.ID = id
+
+	return o, nil
+}
+
+// UpdateOutboundMessage updates outbound message entry in database
+func (m *MySQL) UpdateOutboundMessage(o model.OutboundMessage) (model.OutboundMessage, error) {
+	_, err := m.conn.Exec(
+		"UPDATE `outbound_messages` SET status=?, attempts=?, last_error=?, next_attempt=?, modified=? WHERE id=?",
+		o.Status, o.Attempts, o.LastError, o.NextAttempt, time.Now(), o.ID,
+	)
+	if err != nil {
+		return o, err
+	}
+	var updated model.OutboundMessage
+	err = m.conn.Get(&updated, "SELECT * FROM `outbound_messages` WHERE id=?", o.ID)
+	return updated, err
+}
+
+// ListDueOutboundMessages returns pending outbound messages which should be delivered by the time
+func (m *MySQL) ListDueOutboundMessages(t time.Time, limit int) ([]model.OutboundMessage, error) {
+	items := []model.OutboundMessage{}
+	err := m.conn.Select(&items, "SELECT * FROM `outbound_messages` WHERE status='pending' AND next_attempt<=? ORDER BY id LIMIT ?", t, limit)
+	return items, err
+}
+
+// ListOutboundMessagesByStatus returns latest outbound messages with the status
+func (m *MySQL) ListOutboundMessagesByStatus(status string, limit int) ([]model.OutboundMessage, error) {
+	items := []model.OutboundMessage{}
+	err := m.conn.Select(&items, "SELECT * FROM `outbound_messages` WHERE status=? ORDER BY id DESC LIMIT ?", status, limit)
+	return items, err
+}
+
+// CountOutboundMessages returns number of outbound messages per status
+func (m *MySQL) CountOutboundMessages() (map[string]int, error) {
+	rows := []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}{}
+	counts := map[string]int{}
+	err := m.conn.Select(&rows, "SELECT status, COUNT(*) AS count FROM `outbound_messages` GROUP BY status")
+	if err != nil {
+		return counts, err
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteOutboundMessages deletes processed outbound messages modified before the time
+func (m *MySQL) DeleteOutboundMessages(t time.Time) error {
+	_, err := m.conn.Exec("DELETE FROM `outbound_messages` WHERE status<>'pending' AND modified<?", t)
+	return err
+}
+
+// CreateDigest creates digest entry in database
+func (m *MySQL) CreateDigest(d model.Digest) (model.Digest, error) {
+	err := d.Validate()
+	if err != nil {
+		return d, err
+	}
+	if d.LastSent.IsZero() {
+		d.LastSent = time.Now()
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `digests` (channel_id, period, time, recipients, last_sent, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		d.ChannelID, d.Period, d.Time, d.Recipients, d.LastSent, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return d, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return d, err
+	}
+	d.ID = id
+
+	return d, nil
+}
+
+// UpdateDigest updates digest entry in database
+func (m *MySQL) UpdateDigest(d model.Digest) (model.Digest, error) {
+	err := d.Validate()
+	if err != nil {
+		return d, err
+	}
+	_, err = m.conn.Exec(
+		"UPDATE `digests` SET period=?, time=?, recipients=?, last_sent=?, modified=? WHERE id=?",
+		d.Period, d.Time, d.Recipients, d.LastSent, time.Now(), d.ID,
+	)
+	if err != nil {
+		return d, err
+	}
+	var updated model.Digest
+	err = m.conn.Get(&updated, "SELECT * FROM `digests` WHERE id=?", d.ID)
+	return updated, err
+}
+
+// SelectDigest selects digest entry from database
+func (m *MySQL) SelectDigest(id int64) (model.Digest, error) {
+	var d model.Digest
+	err := m.conn.Get(&d, "SELECT * FROM `digests` WHERE id=?", id)
+	return d, err
+}
+
+// ListDigests returns all digests
+func (m *MySQL) ListDigests() ([]model.Digest, error) {
+	items := []model.Digest{}
+	err := m.conn.Select(&items, "SELECT * FROM `digests` ORDER BY id")
+	return items, err
+}
+
+// DeleteDigest deletes digest entry from database
+func (m *MySQL) DeleteDigest(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `digests` WHERE id=?", id)
+	return err
+}
+
+// CreateAPIToken creates API token entry in database
+func (m *MySQL) CreateAPIToken(t model.APIToken) (model.APIToken, error) {
+	err := t.Validate()
+	if err != nil {
+		return t, err
+	}
+	t.Created = time.Now()
+	res, err := m.conn.Exec(
+		"INSERT INTO `api_tokens` (name, token_hash, role, channel_id, created) VALUES (?, ?, ?, ?, ?)",
+		t.Name, t.TokenHash, t.Role, t.ChannelID, t.Created,
+	)
+	if err != nil {
+		return t, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return t, err
+	}
+	t.ID = id
+
+	return t, nil
+}
+
+// SelectAPITokenByHash selects API token entry by hash of the token
+func (m *MySQL) SelectAPITokenByHash(hash string) (model.APIToken, error) {
+	var t model.APIToken
+	err := m.conn.Get(&t, "SELECT * FROM `api_tokens` WHERE token_hash=?", hash)
+	return t, err
+}
+
+// ListAPITokens returns all API tokens
+func (m *MySQL) ListAPITokens() ([]model.APIToken, error) {
+	items := []model.APIToken{}
+	err := m.conn.Select(&items, "SELECT * FROM `api_tokens` ORDER BY id")
+	return items, err
+}
+
+// DeleteAPIToken deletes API token entry from database
+func (m *MySQL) DeleteAPIToken(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `api_tokens` WHERE id=?", id)
+	return err
+}
+
+// CreateWebhook creates webhook entry in database
+func (m *MySQL) CreateWebhook(w model.Webhook) (model.Webhook, error) {
+	err := w.Validate()
+	if err != nil {
+		return w, err
+	}
+	res, err := m.conn.Exec(
+		"INSERT INTO `webhooks` (url, secret, events, channel_id, created, modified) VALUES (?, ?, ?, ?, ?, ?)",
+		w.URL, w.Secret, w.Events, w.ChannelID, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return w, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return w, err
+	}
+	w.ID = id
+
+	return w, nil
+}
+
+// SelectWebhook selects webhook entry from database
+func (m *MySQL) SelectWebhook(id int64) (model.Webhook, error) {
+	var w model.Webhook
+	err := m.conn.Get(&w, "SELECT * FROM `webhooks` WHERE id=?", id)
+	return w, err
+}
+
+// ListWebhooks returns all webhooks
+func (m *MySQL) ListWebhooks() ([]model.Webhook, error) {
+	items := []model.Webhook{}
+	err := m.conn.Select(&items, "SELECT * FROM `webhooks` ORDER BY id")
+	return items, err
+}
+
+// DeleteWebhook deletes webhook entry and its deliveries from database
+func (m *MySQL) DeleteWebhook(id int64) error {
+	_, err := m.conn.Exec("DELETE FROM `webhook_deliveries` WHERE webhook_id=?", id)
+	if err != nil {
+		return err
+	}
+	_, err = m.conn.Exec("DELETE FROM `webhooks` WHERE id=?", id)
+	return err
+}
+
+// CreateWebhookDelivery creates webhook delivery entry in database
+func (m *MySQL) CreateWebhookDelivery(d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	res, err := m.conn.Exec(
+		"INSERT INTO `webhook_deliveries` (webhook_id, event, payload, status, attempts, response_code, last_error, next_attempt, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		d.WebhookID, d.Event, d.Payload, d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttempt, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return d, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return d, err
+	}
+	d.ID = id
+
+	return d, nil
+}
+
+// UpdateWebhookDelivery updates webhook delivery entry in database
+func (m *MySQL) UpdateWebhookDelivery(d model.WebhookDelivery) (model.WebhookDelivery, error) {
+	_, err := m.conn.Exec(
+		"UPDATE `webhook_deliveries` SET status=?, attempts=?, response_code=?, last_error=?, next_attempt=?, modified=? WHERE id=?",
+		d.Status, d.Attempts, d.ResponseCode, d.LastError, d.NextAttempt, time.Now(), d.ID,
+	)
+	if err != nil {
+		return d, err
+	}
+	var updated model.WebhookDelivery
+	err = m.conn.Get(&updated, "SELECT * FROM `webhook_deliveries` WHERE id=?", d.ID)
+	return updated, err
+}
+
+// ListDueWebhookDeliveries returns pending webhook deliveries which should be attempted by the time.
+// Deliveries queued after a pending delivery of the same webhook which is not due yet wait for it,
+// so that events reach the webhook in the order they happened
+func (m *MySQL) ListDueWebhookDeliveries(t time.Time, limit int) ([]model.WebhookDelivery, error) {
+	items := []model.WebhookDelivery{}
+	err := m.conn.Select(&items, "SELECT * FROM `webhook_deliveries` d WHERE d.status='pending' AND d.next_attempt<=? AND NOT EXISTS (SELECT 1 FROM `webhook_deliveries` e WHERE e.webhook_id=d.webhook_id AND e.status='pending' AND e.id<d.id AND e.next_attempt>?) ORDER BY d.id LIMIT ?", t, t, limit)
+	return items, err
+}
+
+// ListWebhookDeliveries returns latest deliveries of the webhook
+func (m *MySQL) ListWebhookDeliveries(webhookID int64, limit int) ([]model.WebhookDelivery, error) {
+	items := []model.WebhookDelivery{}
+	err := m.conn.Select(&items, "SELECT * FROM `webhook_deliveries` WHERE webhook_id=? ORDER BY id DESC LIMIT ?", webhookID, limit)
+	return items, err
+}
+
+// DeleteWebhookDeliveries deletes processed webhook deliveries modified before the time
+func (m *MySQL) DeleteWebhookDeliveries(t time.Time) error {
+	_, err := m.conn.Exec("DELETE FROM `webhook_deliveries` WHERE status<>'pending' AND modified<?", t)
+	return err
+}
+
+// CreateRole creates custom role entry in database
+func (m *MySQL) CreateRole(r model.Role) (model.Role, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	r.Created = time.Now()
+	res, err := m.conn.Exec(
+		"INSERT INTO `roles` (name, permissions, created) VALUES (?, ?, ?)",
+		r.Name, r.Permissions, r.Created,
+	)
+	if err != nil {
+		return r, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return r, err
+	}
+	r.ID = id
+
+	return r, nil
+}
+
+// UpdateRole updates custom role entry in database
+func (m *MySQL) UpdateRole(r model.Role) (model.Role, error) {
+	err := r.Validate()
+	if err != nil {
+		return r, err
+	}
+	_, err = m.conn.Exec("UPDATE `roles` SET permissions=? WHERE id=?", r.Permissions, r.ID)
+	return r, err
+}
+
+// SelectRole selects custom role entry by its name
+func (m *MySQL) SelectRole(name string) (model.Role, error) {
+	var r model.Role
+	err := m.conn.Get(&r, "SELECT * FROM `roles` WHERE name=?", name)
+	return r, err
+}
+
+// ListRoles returns all custom roles
+func (m *MySQL) ListRoles() ([]model.Role, error) {
+	items := []model.Role{}
+	err := m.conn.Select(&items, "SELECT * FROM `roles` ORDER BY name")
+	return items, err
+}
+
+// DeleteRole deletes custom role entry and its bindings from database
+func (m *MySQL) DeleteRole(name string) error {
+	_, err := m.conn.Exec("DELETE FROM `role_bindings` WHERE role=?", name)
+	if err != nil {
+		return err
+	}
+	_, err = m.conn.Exec("DELETE FROM `roles` WHERE name=?", name)
+	return err
+}
+
+// CreateRoleBinding creates role binding entry in database
+func (m *MySQL) CreateRoleBinding(b model.RoleBinding) (model.RoleBinding, error) {
+	err := b.Validate()
+	if err != nil {
+		return b, err
+	}
+	b.Created = time.Now()
+	res, err := m.conn.Exec(
+		"INSERT INTO `role_bindings` (user_id, role, channel_id, created) VALUES (?, ?, ?, ?)",
+		b.UserID, b.Role, b.ChannelID, b.Created,
+	)
+	if err != nil {
+		return b, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return b, err
+	}
+	b.ID = id
+
+	return b, nil
+}
+
+// ListRoleBindings returns all role bindings
+func (m *MySQL) ListRoleBindings() ([]model.RoleBinding, error) {
+	items := []model.RoleBinding{}
+	err := m.conn.Select(&items, "SELECT * FROM `role_bindings` ORDER BY user_id, channel_id, role")
+	return items, err
+}
+
+// ListUserRoleBindings returns role bindings of the user
+func (m *MySQL) ListUserRoleBindings(userID string) ([]model.RoleBinding, error) {
+	items := []model.RoleBinding{}
+	err := m.conn.Select(&items, "SELECT * FROM `role_bindings` WHERE user_id=? ORDER BY channel_id, role", userID)
+	return items, err
+}
+
+// DeleteRoleBinding deletes role binding entry from database
+func (m *MySQL) DeleteRoleBinding(userID, role, channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM `role_bindings` WHERE user_id=? AND role=? AND channel_id=?", userID, role, channelID)
+	return err
+}
+
+// CreateMemberSync creates member sync entry in database, replacing sync of the channel if there is one
+func (m *MySQL) CreateMemberSync(ms model.MemberSync) (model.MemberSync, error) {
+	err := ms.Validate()
+	if err != nil {
+		return ms, err
+	}
+	_, err = m.conn.Exec("DELETE FROM `member_syncs` WHERE channel_id=?", ms.ChannelID)
+	if err != nil {
+		return ms, err
+	}
+	ms.Created = time.Now()
+	res, err := m.conn.Exec(
+		"INSERT INTO `member_syncs` (channel_id, usergroup_id, usergroup_handle, last_synced, created) VALUES (?, ?, ?, ?, ?)",
+		ms.ChannelID, ms.UserGroupID, ms.UserGroupHandle, ms.LastSynced, ms.Created,
+	)
+	if err != nil {
+		return ms, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return ms, err
+	}
+	ms.ID = id
+
+	return ms, nil
+}
+
+// SelectMemberSync selects member sync entry of the channel
+func (m *MySQL) SelectMemberSync(channelID string) (model.MemberSync, error) {
+	var ms model.MemberSync
+	err := m.conn.Get(&ms, "SELECT * FROM `member_syncs` WHERE channel_id=?", channelID)
+	return ms, err
+}
+
+// ListMemberSyncs returns all member syncs
+func (m *MySQL) ListMemberSyncs() ([]model.MemberSync, error) {
+	items := []model.MemberSync{}
+	err := m.conn.Select(&items, "SELECT * FROM `member_syncs` ORDER BY id")
+	return items, err
+}
+
+// UpdateMemberSync updates member sync entry in database
+func (m *MySQL) UpdateMemberSync(ms model.MemberSync) (model.MemberSync, error) {
+	_, err := m.conn.Exec(
+		"UPDATE `member_syncs` SET usergroup_handle=?, last_synced=? WHERE id=?",
+		ms.UserGroupHandle, ms.LastSynced, ms.ID,
+	)
+	return ms, err
+}
+
+// DeleteMemberSync deletes member sync entry of the channel
+func (m *MySQL) DeleteMemberSync(channelID string) error {
+	_, err := m.conn.Exec("DELETE FROM `member_syncs` WHERE channel_id=?", channelID)
+	return err
+}
+
+// SetChannelSetting creates or updates setting of the channel
+func (m *MySQL) SetChannelSetting(cs model.ChannelSetting) (model.ChannelSetting, error) {
+	err := cs.Validate()
+	if err != nil {
+		return cs, err
+	}
+	_, err = m.conn.Exec(
+		"INSERT INTO `channel_settings` (channel_id, name, value, created, modified) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE value=VALUES(value), modified=VALUES(modified)",
+		cs.ChannelID, cs.Name, cs.Value, time.Now().UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		return cs, err
+	}
+	var i model.ChannelSetting
+	err = m.conn.Get(&i, "SELECT * FROM `channel_settings` WHERE channel_id=? AND name=?", cs.ChannelID, cs.Name)
+	return i, err
+}
+
+// ListChannelSettings returns settings of the channel
+func (m *MySQL) ListChannelSettings(channelID string) ([]model.ChannelSetting, error) {
+	items := []model.ChannelSetting{}
+	err := m.conn.Select(&items, "SELECT * FROM `channel_settings` WHERE channel_id=? ORDER BY id", channelID)
+	return items, err
+}
+
+// DeleteChannelSetting deletes setting of the channel, so that it is inherited again
+func (m *MySQL) DeleteChannelSetting(channelID, name string) error {
+	_, err := m.conn.Exec("DELETE FROM `channel_settings` WHERE channel_id=? AND name=?", channelID, name)
+	return err
+}
+
+// LastReportSent returns when the daily report of the channel was sent, empty channel means the team report.
+// Zero time is returned if the report has never been sent
+func (m *MySQL) LastReportSent(channelID string) (time.Time, error) {
+	var lastSent time.Time
+	err := m.conn.Get(&lastSent, "SELECT last_sent FROM `reports_sent` WHERE channel_id=?", channelID)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return lastSent, err
+}
+
+// UpdateReportSent saves when the daily report of the channel was sent
+func (m *MySQL) UpdateReportSent(channelID string, sent time.Time) error {
+	_, err := m.conn.Exec(
+		"INSERT INTO `reports_sent` (channel_id, last_sent) VALUES (?, ?) ON DUPLICATE KEY UPDATE last_sent=VALUES(last_sent)",
+		channelID, sent,
+	)
+	return err
+}
+
+// Ping checks database connection is alive
+func (m *MySQL) Ping(ctx context.Context) error {
+	return m.conn.PingContext(ctx)
+}
+
+// MigrationVersion returns version of the latest migration applied by goose
+func (m *MySQL) MigrationVersion() (int64, error) {
+	var version int64
+	err := m.conn.Get(&version, "SELECT COALESCE(MAX(version_id), 0) FROM `goose_db_version` WHERE is_applied=1")
+	return version, err
+}
+
+// LatestMigration returns version of the newest migration in goose migrations directory
+func LatestMigration(dir string) (int64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+			continue
+		}
+		version, err := strconv.ParseInt(strings.SplitN(file.Name(), "_", 2)[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
@@ -346,6 +346,11 @@ func TestCRUDUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "admin", user.Role)
 
+	user.Language = "ru"
+	updated, err := db.UpdateUser(user)
+	assert.NoError(t, err)
+	assert.Equal(t, "ru", updated.Language)
+
 	users, err := db.ListUsers()
 	assert.NoError(t, err)
 	for _, user := range users {
@@ -465,3 +470,455 @@ func TestCRUDTimeTable(t *testing.T) {
 	assert.NoError(t, db.DeleteChannelMember(user.UserID, channel.ChannelID))
 	assert.NoError(t, db.DeleteTimeTable(tts.ID))
 }
+
+func TestCRUDStandupRules(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateStandupRules(model.StandupRules{})
+	assert.Error(t, err)
+
+	rules := model.StandupRules{ChannelID: "QWERTY123", MinLength: 10}
+	assert.NoError(t, rules.SetSections([]model.StandupSection{{Name: "today", Keywords: []string{"today"}}}))
+	rules, err = db.CreateStandupRules(rules)
+	assert.NoError(t, err)
+
+	selected, err := db.SelectStandupRules("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, rules.ID, selected.ID)
+	sections, err := selected.ListSections()
+	assert.NoError(t, err)
+	assert.Equal(t, []model.StandupSection{{Name: "today", Keywords: []string{"today"}}}, sections)
+
+	selected.TicketPattern = "[A-Z]+-[0-9]+"
+	selected.MinLength = -1
+	_, err = db.UpdateStandupRules(selected)
+	assert.Error(t, err)
+
+	selected.MinLength = 20
+	updated, err := db.UpdateStandupRules(selected)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, updated.MinLength)
+	assert.Equal(t, "[A-Z]+-[0-9]+", updated.TicketPattern)
+
+	assert.NoError(t, db.DeleteStandupRules("QWERTY123"))
+	_, err = db.SelectStandupRules("QWERTY123")
+	assert.Error(t, err)
+}
+
+func TestCRUDInterview(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateInterview(model.Interview{UserID: "userID1"})
+	assert.Error(t, err)
+
+	i1, err := db.CreateInterview(model.Interview{UserID: "userID1", ChannelID: "QWERTY123"})
+	assert.NoError(t, err)
+	i2, err := db.CreateInterview(model.Interview{UserID: "userID1", ChannelID: "QWERTY456"})
+	assert.NoError(t, err)
+
+	interviews, err := db.ListUserInterviews("userID1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(interviews))
+	assert.Equal(t, i1.ID, interviews[0].ID)
+
+	assert.NoError(t, i1.AddAnswer("did a lot"))
+	i1, err = db.UpdateInterview(i1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, i1.Step)
+	answers, err := i1.ListAnswers()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"did a lot"}, answers)
+
+	interviews, err = db.ListInterviews()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(interviews))
+
+	assert.NoError(t, db.DeleteInterview(i1.ID))
+	assert.NoError(t, db.DeleteInterview(i2.ID))
+}
+
+func TestCRUDStandupThread(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateStandupThread(model.StandupThread{ChannelID: "QWERTY123"})
+	assert.Error(t, err)
+
+	thread, err := db.CreateStandupThread(model.StandupThread{ChannelID: "QWERTY123", ThreadTS: "1530000000.000100"})
+	assert.NoError(t, err)
+
+	selected, err := db.SelectStandupThread("QWERTY123", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, thread.ID, selected.ID)
+
+	_, err = db.SelectStandupThread("QWERTY123", time.Now().AddDate(0, 0, -1))
+	assert.Error(t, err)
+
+	selected, err = db.SelectStandupThreadByTS("1530000000.000100")
+	assert.NoError(t, err)
+	assert.Equal(t, "QWERTY123", selected.ChannelID)
+	assert.Equal(t, false, selected.SummaryPosted)
+
+	selected.SummaryPosted = true
+	updated, err := db.UpdateStandupThread(selected)
+	assert.NoError(t, err)
+	assert.Equal(t, true, updated.SummaryPosted)
+
+	assert.NoError(t, db.DeleteStandupThread(thread.ID))
+	_, err = db.SelectStandupThreadByTS("1530000000.000100")
+	assert.Error(t, err)
+}
+
+func TestCRUDBlocker(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateBlocker(model.Blocker{UserID: "userID1", ChannelID: "QWERTY123"})
+	assert.Error(t, err)
+
+	b, err := db.CreateBlocker(model.Blocker{UserID: "userID1", ChannelID: "QWERTY123", StandupID: 1, Text: "CI is down"})
+	assert.NoError(t, err)
+
+	selected, err := db.SelectBlockerByStandup(1)
+	assert.NoError(t, err)
+	assert.Equal(t, b.ID, selected.ID)
+
+	blockers, err := db.ListOpenBlockers("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(blockers))
+
+	b.Resolved = true
+	b.ResolvedBy = "pmID"
+	b, err = db.UpdateBlocker(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "pmID", b.ResolvedBy)
+
+	blockers, err = db.ListOpenBlockers("QWERTY123")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(blockers))
+
+	assert.NoError(t, db.DeleteBlocker(b.ID))
+	_, err = db.SelectBlocker(b.ID)
+	assert.Error(t, err)
+}
+
+func TestCRUDOutboundMessage(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateOutboundMessage(model.OutboundMessage{Kind: "message", Status: "pending"})
+	assert.Error(t, err)
+
+	m, err := db.CreateOutboundMessage(model.OutboundMessage{Kind: "message", Status: "pending", ChannelID: "QWERTY123", Text: "Hey!", NextAttempt: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+
+	due, err := db.ListDueOutboundMessages(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, m.ID, due[0].ID)
+
+	m.Status = "sent"
+	m.Attempts = 2
+	m, err = db.UpdateOutboundMessage(m)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m.Attempts)
+
+	counts, err := db.CountOutboundMessages()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counts["sent"])
+
+	assert.NoError(t, db.DeleteOutboundMessages(time.Now().Add(time.Minute)))
+	sent, err := db.ListOutboundMessagesByStatus("sent", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(sent))
+}
+
+func TestCRUDDigest(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateDigest(model.Digest{ChannelID: "QWERTY123", Period: "daily", Time: "10:00", Recipients: "QWERTY123"})
+	assert.Error(t, err)
+
+	d, err := db.CreateDigest(model.Digest{ChannelID: "QWERTY123", Period: model.DigestWeekly, Time: "10:00", Recipients: "QWERTY123,userID1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"QWERTY123", "userID1"}, d.RecipientsList())
+
+	d.Period = model.DigestMonthly
+	d, err = db.UpdateDigest(d)
+	assert.NoError(t, err)
+	assert.Equal(t, model.DigestMonthly, d.Period)
+
+	digests, err := db.ListDigests()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(digests))
+
+	assert.NoError(t, db.DeleteDigest(d.ID))
+	_, err = db.SelectDigest(d.ID)
+	assert.Error(t, err)
+}
+
+func TestCRUDAPIToken(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateAPIToken(model.APIToken{Name: "hr", TokenHash: "hash", Role: "owner"})
+	assert.Error(t, err)
+	_, err = db.CreateAPIToken(model.APIToken{Name: "hr", TokenHash: "hash", Role: model.APIRolePM})
+	assert.Error(t, err)
+
+	token, err := db.CreateAPIToken(model.APIToken{Name: "hr", TokenHash: "hash", Role: model.APIRoleViewer})
+	assert.NoError(t, err)
+
+	selected, err := db.SelectAPITokenByHash("hash")
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, selected.ID)
+	assert.Equal(t, model.APIRoleViewer, selected.Role)
+
+	tokens, err := db.ListAPITokens()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(tokens))
+
+	assert.NoError(t, db.DeleteAPIToken(token.ID))
+	_, err = db.SelectAPITokenByHash("hash")
+	assert.Error(t, err)
+}
+
+func TestCRUDWebhook(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateWebhook(model.Webhook{URL: "ftp://example.com", Secret: "secret", Events: model.EventStandupCreated})
+	assert.Error(t, err)
+	_, err = db.CreateWebhook(model.Webhook{URL: "https://example.com", Secret: "secret", Events: "standup.exploded"})
+	assert.Error(t, err)
+
+	w, err := db.CreateWebhook(model.Webhook{URL: "https://example.com", Secret: "secret", Events: "standup.created,report.generated"})
+	assert.NoError(t, err)
+
+	selected, err := db.SelectWebhook(w.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.EventStandupCreated, model.EventReportGenerated}, selected.EventsList())
+
+	webhooks, err := db.ListWebhooks()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(webhooks))
+
+	d, err := db.CreateWebhookDelivery(model.WebhookDelivery{WebhookID: w.ID, Event: model.EventStandupCreated, Payload: "{}", Status: "pending", NextAttempt: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+
+	due, err := db.ListDueWebhookDeliveries(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(due))
+
+	// a delivery waits while an earlier delivery to the same webhook is retried later
+	later, err := db.CreateWebhookDelivery(model.WebhookDelivery{WebhookID: w.ID, Event: model.EventReportGenerated, Payload: "{}", Status: "pending", NextAttempt: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+	d.Attempts = 1
+	d.NextAttempt = time.Now().Add(time.Minute)
+	d, err = db.UpdateWebhookDelivery(d)
+	assert.NoError(t, err)
+	due, err = db.ListDueWebhookDeliveries(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(due))
+	due, err = db.ListDueWebhookDeliveries(time.Now().Add(2*time.Minute), 10)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(due)) {
+		assert.Equal(t, []int64{d.ID, later.ID}, []int64{due[0].ID, due[1].ID})
+	}
+	later.Status = "delivered"
+	_, err = db.UpdateWebhookDelivery(later)
+	assert.NoError(t, err)
+
+	d.Status = "delivered"
+	d.Attempts = 1
+	d.ResponseCode = 200
+	d, err = db.UpdateWebhookDelivery(d)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, d.ResponseCode)
+
+	due, err = db.ListDueWebhookDeliveries(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(due))
+
+	deliveries, err := db.ListWebhookDeliveries(w.ID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(deliveries))
+
+	assert.NoError(t, db.DeleteWebhook(w.ID))
+	_, err = db.SelectWebhook(w.ID)
+	assert.Error(t, err)
+	deliveries, err = db.ListWebhookDeliveries(w.ID, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(deliveries))
+}
+
+func TestCRUDRole(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateRole(model.Role{Name: model.RolePM, Permissions: model.PermReportsView})
+	assert.Error(t, err)
+	_, err = db.CreateRole(model.Role{Name: "auditor", Permissions: "reports.steal"})
+	assert.Error(t, err)
+
+	r, err := db.CreateRole(model.Role{Name: "auditor", Permissions: "reports.view, blockers.view"})
+	assert.NoError(t, err)
+	selected, err := db.SelectRole(r.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.PermReportsView, model.PermBlockersView}, selected.PermissionsList())
+	selected.Permissions = model.PermReportsView
+	_, err = db.UpdateRole(selected)
+	assert.NoError(t, err)
+	selected, err = db.SelectRole(r.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.PermReportsView}, selected.PermissionsList())
+
+	_, err = db.CreateRoleBinding(model.RoleBinding{UserID: "USERID", Role: r.Name, ChannelID: "CHANID"})
+	assert.NoError(t, err)
+	_, err = db.CreateRoleBinding(model.RoleBinding{UserID: "USERID", Role: model.RoleAdmin})
+	assert.NoError(t, err)
+	bindings, err := db.ListUserRoleBindings("USERID")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(bindings))
+	assert.Equal(t, true, bindings[0].AppliesTo("OTHERID"))
+	assert.Equal(t, false, bindings[1].AppliesTo("OTHERID"))
+
+	assert.NoError(t, db.DeleteRoleBinding("USERID", model.RoleAdmin, ""))
+	assert.NoError(t, db.DeleteRole(r.Name))
+	bindings, err = db.ListUserRoleBindings("USERID")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(bindings))
+	_, err = db.SelectRole(r.Name)
+	assert.Error(t, err)
+}
+
+func TestCRUDMemberSync(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.CreateMemberSync(model.MemberSync{ChannelID: "CHANID"})
+	assert.Error(t, err)
+
+	ms, err := db.CreateMemberSync(model.MemberSync{ChannelID: "CHANID", UserGroupID: "S123", UserGroupHandle: "frontend", LastSynced: time.Now()})
+	assert.NoError(t, err)
+	ms, err = db.CreateMemberSync(model.MemberSync{ChannelID: "CHANID", UserGroupID: "S456", UserGroupHandle: "backend", LastSynced: time.Now()})
+	assert.NoError(t, err)
+
+	syncs, err := db.ListMemberSyncs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(syncs))
+
+	ms.UserGroupHandle = "backend-team"
+	_, err = db.UpdateMemberSync(ms)
+	assert.NoError(t, err)
+	selected, err := db.SelectMemberSync("CHANID")
+	assert.NoError(t, err)
+	assert.Equal(t, "S456", selected.UserGroupID)
+	assert.Equal(t, "backend-team", selected.UserGroupHandle)
+
+	assert.NoError(t, db.DeleteMemberSync("CHANID"))
+	_, err = db.SelectMemberSync("CHANID")
+	assert.Error(t, err)
+}
+
+func TestChannelSettings(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	_, err = db.SetChannelSetting(model.ChannelSetting{ChannelID: "CHANID", Name: model.SettingMaxReminders, Value: "-1"})
+	assert.Error(t, err)
+	_, err = db.SetChannelSetting(model.ChannelSetting{ChannelID: "CHANID", Name: "unknown", Value: "1"})
+	assert.Error(t, err)
+
+	setting, err := db.SetChannelSetting(model.ChannelSetting{ChannelID: "CHANID", Name: model.SettingMaxReminders, Value: "2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", setting.Value)
+	updated, err := db.SetChannelSetting(model.ChannelSetting{ChannelID: "CHANID", Name: model.SettingMaxReminders, Value: "4"})
+	assert.NoError(t, err)
+	assert.Equal(t, setting.ID, updated.ID)
+	assert.Equal(t, "4", updated.Value)
+	_, err = db.SetChannelSetting(model.ChannelSetting{ChannelID: "CHANID", Name: model.SettingValidation, Value: model.ValidationWarn})
+	assert.NoError(t, err)
+
+	settings, err := db.ListChannelSettings("CHANID")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(settings))
+
+	assert.NoError(t, db.DeleteChannelSetting("CHANID", model.SettingMaxReminders))
+	settings, err = db.ListChannelSettings("CHANID")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(settings))
+	assert.Equal(t, model.SettingValidation, settings[0].Name)
+
+	assert.NoError(t, db.DeleteChannelSetting("CHANID", model.SettingValidation))
+}
+
+func TestReportsSent(t *testing.T) {
+	c, err := config.Get()
+	assert.NoError(t, err)
+	db, err := NewMySQL(c)
+	assert.NoError(t, err)
+
+	lastSent, err := db.LastReportSent("CHANID")
+	assert.NoError(t, err)
+	assert.True(t, lastSent.IsZero())
+
+	sent := time.Date(2018, 6, 25, 18, 0, 0, 0, time.UTC)
+	assert.NoError(t, db.UpdateReportSent("CHANID", sent))
+	assert.NoError(t, db.UpdateReportSent("CHANID", sent.AddDate(0, 0, 1)))
+	lastSent, err = db.LastReportSent("CHANID")
+	assert.NoError(t, err)
+	assert.Equal(t, sent.AddDate(0, 0, 1), lastSent.UTC())
+
+	_, err = db.conn.Exec("DELETE FROM `reports_sent` WHERE channel_id=?", "CHANID")
+	assert.NoError(t, err)
+}
+
+func TestQueryLabels(t *testing.T) {
+	operation, table := queryLabels("SELECT * FROM `standups` WHERE id=?")
+	assert.Equal(t, "select", operation)
+	assert.Equal(t, "standups", table)
+	operation, table = queryLabels("INSERT INTO `channels` (channel_name) VALUES (?)")
+	assert.Equal(t, "insert", operation)
+	assert.Equal(t, "channels", table)
+	operation, table = queryLabels("SELECT comment FROM standups where channel_id='CHANID'")
+	assert.Equal(t, "select", operation)
+	assert.Equal(t, "standups", table)
+	operation, table = queryLabels("")
+	assert.Equal(t, "unknown", operation)
+	assert.Equal(t, "unknown", table)
+}
+
+func TestLatestMigration(t *testing.T) {
+	version, err := LatestMigration("../migrations")
+	assert.NoError(t, err)
+	assert.Equal(t, true, version >= 40)
+
+	_, err = LatestMigration("../no_such_dir")
+	assert.Error(t, err)
+}
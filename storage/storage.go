@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"time"
 
 	"github.com/maddevsio/comedian/model"
@@ -150,4 +151,203 @@ type Storage interface {
 
 	// SelectUser selects User entry from database
 	ListUsers() ([]model.User, error)
+
+	// CreateStandupRules creates standup rules entry in database
+	CreateStandupRules(model.StandupRules) (model.StandupRules, error)
+
+	// UpdateStandupRules updates standup rules entry in database
+	UpdateStandupRules(model.StandupRules) (model.StandupRules, error)
+
+	// SelectStandupRules selects standup rules entry for channel from database
+	SelectStandupRules(string) (model.StandupRules, error)
+
+	// DeleteStandupRules deletes standup rules entry for channel from database
+	DeleteStandupRules(string) error
+
+	// UpdateChannel updates Channel entry in database
+	UpdateChannel(model.Channel) (model.Channel, error)
+
+	// CreateInterview creates interview entry in database
+	CreateInterview(model.Interview) (model.Interview, error)
+
+	// UpdateInterview updates interview entry in database
+	UpdateInterview(model.Interview) (model.Interview, error)
+
+	// ListUserInterviews returns interviews pending for user ordered by creation
+	ListUserInterviews(string) ([]model.Interview, error)
+
+	// ListInterviews returns all pending interviews
+	ListInterviews() ([]model.Interview, error)
+
+	// DeleteInterview deletes interview entry from database
+	DeleteInterview(int64) error
+
+	// CreateStandupThread creates standup thread entry in database
+	CreateStandupThread(model.StandupThread) (model.StandupThread, error)
+
+	// SelectStandupThread selects standup thread started in channel on the date
+	SelectStandupThread(string, time.Time) (model.StandupThread, error)
+
+	// SelectStandupThreadByTS selects standup thread entry by timestamp of its parent message
+	SelectStandupThreadByTS(string) (model.StandupThread, error)
+
+	// UpdateStandupThread updates standup thread entry in database
+	UpdateStandupThread(model.StandupThread) (model.StandupThread, error)
+
+	// DeleteStandupThread deletes standup thread entry from database
+	DeleteStandupThread(int64) error
+
+	// CreateBlocker creates blocker entry in database
+	CreateBlocker(model.Blocker) (model.Blocker, error)
+
+	// UpdateBlocker updates blocker entry in database
+	UpdateBlocker(model.Blocker) (model.Blocker, error)
+
+	// SelectBlocker selects blocker entry from database
+	SelectBlocker(int64) (model.Blocker, error)
+
+	// SelectBlockerByStandup selects blocker reported in the standup
+	SelectBlockerByStandup(int64) (model.Blocker, error)
+
+	// ListOpenBlockers returns unresolved blockers of the channel
+	ListOpenBlockers(string) ([]model.Blocker, error)
+
+	// DeleteBlocker deletes blocker entry from database
+	DeleteBlocker(int64) error
+
+	// CreateOutboundMessage creates outbound message entry in database
+	CreateOutboundMessage(model.OutboundMessage) (model.OutboundMessage, error)
+
+	// UpdateOutboundMessage updates outbound message entry in database
+	UpdateOutboundMessage(model.OutboundMessage) (model.OutboundMessage, error)
+
+	// ListDueOutboundMessages returns pending outbound messages which should be delivered by the time
+	ListDueOutboundMessages(time.Time, int) ([]model.OutboundMessage, error)
+
+	// ListOutboundMessagesByStatus returns latest outbound messages with the status
+	ListOutboundMessagesByStatus(string, int) ([]model.OutboundMessage, error)
+
+	// CountOutboundMessages returns number of outbound messages per status
+	CountOutboundMessages() (map[string]int, error)
+
+	// DeleteOutboundMessages deletes processed outbound messages modified before the time
+	DeleteOutboundMessages(time.Time) error
+
+	// CreateDigest creates digest entry in database
+	CreateDigest(model.Digest) (model.Digest, error)
+
+	// UpdateDigest updates digest entry in database
+	UpdateDigest(model.Digest) (model.Digest, error)
+
+	// SelectDigest selects digest entry from database
+	SelectDigest(int64) (model.Digest, error)
+
+	// ListDigests returns all digests
+	ListDigests() ([]model.Digest, error)
+
+	// DeleteDigest deletes digest entry from database
+	DeleteDigest(int64) error
+
+	// CreateAPIToken creates API token entry in database
+	CreateAPIToken(model.APIToken) (model.APIToken, error)
+
+	// SelectAPITokenByHash selects API token entry by hash of the token
+	SelectAPITokenByHash(string) (model.APIToken, error)
+
+	// ListAPITokens returns all API tokens
+	ListAPITokens() ([]model.APIToken, error)
+
+	// DeleteAPIToken deletes API token entry from database
+	DeleteAPIToken(int64) error
+
+	// CreateWebhook creates webhook entry in database
+	CreateWebhook(model.Webhook) (model.Webhook, error)
+
+	// SelectWebhook selects webhook entry from database
+	SelectWebhook(int64) (model.Webhook, error)
+
+	// ListWebhooks returns all webhooks
+	ListWebhooks() ([]model.Webhook, error)
+
+	// DeleteWebhook deletes webhook entry and its deliveries from database
+	DeleteWebhook(int64) error
+
+	// CreateWebhookDelivery creates webhook delivery entry in database
+	CreateWebhookDelivery(model.WebhookDelivery) (model.WebhookDelivery, error)
+
+	// UpdateWebhookDelivery updates webhook delivery entry in database
+	UpdateWebhookDelivery(model.WebhookDelivery) (model.WebhookDelivery, error)
+
+	// ListDueWebhookDeliveries returns pending webhook deliveries which should be attempted by the time,
+	// in the order they were queued
+	ListDueWebhookDeliveries(time.Time, int) ([]model.WebhookDelivery, error)
+
+	// ListWebhookDeliveries returns latest deliveries of the webhook
+	ListWebhookDeliveries(int64, int) ([]model.WebhookDelivery, error)
+
+	// DeleteWebhookDeliveries deletes processed webhook deliveries modified before the time
+	DeleteWebhookDeliveries(time.Time) error
+
+	// CreateRole creates custom role entry in database
+	CreateRole(model.Role) (model.Role, error)
+
+	// UpdateRole updates custom role entry in database
+	UpdateRole(model.Role) (model.Role, error)
+
+	// SelectRole selects custom role entry by its name
+	SelectRole(string) (model.Role, error)
+
+	// ListRoles returns all custom roles
+	ListRoles() ([]model.Role, error)
+
+	// DeleteRole deletes custom role entry and its bindings from database
+	DeleteRole(string) error
+
+	// CreateRoleBinding creates role binding entry in database
+	CreateRoleBinding(model.RoleBinding) (model.RoleBinding, error)
+
+	// ListRoleBindings returns all role bindings
+	ListRoleBindings() ([]model.RoleBinding, error)
+
+	// ListUserRoleBindings returns role bindings of the user
+	ListUserRoleBindings(string) ([]model.RoleBinding, error)
+
+	// DeleteRoleBinding deletes role binding entry from database
+	DeleteRoleBinding(string, string, string) error
+
+	// CreateMemberSync creates member sync entry in database, replacing sync of the channel if there is one
+	CreateMemberSync(model.MemberSync) (model.MemberSync, error)
+
+	// SelectMemberSync selects member sync entry of the channel
+	SelectMemberSync(string) (model.MemberSync, error)
+
+	// ListMemberSyncs returns all member syncs
+	ListMemberSyncs() ([]model.MemberSync, error)
+
+	// UpdateMemberSync updates member sync entry in database
+	UpdateMemberSync(model.MemberSync) (model.MemberSync, error)
+
+	// DeleteMemberSync deletes member sync entry of the channel
+	DeleteMemberSync(string) error
+
+	// SetChannelSetting creates or updates setting of the channel
+	SetChannelSetting(model.ChannelSetting) (model.ChannelSetting, error)
+
+	// ListChannelSettings returns settings of the channel
+	ListChannelSettings(string) ([]model.ChannelSetting, error)
+
+	// DeleteChannelSetting deletes setting of the channel, so that it is inherited again
+	DeleteChannelSetting(string, string) error
+
+	// LastReportSent returns when the daily report of the channel was sent, empty channel means the team report
+	LastReportSent(string) (time.Time, error)
+
+	// UpdateReportSent saves when the daily report of the channel was sent
+	UpdateReportSent(string, time.Time) error
+
+	// Ping checks database connection is alive
+	Ping(context.Context) error
+
+	// MigrationVersion returns version of the latest migration applied by goose
+	MigrationVersion() (int64, error)
 }